@@ -12,6 +12,7 @@ import (
 
 	"github.com/frallan97/table-planner-backend/internal/config"
 	"github.com/frallan97/table-planner-backend/internal/database"
+	"github.com/frallan97/table-planner-backend/internal/events"
 	"github.com/frallan97/table-planner-backend/internal/handlers"
 	"github.com/frallan97/table-planner-backend/internal/middleware"
 	"github.com/go-chi/chi/v5"
@@ -41,7 +42,15 @@ func main() {
 	}
 	go authMW.StartKeyRefresh(1 * time.Hour)
 
-	h := handlers.New(pool)
+	// Background workers are stopped when the server shuts down
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	broker := events.NewBroker(pool)
+	go broker.Run(bgCtx)
+
+	h := handlers.New(pool).WithEvents(broker)
+	go h.SweepPresence(bgCtx)
 
 	r := chi.NewRouter()
 
@@ -59,6 +68,18 @@ func main() {
 
 	// Public routes (no auth required)
 	r.Get("/public/floor-plans/{token}", h.GetFloorPlanByShareToken)
+	// Rendering is costly, so exports for share links are limited too
+	exportRL := middleware.NewRateLimiter(1, 5)
+	r.With(exportRL.Middleware).Get("/public/floor-plans/{token}/export.pdf", h.ExportPDFByShareToken)
+	r.With(exportRL.Middleware).Get("/public/floor-plans/{token}/export.svg", h.ExportSVGByShareToken)
+	r.With(exportRL.Middleware).Get("/public/floor-plans/{token}/export.png", h.ExportPNGByShareToken)
+	// Name lookups are limited harder to slow down guessing who is on the list
+	lookupRL := middleware.NewRateLimiter(1, 10)
+	r.With(lookupRL.Middleware).Get("/public/floor-plans/{token}/lookup", h.LookupSeatByShareToken)
+	// RSVP links are limited the same way, and each answer writes a version
+	rsvpRL := middleware.NewRateLimiter(1, 10)
+	r.With(rsvpRL.Middleware).Get("/public/rsvp/{token}", h.GetRSVP)
+	r.With(rsvpRL.Middleware).Post("/public/rsvp/{token}", h.SubmitRSVP)
 
 	rl := middleware.NewRateLimiter(10, 20) // 10 req/s, burst 20
 
@@ -71,9 +92,36 @@ func main() {
 			r.Post("/", h.CreateFloorPlan)
 			r.Get("/{id}", h.GetFloorPlan)
 			r.Put("/{id}", h.UpdateFloorPlan)
+			r.Patch("/{id}", h.PatchFloorPlan)
 			r.Delete("/{id}", h.DeleteFloorPlan)
+			r.Post("/import", h.ImportFloorPlan)
+			r.Get("/{id}/export", h.ExportFloorPlan)
+			r.Post("/{id}/duplicate", h.DuplicateFloorPlan)
+			r.Put("/{id}/room", h.SetFloorPlanRoom)
+			r.Put("/{id}/event", h.SetFloorPlanEvent)
+			r.Post("/{id}/contacts", h.AddContactsToFloorPlan)
+			r.Post("/{id}/validate", h.ValidateLayout)
+			r.Post("/{id}/auto-layout", h.AutoLayout)
 			r.Put("/{id}/save", h.BulkSave)
 
+			// Revision history
+			r.Get("/{id}/revisions", h.ListRevisions)
+			r.Get("/{id}/revisions/{version}", h.GetRevision)
+			r.Post("/{id}/revisions/{version}/restore", h.RestoreRevision)
+
+			// Seating
+			r.Post("/{id}/auto-assign", h.AutoAssign)
+			r.Post("/{id}/optimize", h.OptimizeSeating)
+
+			// Guest list import
+			r.Post("/{id}/guests/import", h.ImportGuests)
+
+			// Exports
+			r.Get("/{id}/export.xlsx", h.ExportXLSX)
+			r.Get("/{id}/export.pdf", h.ExportPDF)
+			r.Get("/{id}/export.svg", h.ExportSVG)
+			r.Get("/{id}/export.png", h.ExportPNG)
+
 			// Share/unshare endpoints
 			r.Post("/{id}/share", h.ShareFloorPlan)
 			r.Post("/{id}/unshare", h.UnshareFloorPlan)
@@ -83,9 +131,18 @@ func main() {
 			r.Delete("/{id}/share-token", h.RevokeShareToken)
 			r.Get("/{id}/share-token", h.GetShareToken)
 
+			// RSVP links
+			r.Post("/{id}/rsvp-tokens", h.CreateRSVPTokens)
+			r.Delete("/{id}/rsvp-tokens/{guestId}", h.RevokeRSVPToken)
+			r.Get("/{id}/rsvp", h.GetRSVPSummary)
+
 			// Presence endpoints
 			r.Post("/{id}/presence", h.SendPresenceHeartbeat)
 			r.Get("/{id}/presence", h.GetPresence)
+			r.Delete("/{id}/presence", h.LeavePresence)
+
+			// Real-time change stream (Server-Sent Events)
+			r.Get("/{id}/events", h.StreamEvents)
 		})
 
 		r.Route("/organizations", func(r chi.Router) {
@@ -100,6 +157,44 @@ func main() {
 			r.Post("/{id}/members/invite", h.InviteMember)
 			r.Delete("/{id}/members/{memberId}", h.RemoveMember)
 			r.Put("/{id}/members/{memberId}", h.UpdateMemberRole)
+
+			// Floor plan templates
+			r.Get("/{id}/templates", h.ListTemplates)
+			r.Post("/{id}/templates", h.PublishTemplate)
+			r.Get("/{id}/templates/{templateId}", h.GetTemplate)
+			r.Delete("/{id}/templates/{templateId}", h.DeleteTemplate)
+
+			// Guest directory
+			r.Get("/{id}/contacts", h.ListContacts)
+			r.Post("/{id}/contacts", h.CreateContact)
+			r.Put("/{id}/contacts/{contactId}", h.UpdateContact)
+			r.Delete("/{id}/contacts/{contactId}", h.DeleteContact)
+		})
+
+		r.Route("/venues", func(r chi.Router) {
+			r.Get("/", h.ListVenues)
+			r.Post("/", h.CreateVenue)
+			r.Get("/{id}", h.GetVenue)
+			r.Put("/{id}", h.UpdateVenue)
+			r.Delete("/{id}", h.DeleteVenue)
+
+			// Rooms
+			r.Post("/{id}/rooms", h.CreateRoom)
+			r.Put("/{id}/rooms/{roomId}", h.UpdateRoom)
+			r.Delete("/{id}/rooms/{roomId}", h.DeleteRoom)
+		})
+
+		r.Route("/events", func(r chi.Router) {
+			r.Get("/", h.ListEvents)
+			r.Post("/", h.CreateEvent)
+			r.Get("/{id}", h.GetEvent)
+			r.Put("/{id}", h.UpdateEvent)
+			r.Delete("/{id}", h.DeleteEvent)
+
+			// Guest list
+			r.Post("/{id}/guests", h.CreateEventGuest)
+			r.Put("/{id}/guests/{guestId}", h.UpdateEventGuest)
+			r.Delete("/{id}/guests/{guestId}", h.DeleteEventGuest)
 		})
 
 		// Invitation acceptance (no org ID needed, uses token)
@@ -113,6 +208,8 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Closes open event streams so Shutdown doesn't wait on them
+	srv.RegisterOnShutdown(stopBackground)
 
 	go func() {
 		log.Printf("Starting table planner backend on port %s", cfg.Port)
@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const subscriberBuffer = 16
+
+// Broker listens on the Postgres notification channel and fans events out to
+// in-process subscribers, so events raised on any replica reach every client.
+type Broker struct {
+	pool   *pgxpool.Pool
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[chan Event]struct{}
+	closed bool
+}
+
+func NewBroker(pool *pgxpool.Pool) *Broker {
+	return &Broker{
+		pool: pool,
+		subs: make(map[uuid.UUID]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe registers for events on a floor plan. The returned function must be
+// called to unsubscribe. The channel is closed when the broker stops.
+func (b *Broker) Subscribe(floorPlanID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subs[floorPlanID] == nil {
+		b.subs[floorPlanID] = make(map[chan Event]struct{})
+	}
+	b.subs[floorPlanID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if set, ok := b.subs[floorPlanID]; ok {
+				if _, ok := set[ch]; ok {
+					delete(set, ch)
+					close(ch)
+				}
+				if len(set) == 0 {
+					delete(b.subs, floorPlanID)
+				}
+			}
+		})
+	}
+}
+
+// Run listens for notifications until ctx is cancelled, reconnecting on error.
+// On return all subscriber channels are closed.
+func (b *Broker) Run(ctx context.Context) {
+	defer b.closeAll()
+
+	for {
+		if err := b.listen(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Event listener error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (b *Broker) listen(ctx context.Context) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			// The connection may still be listening; drop it rather than return it to the pool.
+			conn.Conn().Close(context.Background())
+			return err
+		}
+		b.dispatch([]byte(n.Payload))
+	}
+}
+
+// dispatch decodes a notification payload and delivers it to subscribers of
+// that floor plan. Slow subscribers whose buffer is full miss the event.
+func (b *Broker) dispatch(payload []byte) {
+	var ev Event
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		log.Printf("Dropping malformed event payload: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[ev.FloorPlanID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for id, set := range b.subs {
+		for ch := range set {
+			close(ch)
+		}
+		delete(b.subs, id)
+	}
+}
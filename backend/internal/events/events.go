@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Channel is the Postgres NOTIFY channel all floor plan events are published on.
+const Channel = "floor_plan_events"
+
+// Event types
+const (
+	TypeSaved          = "saved"
+	TypePresenceJoined = "presence.joined"
+	TypePresenceLeft   = "presence.left"
+)
+
+// maxPayloadSize stays below Postgres' 8000 byte NOTIFY payload limit.
+const maxPayloadSize = 7900
+
+// Event is a change to a floor plan, delivered to every subscriber of that plan.
+type Event struct {
+	Type        string    `json:"type"`
+	FloorPlanID uuid.UUID `json:"floorPlanId"`
+	UserID      uuid.UUID `json:"userId"`
+	UserEmail   string    `json:"userEmail,omitempty"`
+	Version     int       `json:"version,omitempty"`
+	Changes     *Changes  `json:"changes,omitempty"`
+	// Truncated is set when the change list did not fit in a notification.
+	// Clients should refetch the whole floor plan.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Changes lists the entities touched by a save, per entity type.
+type Changes struct {
+	Tables EntityChanges `json:"tables"`
+	Guests EntityChanges `json:"guests"`
+	Labels EntityChanges `json:"labels"`
+}
+
+// EntityChanges holds the IDs of entities that were created or modified, and deleted.
+type EntityChanges struct {
+	Upserted []uuid.UUID `json:"upserted"`
+	Deleted  []uuid.UUID `json:"deleted"`
+}
+
+// Execer is satisfied by *pgxpool.Pool and pgx.Tx.
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Publish sends the event via pg_notify. When called inside a transaction the
+// notification is only delivered if the transaction commits.
+func Publish(ctx context.Context, db Execer, ev Event) error {
+	payload, err := Encode(ev)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx, `SELECT pg_notify($1, $2)`, Channel, string(payload))
+	return err
+}
+
+// Encode marshals the event, dropping the change list if the result would
+// exceed the NOTIFY payload limit.
+func Encode(ev Event) ([]byte, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) <= maxPayloadSize {
+		return payload, nil
+	}
+	ev.Changes = nil
+	ev.Truncated = true
+	return json.Marshal(ev)
+}
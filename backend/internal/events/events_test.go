@@ -0,0 +1,124 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEncode_SmallEventKeepsChanges(t *testing.T) {
+	ev := Event{
+		Type:        TypeSaved,
+		FloorPlanID: uuid.New(),
+		Version:     2,
+		Changes:     &Changes{Tables: EntityChanges{Upserted: []uuid.UUID{uuid.New()}}},
+	}
+
+	payload, err := Encode(ev)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var got Event
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if got.Truncated || got.Changes == nil || len(got.Changes.Tables.Upserted) != 1 {
+		t.Errorf("expected changes to be kept, got %+v", got)
+	}
+}
+
+func TestEncode_LargeEventIsTruncated(t *testing.T) {
+	ids := make([]uuid.UUID, 500)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	ev := Event{
+		Type:        TypeSaved,
+		FloorPlanID: uuid.New(),
+		Version:     2,
+		Changes:     &Changes{Guests: EntityChanges{Upserted: ids}},
+	}
+
+	payload, err := Encode(ev)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if len(payload) > maxPayloadSize {
+		t.Fatalf("payload is %d bytes, want <= %d", len(payload), maxPayloadSize)
+	}
+
+	var got Event
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if !got.Truncated || got.Changes != nil {
+		t.Errorf("expected truncated event without changes, got %+v", got)
+	}
+	if got.Version != 2 {
+		t.Errorf("expected version 2, got %d", got.Version)
+	}
+}
+
+func TestBroker_DispatchToSubscribers(t *testing.T) {
+	b := NewBroker(nil)
+	fpID := uuid.New()
+	otherID := uuid.New()
+
+	ch, unsubscribe := b.Subscribe(fpID)
+	defer unsubscribe()
+	other, unsubscribeOther := b.Subscribe(otherID)
+	defer unsubscribeOther()
+
+	payload, _ := Encode(Event{Type: TypePresenceJoined, FloorPlanID: fpID})
+	b.dispatch(payload)
+
+	select {
+	case ev := <-ch:
+		if ev.Type != TypePresenceJoined {
+			t.Errorf("expected %s, got %s", TypePresenceJoined, ev.Type)
+		}
+	default:
+		t.Fatal("expected event for subscribed floor plan")
+	}
+
+	select {
+	case ev := <-other:
+		t.Fatalf("unexpected event for other floor plan: %+v", ev)
+	default:
+	}
+}
+
+func TestBroker_UnsubscribeClosesChannel(t *testing.T) {
+	b := NewBroker(nil)
+	fpID := uuid.New()
+
+	ch, unsubscribe := b.Subscribe(fpID)
+	unsubscribe()
+	unsubscribe() // idempotent
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	if len(b.subs) != 0 {
+		t.Errorf("expected no subscriptions, got %d", len(b.subs))
+	}
+}
+
+func TestBroker_CloseAll(t *testing.T) {
+	b := NewBroker(nil)
+	ch, unsubscribe := b.Subscribe(uuid.New())
+	defer unsubscribe()
+
+	b.closeAll()
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed after broker stops")
+	}
+
+	late, _ := b.Subscribe(uuid.New())
+	if _, ok := <-late; ok {
+		t.Fatal("expected subscription after stop to be closed")
+	}
+}
@@ -0,0 +1,195 @@
+// Package eventsync keeps the guests of an event's floor plans in line with
+// the event's guest list. A plan's copy of an event guest is an ordinary guest
+// document with an eventGuestId; its name, dietary restrictions and guestOf
+// come from the event, while seating stays with the plan.
+package eventsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/google/uuid"
+)
+
+// Sync copies the given event guests onto the plan's copies of them. With add
+// set, event guests the plan has no copy of are added unseated, with
+// createdAt set to now. It reports whether any document changed.
+func Sync(guestItems []json.RawMessage, guests []models.EventGuest, add bool, now time.Time) ([]json.RawMessage, bool, error) {
+	docs, err := decodeAll(guestItems)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Plan guest IDs by event guest, including the copies about to be added
+	copyOf := map[string]string{}
+	byEventGuest := map[string]map[string]any{}
+	for _, doc := range docs {
+		if eg, ok := doc["eventGuestId"].(string); ok {
+			copyOf[eg] = stringField(doc, "id")
+			byEventGuest[eg] = doc
+		}
+	}
+
+	changed := false
+	createdAt := now.UTC().Format(time.RFC3339)
+	for _, g := range guests {
+		if _, ok := byEventGuest[g.ID.String()]; ok || !add {
+			continue
+		}
+		doc := map[string]any{
+			"id":              uuid.NewString(),
+			"assignedTableId": nil,
+			"seatPosition":    nil,
+			"createdAt":       createdAt,
+			"eventGuestId":    g.ID.String(),
+		}
+		docs = append(docs, doc)
+		byEventGuest[g.ID.String()] = doc
+		copyOf[g.ID.String()] = doc["id"].(string)
+		changed = true
+	}
+
+	for _, g := range guests {
+		doc, ok := byEventGuest[g.ID.String()]
+		if !ok {
+			continue
+		}
+		var guestOf any
+		if g.GuestOf != nil {
+			if id, ok := copyOf[g.GuestOf.String()]; ok {
+				guestOf = id
+			}
+		}
+		fields := map[string]any{
+			"name":                g.Name,
+			"dietaryRestrictions": g.DietaryRestrictions,
+			"guestOf":             guestOf,
+		}
+		for k, v := range fields {
+			if set(doc, k, v) {
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return guestItems, false, nil
+	}
+	out, err := encodeAll(docs)
+	return out, true, err
+}
+
+// Remove deletes the plan's copies of the given event guests, unseating them
+// and clearing guestOf references to them.
+func Remove(tableItems, guestItems []json.RawMessage, eventGuestIDs []uuid.UUID) ([]json.RawMessage, []json.RawMessage, bool, error) {
+	docs, err := decodeAll(guestItems)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	gone := make(map[string]bool, len(eventGuestIDs))
+	for _, id := range eventGuestIDs {
+		gone[id.String()] = true
+	}
+	removed := map[string]bool{}
+	kept := docs[:0]
+	for _, doc := range docs {
+		if eg, ok := doc["eventGuestId"].(string); ok && gone[eg] {
+			removed[stringField(doc, "id")] = true
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	if len(removed) == 0 {
+		return tableItems, guestItems, false, nil
+	}
+	for _, doc := range kept {
+		if removed[stringField(doc, "guestOf")] {
+			doc["guestOf"] = nil
+		}
+	}
+
+	guests, err := encodeAll(kept)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	// Seats of the removed guests are rebuilt from the remaining guests
+	res, err := seating.Reconcile(tableItems, guests)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return res.Tables, res.Guests, true, nil
+}
+
+// Unlink turns the plan's copies of event guests into plain guests.
+func Unlink(guestItems []json.RawMessage) ([]json.RawMessage, bool, error) {
+	docs, err := decodeAll(guestItems)
+	if err != nil {
+		return nil, false, err
+	}
+	changed := false
+	for _, doc := range docs {
+		if _, ok := doc["eventGuestId"]; ok {
+			delete(doc, "eventGuestId")
+			changed = true
+		}
+	}
+	if !changed {
+		return guestItems, false, nil
+	}
+	out, err := encodeAll(docs)
+	return out, true, err
+}
+
+// set stores v in doc[key], reporting whether that changed the document.
+func set(doc map[string]any, key string, v any) bool {
+	// Compare in decoded form, so []DietaryRestriction equals []any of strings
+	raw, _ := json.Marshal(v)
+	var normalized any
+	decode(raw, &normalized)
+	if old, ok := doc[key]; ok && reflect.DeepEqual(old, normalized) {
+		return false
+	}
+	doc[key] = normalized
+	return true
+}
+
+func stringField(doc map[string]any, key string) string {
+	s, _ := doc[key].(string)
+	return s
+}
+
+// decodeAll decodes guest documents, keeping fields the backend doesn't model
+// and numbers exactly as they were.
+func decodeAll(items []json.RawMessage) ([]map[string]any, error) {
+	docs := make([]map[string]any, len(items))
+	for i, item := range items {
+		if err := decode(item, &docs[i]); err != nil || docs[i] == nil {
+			return nil, fmt.Errorf("guests[%d]: invalid guest document", i)
+		}
+	}
+	return docs, nil
+}
+
+func encodeAll(docs []map[string]any) ([]json.RawMessage, error) {
+	items := make([]json.RawMessage, len(docs))
+	for i, doc := range docs {
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = raw
+	}
+	return items, nil
+}
+
+func decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
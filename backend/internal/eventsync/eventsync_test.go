@@ -0,0 +1,109 @@
+package eventsync
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func decodeGuests(t *testing.T, items []json.RawMessage) []models.Guest {
+	t.Helper()
+	guests := make([]models.Guest, len(items))
+	for i, item := range items {
+		if err := json.Unmarshal(item, &guests[i]); err != nil {
+			t.Fatalf("guests[%d]: %v", i, err)
+		}
+	}
+	return guests
+}
+
+func TestSync(t *testing.T) {
+	ann := models.EventGuest{ID: uuid.New(), Name: "Ann", DietaryRestrictions: []models.DietaryRestriction{models.DietaryVegan}}
+	bob := models.EventGuest{ID: uuid.New(), Name: "Bob", DietaryRestrictions: []models.DietaryRestriction{}, GuestOf: &ann.ID}
+	tableID := uuid.NewString()
+	items := []json.RawMessage{
+		json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Ann Smith","dietaryRestrictions":[],"assignedTableId":"` + tableID +
+			`","seatPosition":2,"guestOf":null,"eventGuestId":"` + ann.ID.String() + `","color":"red"}`),
+		json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Caterer","dietaryRestrictions":[],"assignedTableId":null,"seatPosition":null,"guestOf":null}`),
+	}
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// An update only touches existing copies
+	out, changed, err := Sync(items, []models.EventGuest{ann, bob}, false, now)
+	if err != nil || !changed || len(out) != 2 {
+		t.Fatalf("Sync() = %d items, %v, %v", len(out), changed, err)
+	}
+	guests := decodeGuests(t, out)
+	if guests[0].Name != "Ann" || len(guests[0].DietaryRestrictions) != 1 || guests[0].SeatPosition == nil || *guests[0].SeatPosition != 2 {
+		t.Errorf("expected the profile updated and seating kept, got %+v", guests[0])
+	}
+	if !strings.Contains(string(out[0]), `"color":"red"`) || guests[1].Name != "Caterer" {
+		t.Errorf("expected other fields and guests kept, got %s", out)
+	}
+
+	// Adding brings in missing guests with guestOf pointing at the plan's copy
+	out, changed, err = Sync(out, []models.EventGuest{ann, bob}, true, now)
+	if err != nil || !changed || len(out) != 3 {
+		t.Fatalf("Sync() = %d items, %v, %v", len(out), changed, err)
+	}
+	guests = decodeGuests(t, out)
+	added := guests[2]
+	if added.Name != "Bob" || added.EventGuestID == nil || *added.EventGuestID != bob.ID.String() ||
+		added.GuestOf == nil || *added.GuestOf != guests[0].ID || added.AssignedTableID != nil || added.CreatedAt != "2026-05-01T12:00:00Z" {
+		t.Errorf("unexpected added guest %+v", added)
+	}
+	if errs := added.Validate("guest"); len(errs) > 0 {
+		t.Errorf("added guest is invalid: %v", errs)
+	}
+
+	if _, changed, _ := Sync(out, []models.EventGuest{ann, bob}, true, now); changed {
+		t.Error("expected a second sync to change nothing")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	eventGuest := uuid.New()
+	guestID, companionID, tableID := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	tables := []json.RawMessage{json.RawMessage(`{"id":"` + tableID + `","name":"T1","tableType":"ROUND","capacity":2,` +
+		`"seats":[{"position":0,"guestId":"` + guestID + `"},{"position":1,"guestId":"` + companionID + `"}],` +
+		`"assignedGuests":["` + guestID + `","` + companionID + `"]}`)}
+	guests := []json.RawMessage{
+		json.RawMessage(`{"id":"` + guestID + `","name":"Ann","dietaryRestrictions":[],"assignedTableId":"` + tableID +
+			`","seatPosition":0,"guestOf":null,"eventGuestId":"` + eventGuest.String() + `"}`),
+		json.RawMessage(`{"id":"` + companionID + `","name":"Bob","dietaryRestrictions":[],"assignedTableId":"` + tableID +
+			`","seatPosition":1,"guestOf":"` + guestID + `"}`),
+	}
+
+	outTables, outGuests, changed, err := Remove(tables, guests, []uuid.UUID{eventGuest})
+	if err != nil || !changed {
+		t.Fatalf("Remove() changed = %v, err = %v", changed, err)
+	}
+	left := decodeGuests(t, outGuests)
+	if len(left) != 1 || left[0].ID != companionID || left[0].GuestOf != nil {
+		t.Errorf("unexpected guests %+v", left)
+	}
+	var table models.Table
+	json.Unmarshal(outTables[0], &table)
+	if table.Seats[0].GuestID != nil || len(table.AssignedGuests) != 1 || table.AssignedGuests[0] != companionID {
+		t.Errorf("expected the removed guest's seat cleared, got %s", outTables[0])
+	}
+
+	if _, _, changed, _ := Remove(outTables, outGuests, []uuid.UUID{eventGuest}); changed {
+		t.Error("expected removing an absent guest to change nothing")
+	}
+}
+
+func TestUnlink(t *testing.T) {
+	items := []json.RawMessage{json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Ann","eventGuestId":"` + uuid.NewString() + `"}`)}
+	out, changed, err := Unlink(items)
+	if err != nil || !changed || strings.Contains(string(out[0]), "eventGuestId") {
+		t.Errorf("Unlink() = %s, %v, %v", out, changed, err)
+	}
+	if _, changed, _ := Unlink(out); changed {
+		t.Error("expected unlinking plain guests to change nothing")
+	}
+}
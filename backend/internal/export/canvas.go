@@ -0,0 +1,320 @@
+package export
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/frallan97/table-planner-backend/internal/geometry"
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+// canvas is a drawing surface with y pointing down, as in SVG. Drawing
+// functions are written once against it and rendered by each output format.
+type canvas interface {
+	save()
+	restore()
+	translate(x, y float64)
+	// rotate turns clockwise by deg degrees, like SVG's rotate()
+	rotate(deg float64)
+	scale(s float64)
+
+	rect(r rect, radius float64, st style)
+	circle(cx, cy, r float64, st style)
+	line(x1, y1, x2, y2 float64, st style)
+	// path draws a polyline through pts, closed into a polygon if closed is set
+	path(pts []point, closed bool, st style)
+	text(x, y float64, s string, ts textStyle)
+}
+
+type color struct {
+	r, g, b uint8
+}
+
+// hex parses a "#rrggbb" color.
+func hex(s string) color {
+	v, _ := strconv.ParseUint(s[1:], 16, 32)
+	return color{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+}
+
+type style struct {
+	fill   *color
+	stroke *color
+	width  float64
+	dashed bool
+}
+
+func filled(c color) style             { return style{fill: &c} }
+func stroked(c color, w float64) style { return style{stroke: &c, width: w} }
+func outlined(fill, stroke color, w float64) style {
+	return style{fill: &fill, stroke: &stroke, width: w}
+}
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type textStyle struct {
+	size   float64
+	bold   bool
+	color  color
+	anchor anchor
+	// central puts y at the middle of the text instead of on its baseline
+	central bool
+}
+
+// centralShift is how far below the middle of a line of text its baseline is,
+// as a fraction of the font size.
+const centralShift = 0.35
+
+// matrix is a 2D affine transform [a b c d e f] mapping (x, y) to
+// (a*x + c*y + e, b*x + d*y + f), as in SVG and PDF.
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m applied after n, i.e. the transform for drawing in n's
+// coordinates inside m's.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+func rotation(deg float64) matrix {
+	s, c := math.Sincos(deg * math.Pi / 180)
+	return matrix{c, s, -s, c, 0, 0}
+}
+
+// transforms tracks the current transform of a canvas.
+type transforms struct {
+	m     matrix
+	stack []matrix
+}
+
+func newTransforms() transforms { return transforms{m: identity} }
+
+func (t *transforms) save()                  { t.stack = append(t.stack, t.m) }
+func (t *transforms) translate(x, y float64) { t.m = t.m.mul(matrix{1, 0, 0, 1, x, y}) }
+func (t *transforms) rotate(deg float64)     { t.m = t.m.mul(rotation(deg)) }
+func (t *transforms) scale(s float64)        { t.m = t.m.mul(matrix{s, 0, 0, s, 0, 0}) }
+
+func (t *transforms) restore() {
+	if n := len(t.stack); n > 0 {
+		t.m, t.stack = t.stack[n-1], t.stack[:n-1]
+	}
+}
+
+// Colors of the floor plan drawing, as in TableRenderer.tsx
+var (
+	colorTable     = hex("#fdfdfd")
+	colorOutline   = hex("#888888")
+	colorTableName = hex("#555555")
+	colorSeat      = hex("#999999")
+	colorGuestName = hex("#333333")
+	colorLabel     = hex("#aaaaaa")
+	colorWhite     = hex("#ffffff")
+	colorBlack     = hex("#000000")
+	colorMuted     = hex("#666666")
+	colorRule      = hex("#cccccc")
+)
+
+var pastel = []string{
+	"#C5D9F1", "#F2DCDB", "#D5E8D4", "#E1D5E7",
+	"#FFF2CC", "#DAE8FC", "#F8CECC", "#FFE6CC",
+	"#D4E6F1", "#FADBD8", "#D5F5E3", "#E8DAEF",
+}
+
+// guestColor picks a guest's seat color the way gColor in TableRenderer.tsx
+// does, so a guest has the same color everywhere.
+func guestColor(id string) color {
+	var h int32
+	for _, c := range utf16.Encode([]rune(id)) {
+		h = (h << 5) - h + int32(c)
+	}
+	n := int64(h)
+	if n < 0 {
+		n = -n
+	}
+	return hex(pastel[n%int64(len(pastel))])
+}
+
+// drawOptions controls what a floor plan drawing shows.
+type drawOptions struct {
+	guestNames bool
+}
+
+// Colors of the room under the plan
+var (
+	colorFloor      = hex("#f7f6f2")
+	colorWall       = hex("#444444")
+	colorPillar     = hex("#bbbbbb")
+	colorDoor       = hex("#8fbc8f")
+	colorStage      = hex("#e8dcc8")
+	colorDanceFloor = hex("#e3ecf7")
+)
+
+// drawRoom draws the room outline and its obstacles. Doors are drawn last so
+// they cut through walls.
+func drawRoom(c canvas, g *models.RoomGeometry) {
+	if len(g.Outline) >= 3 {
+		c.path(points(g.Outline), true, outlined(colorFloor, colorWall, 4))
+	}
+	for _, doors := range []bool{false, true} {
+		for _, o := range g.Obstacles {
+			if (o.Type == models.ObstacleDoor) != doors {
+				continue
+			}
+			pts := points(o.Points)
+			switch o.Type {
+			case models.ObstacleWall:
+				c.path(pts, false, stroked(colorWall, 6))
+			case models.ObstacleDoor:
+				c.path(pts, false, stroked(colorDoor, 8))
+			case models.ObstaclePillar:
+				c.path(pts, true, filled(colorPillar))
+			case models.ObstacleStage:
+				c.path(pts, true, outlined(colorStage, colorOutline, 1.5))
+			case models.ObstacleDanceFloor:
+				st := outlined(colorDanceFloor, colorOutline, 1.5)
+				st.dashed = true
+				c.path(pts, true, st)
+			default:
+				c.path(pts, true, outlined(colorWhite, colorOutline, 1.5))
+			}
+			if o.Label != "" && !o.Type.Open() && len(pts) > 0 {
+				x, y := centroid(pts)
+				c.text(x, y, o.Label, textStyle{size: 14, color: colorMuted, anchor: anchorMiddle, central: true})
+			}
+		}
+	}
+}
+
+func points(ps []models.Position) []point {
+	pts := make([]point, len(ps))
+	for i, p := range ps {
+		pts[i] = point{p.X, p.Y}
+	}
+	return pts
+}
+
+// centroid returns the average of pts, which is inside the convex shapes
+// obstacles usually are.
+func centroid(pts []point) (float64, float64) {
+	var x, y float64
+	for _, p := range pts {
+		x, y = x+p.x, y+p.y
+	}
+	return x / float64(len(pts)), y / float64(len(pts))
+}
+
+// drawFloorPlan draws the room, labels and tables with their seats in plan
+// coordinates.
+func drawFloorPlan(c canvas, p *Plan, opts drawOptions) {
+	if p.Room != nil {
+		drawRoom(c, p.Room)
+	}
+	for _, l := range p.Labels {
+		c.save()
+		c.translate(l.Position.X, l.Position.Y)
+		c.rotate(l.Rotation)
+		c.rect(rect{-l.Width / 2, -l.Height / 2, l.Width, l.Height}, 3, outlined(colorWhite, colorLabel, 1))
+		c.text(0, 0, l.Text, textStyle{size: l.FontSize, color: colorGuestName, anchor: anchorMiddle, central: true})
+		c.restore()
+	}
+
+	guests := make(map[string]string, len(p.Guests))
+	for _, g := range p.Guests {
+		guests[g.ID] = g.Name
+	}
+	for _, t := range p.Tables {
+		l := geometry.LayoutTable(t)
+		c.save()
+		c.translate(t.Position.X, t.Position.Y)
+		c.rotate(t.Rotation)
+
+		body := outlined(colorTable, colorOutline, 2)
+		for _, r := range l.Rects {
+			c.rect(rect{r.X, r.Y, r.W, r.H}, 3, body)
+		}
+		if l.Radius > 0 {
+			c.circle(0, 0, l.Radius, body)
+		}
+		c.text(0, l.NameY, t.Name, textStyle{size: 13, color: colorTableName, anchor: anchorMiddle, central: true})
+
+		for _, s := range l.Seats {
+			name, seated := "", false
+			if s.Index < len(t.Seats) && t.Seats[s.Index].GuestID != nil {
+				name, seated = guests[*t.Seats[s.Index].GuestID]
+			}
+			fill, numberSize, numberColor := colorWhite, 10.0, colorSeat
+			if seated {
+				fill, numberSize, numberColor = guestColor(*t.Seats[s.Index].GuestID), 8, colorTableName
+			}
+			c.circle(s.X, s.Y, geometry.SeatRadius, outlined(fill, colorSeat, 1.5))
+			c.text(s.X, s.Y, strconv.Itoa(s.Index+1), textStyle{size: numberSize, color: numberColor, anchor: anchorMiddle, central: true})
+			if seated && opts.guestNames {
+				drawSeatName(c, s, truncate(compactName(name), 14))
+			}
+		}
+		c.restore()
+	}
+}
+
+// drawSeatName places a guest's name beside their seat like seatLabel in
+// TableRenderer.tsx.
+func drawSeatName(c canvas, s geometry.SeatSpot, name string) {
+	ts := textStyle{size: 11, color: colorGuestName}
+	switch s.Side {
+	case geometry.SideTop, geometry.SideBottom:
+		x, y, deg := s.X+2, s.Y-geometry.SeatRadius-5, -45.0
+		if s.Side == geometry.SideBottom {
+			y, deg = s.Y+geometry.SeatRadius+14, 45
+		}
+		c.save()
+		c.translate(x, y)
+		c.rotate(deg)
+		c.text(0, 0, name, ts)
+		c.restore()
+	case geometry.SideLeft:
+		ts.anchor = anchorEnd
+		c.text(s.X-geometry.SeatRadius-6, s.Y+4, name, ts)
+	case geometry.SideRight:
+		c.text(s.X+geometry.SeatRadius+6, s.Y+4, name, ts)
+	case geometry.SideRadial:
+		rad := s.Angle * math.Pi / 180
+		x := s.X + math.Cos(rad)*(geometry.SeatRadius+8)
+		y := s.Y + math.Sin(rad)*(geometry.SeatRadius+8)
+		switch {
+		case math.Abs(s.Angle) < 1 || math.Abs(s.Angle-180) < 1:
+			ts.anchor = anchorMiddle
+		case s.Angle > -90 && s.Angle < 90:
+			ts.anchor = anchorStart
+		default:
+			ts.anchor = anchorEnd
+		}
+		c.text(x, y+4, name, ts)
+	}
+}
+
+// fitPlan transforms c so the plan's drawing fills the area, centered.
+func fitPlan(c canvas, p *Plan, area rect) {
+	b := p.bounds()
+	s := math.Min(area.w/b.w, area.h/b.h)
+	c.translate(area.x+(area.w-b.w*s)/2, area.y+(area.h-b.h*s)/2)
+	c.scale(s)
+	c.translate(-b.x, -b.y)
+}
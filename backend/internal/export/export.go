@@ -0,0 +1,166 @@
+// Package export renders floor plans into files handed to venues, caterers
+// and guests.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Plan is a decoded floor plan.
+type Plan struct {
+	Name   string
+	Tables []models.Table
+	Guests []models.Guest
+	Labels []models.FloorLabel
+	// Room is the room the plan is laid out in, if any
+	Room *models.RoomGeometry
+}
+
+// DecodePlan decodes the stored documents of a floor plan.
+func DecodePlan(name string, tables, guests, labels []json.RawMessage) (*Plan, error) {
+	p := &Plan{
+		Name:   name,
+		Tables: make([]models.Table, len(tables)),
+		Guests: make([]models.Guest, len(guests)),
+		Labels: make([]models.FloorLabel, len(labels)),
+	}
+	for i, item := range tables {
+		if err := json.Unmarshal(item, &p.Tables[i]); err != nil {
+			return nil, fmt.Errorf("tables[%d]: %w", i, err)
+		}
+	}
+	for i, item := range guests {
+		if err := json.Unmarshal(item, &p.Guests[i]); err != nil {
+			return nil, fmt.Errorf("guests[%d]: %w", i, err)
+		}
+	}
+	for i, item := range labels {
+		if err := json.Unmarshal(item, &p.Labels[i]); err != nil {
+			return nil, fmt.Errorf("labels[%d]: %w", i, err)
+		}
+	}
+	return p, nil
+}
+
+// dietaryOrder and dietaryLabels mirror DIETARY_RESTRICTION_LABELS in the
+// frontend's types.ts.
+var dietaryOrder = []models.DietaryRestriction{
+	models.DietaryVegetarian,
+	models.DietaryVegan,
+	models.DietaryPescatarian,
+	models.DietaryLactoseIntolerant,
+}
+
+var dietaryLabels = map[models.DietaryRestriction]string{
+	models.DietaryVegetarian:        "Vegetarian",
+	models.DietaryVegan:             "Vegan",
+	models.DietaryPescatarian:       "Pescatarian",
+	models.DietaryLactoseIntolerant: "Lactose Intolerant",
+	models.DietaryNone:              "None",
+}
+
+// newCollator orders names the way people expect: accents and case are
+// secondary and "Table 2" comes before "Table 10".
+func newCollator() *collate.Collator {
+	return collate.New(language.Und, collate.Numeric)
+}
+
+// sortedGuests returns the guests in alphabetical order.
+func (p *Plan) sortedGuests() []models.Guest {
+	col := newCollator()
+	guests := append([]models.Guest(nil), p.Guests...)
+	sort.SliceStable(guests, func(i, j int) bool { return col.CompareString(guests[i].Name, guests[j].Name) < 0 })
+	return guests
+}
+
+// sortedTables returns the tables ordered by name.
+func (p *Plan) sortedTables() []models.Table {
+	col := newCollator()
+	tables := append([]models.Table(nil), p.Tables...)
+	sort.SliceStable(tables, func(i, j int) bool { return col.CompareString(tables[i].Name, tables[j].Name) < 0 })
+	return tables
+}
+
+// tableOf returns the table a guest is assigned to, or nil.
+func (p *Plan) tableOf(g models.Guest) *models.Table {
+	if g.AssignedTableID == nil {
+		return nil
+	}
+	for i := range p.Tables {
+		if strings.EqualFold(p.Tables[i].ID, *g.AssignedTableID) {
+			return &p.Tables[i]
+		}
+	}
+	return nil
+}
+
+// tableGuests returns the guests assigned to t, seated guests first in seat
+// order and then those without a seat alphabetically.
+func (p *Plan) tableGuests(t models.Table) []models.Guest {
+	var seated, unseated []models.Guest
+	for _, g := range p.sortedGuests() {
+		if g.AssignedTableID == nil || !strings.EqualFold(*g.AssignedTableID, t.ID) {
+			continue
+		}
+		if g.SeatPosition != nil {
+			seated = append(seated, g)
+		} else {
+			unseated = append(unseated, g)
+		}
+	}
+	sort.SliceStable(seated, func(i, j int) bool { return *seated[i].SeatPosition < *seated[j].SeatPosition })
+	return append(seated, unseated...)
+}
+
+// seatNumber is the 1-based seat number shown to people, as in the print view.
+func seatNumber(g models.Guest) string {
+	if g.SeatPosition == nil {
+		return ""
+	}
+	return strconv.Itoa(*g.SeatPosition + 1)
+}
+
+// dietaryText lists a guest's restrictions, leaving out NONE.
+func dietaryText(g models.Guest) string {
+	var labels []string
+	for _, d := range g.DietaryRestrictions {
+		if d == models.DietaryNone {
+			continue
+		}
+		if label, ok := dietaryLabels[d]; ok {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+func hasDietary(g models.Guest, d models.DietaryRestriction) bool {
+	for _, r := range g.DietaryRestrictions {
+		if r == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Filename turns a plan name into a safe download filename with the given extension.
+func Filename(name, ext string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "floor-plan"
+	}
+	return name + "." + ext
+}
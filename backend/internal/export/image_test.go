@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+// imagePlan has one table of each type, a label and a seated guest.
+func imagePlan() *Plan {
+	guest, table := "g1", "t1"
+	return &Plan{
+		Name: "Dinner & dance",
+		Tables: []models.Table{
+			{ID: table, Name: "Head", TableType: models.TableTypeLine, Seats: []models.Seat{{GuestID: &guest}, {}, {}}},
+			{ID: "t2", Name: "Round", TableType: models.TableTypeRound, Seats: make([]models.Seat, 8), Position: models.Position{X: 600}},
+			{ID: "t3", Name: "U", TableType: models.TableTypeUShape, TopSeats: 3, LeftSeats: 2, RightSeats: 2,
+				Seats: make([]models.Seat, 7), Position: models.Position{X: 300, Y: 500}, Rotation: 90},
+		},
+		Guests: []models.Guest{{ID: guest, Name: "Zoë Öberg", AssignedTableID: &table}},
+		Labels: []models.FloorLabel{{ID: "l1", Text: "Stage", Position: models.Position{X: 300, Y: -250}, Width: 200, Height: 60, FontSize: 24}},
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, imagePlan(), ImageOptions{Width: 800, GuestNames: true}); err != nil {
+		t.Fatal(err)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("SVG is not well-formed: %v", err)
+		}
+	}
+	svg := buf.String()
+	for _, want := range []string{`width="800"`, "<title>Dinner &amp; dance</title>", ">Zoë Ö.</text>", ">Stage</text>", "<circle"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG is missing %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteSVG(&buf, imagePlan(), ImageOptions{Width: 800}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Zoë") {
+		t.Error("guest names drawn although GuestNames is off")
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, imagePlan(), ImageOptions{Width: 400, GuestNames: true}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	b := img.Bounds()
+	if b.Dx() != 400 {
+		t.Errorf("expected width 400, got %d", b.Dx())
+	}
+	drawn := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, g, bl, _ := img.At(x, y).RGBA(); r != 0xffff || g != 0xffff || bl != 0xffff {
+				drawn++
+			}
+		}
+	}
+	if drawn == 0 {
+		t.Error("image is blank")
+	}
+}
+
+func TestImageSize(t *testing.T) {
+	tall := &Plan{Tables: []models.Table{{Position: models.Position{Y: 0}}, {Position: models.Position{Y: 20000}}}}
+	w, h := imageSize(tall, ImageOptions{Width: 2000})
+	if h != MaxImageWidth || w >= 2000 {
+		t.Errorf("tall plan not scaled down: %dx%d", w, h)
+	}
+
+	w, h = imageSize(&Plan{}, ImageOptions{Width: 100000})
+	if w != MaxImageWidth || h != MaxImageWidth {
+		t.Errorf("expected an empty plan to be square and clamped, got %dx%d", w, h)
+	}
+
+	w, h = imageSize(tall, ImageOptions{Width: 1000, MaxSize: MaxSharedImageWidth})
+	if h != MaxSharedImageWidth || w >= 1000 {
+		t.Errorf("expected a tall plan within MaxSize, got %dx%d", w, h)
+	}
+}
+
+func TestWriteSVG_Room(t *testing.T) {
+	p := imagePlan()
+	p.Room = &models.RoomGeometry{
+		Outline: []models.Position{{X: -2000, Y: -1000}, {X: 2000, Y: -1000}, {X: 2000, Y: 1500}, {X: -2000, Y: 1500}},
+		Obstacles: []models.Obstacle{
+			{Type: models.ObstacleStage, Label: "Stage", Points: []models.Position{{X: -500, Y: -1000}, {X: 500, Y: -1000}, {X: 500, Y: -700}, {X: -500, Y: -700}}},
+			{Type: models.ObstacleDoor, Points: []models.Position{{X: 2000, Y: 0}, {X: 2000, Y: 200}}},
+		},
+	}
+	if b := p.bounds(); b.x > -2000 || b.y > -1000 || b.x+b.w < 2000 || b.y+b.h < 1500 {
+		t.Errorf("bounds %+v do not cover the room", b)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, p, ImageOptions{Width: 800}); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if n := strings.Count(svg, "<polygon"); n != 2 {
+		t.Errorf("expected room and stage polygons, got %d", n)
+	}
+	if !strings.Contains(svg, "<polyline") {
+		t.Error("door not drawn")
+	}
+	if strings.Index(svg, "<polygon") > strings.Index(svg, "<circle") {
+		t.Error("room must be drawn under the tables")
+	}
+
+	// The PNG canvas strokes paths segment by segment
+	if err := WritePNG(io.Discard, p, ImageOptions{Width: 400}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"math"
+	"strings"
+)
+
+type rect struct {
+	x, y, w, h float64
+}
+
+type point struct {
+	x, y float64
+}
+
+// Padding around tables, labels and the room when fitting a plan, as in
+// computeFloorPlanBounds in the frontend's utils.ts.
+const (
+	boundsTablePad = 350.0
+	boundsLabelPad = 50.0
+	boundsRoomPad  = 20.0
+)
+
+// bounds returns the area a drawing of the plan covers.
+func (p *Plan) bounds() rect {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	grow := func(x, y, padX, padY float64) {
+		minX, minY = math.Min(minX, x-padX), math.Min(minY, y-padY)
+		maxX, maxY = math.Max(maxX, x+padX), math.Max(maxY, y+padY)
+	}
+	for _, t := range p.Tables {
+		grow(t.Position.X, t.Position.Y, boundsTablePad, boundsTablePad)
+	}
+	for _, l := range p.Labels {
+		grow(l.Position.X, l.Position.Y, l.Width+boundsLabelPad, l.Height+boundsLabelPad)
+	}
+	if p.Room != nil {
+		for _, pt := range p.Room.Outline {
+			grow(pt.X, pt.Y, boundsRoomPad, boundsRoomPad)
+		}
+		for _, o := range p.Room.Obstacles {
+			for _, pt := range o.Points {
+				grow(pt.X, pt.Y, boundsRoomPad, boundsRoomPad)
+			}
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return rect{0, 0, 1, 1}
+	}
+	return rect{minX, minY, maxX - minX, maxY - minY}
+}
+
+// compactName shortens "John Doe" to "John D." like formatGuestNameCompact.
+func compactName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return strings.TrimSpace(name)
+	}
+	last := []rune(parts[len(parts)-1])
+	return parts[0] + " " + strings.ToUpper(string(last[0])) + "."
+}
+
+// truncate shortens s to at most n runes, ending with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,303 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ContentTypePDF is the media type of a PDF document.
+const ContentTypePDF = "application/pdf"
+
+// Page sizes in points, portrait, matching PAGE_DIMS in the frontend's PrintView.tsx
+var pageSizes = map[string][2]float64{
+	"a4":     {595.28, 841.89},
+	"letter": {612, 792},
+}
+
+// ValidPageSize reports whether size names a supported page size.
+func ValidPageSize(size string) bool {
+	_, ok := pageSizes[size]
+	return ok
+}
+
+// pdfDoc builds a PDF using the standard Helvetica fonts, so nothing needs to
+// be embedded. Text is limited to the Windows-1252 character set.
+type pdfDoc struct {
+	pages []*pdfPage
+}
+
+func (d *pdfDoc) addPage(w, h float64) *pdfPage {
+	p := &pdfPage{transforms: newTransforms(), w: w, h: h}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+func (d *pdfDoc) write(w io.Writer) error {
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes
+	// two objects: the page and its content stream.
+	var offsets []int
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s", len(offsets), body)
+		if stream != nil {
+			out.WriteString("\nstream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream")
+		}
+		out.WriteString("\nendobj\n")
+	}
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)), nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+
+	for i, p := range d.pages {
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(p.content.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			nums(p.w, p.h), 6+2*i), nil)
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", content.Len()), content.Bytes())
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// pdfPage is a canvas in points with the origin at the top left.
+type pdfPage struct {
+	transforms
+	w, h    float64
+	content bytes.Buffer
+}
+
+// begin starts a graphics state that maps the canvas transform onto PDF's
+// bottom-left origin.
+func (p *pdfPage) begin(st style) {
+	m := matrix{1, 0, 0, -1, 0, p.h}.mul(p.m)
+	fmt.Fprintf(&p.content, "q %s cm\n", nums(m[:]...))
+	if st.fill != nil {
+		fmt.Fprintf(&p.content, "%s rg\n", rgb(*st.fill))
+	}
+	if st.stroke != nil {
+		fmt.Fprintf(&p.content, "%s RG %s w\n", rgb(*st.stroke), nums(st.width))
+		if st.dashed {
+			fmt.Fprintf(&p.content, "[%s] 0 d\n", nums(st.width*3, st.width*2))
+		}
+	}
+}
+
+func (p *pdfPage) paint(st style) {
+	switch {
+	case st.fill != nil && st.stroke != nil:
+		p.content.WriteString("B Q\n")
+	case st.fill != nil:
+		p.content.WriteString("f Q\n")
+	case st.stroke != nil:
+		p.content.WriteString("S Q\n")
+	default:
+		p.content.WriteString("n Q\n")
+	}
+}
+
+func (p *pdfPage) rect(r rect, radius float64, st style) {
+	p.begin(st)
+	radius = math.Min(radius, math.Min(r.w, r.h)/2)
+	if radius <= 0 {
+		fmt.Fprintf(&p.content, "%s re\n", nums(r.x, r.y, r.w, r.h))
+	} else {
+		k := radius * (1 - bezierCircle)
+		x0, y0, x1, y1 := r.x, r.y, r.x+r.w, r.y+r.h
+		fmt.Fprintf(&p.content, "%s m\n", nums(x0+radius, y0))
+		fmt.Fprintf(&p.content, "%s l %s c\n", nums(x1-radius, y0), nums(x1-k, y0, x1, y0+k, x1, y0+radius))
+		fmt.Fprintf(&p.content, "%s l %s c\n", nums(x1, y1-radius), nums(x1, y1-k, x1-k, y1, x1-radius, y1))
+		fmt.Fprintf(&p.content, "%s l %s c\n", nums(x0+radius, y1), nums(x0+k, y1, x0, y1-k, x0, y1-radius))
+		fmt.Fprintf(&p.content, "%s l %s c h\n", nums(x0, y0+radius), nums(x0, y0+k, x0+k, y0, x0+radius, y0))
+	}
+	p.paint(st)
+}
+
+// bezierCircle is the control point distance that best approximates a
+// quarter circle of radius 1 with a cubic Bézier curve.
+const bezierCircle = 0.5523
+
+func (p *pdfPage) circle(cx, cy, r float64, st style) {
+	p.begin(st)
+	k := r * bezierCircle
+	fmt.Fprintf(&p.content, "%s m\n", nums(cx+r, cy))
+	fmt.Fprintf(&p.content, "%s c\n", nums(cx+r, cy+k, cx+k, cy+r, cx, cy+r))
+	fmt.Fprintf(&p.content, "%s c\n", nums(cx-k, cy+r, cx-r, cy+k, cx-r, cy))
+	fmt.Fprintf(&p.content, "%s c\n", nums(cx-r, cy-k, cx-k, cy-r, cx, cy-r))
+	fmt.Fprintf(&p.content, "%s c h\n", nums(cx+k, cy-r, cx+r, cy-k, cx+r, cy))
+	p.paint(st)
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2 float64, st style) {
+	st.fill = nil
+	p.begin(st)
+	fmt.Fprintf(&p.content, "%s m %s l\n", nums(x1, y1), nums(x2, y2))
+	p.paint(st)
+}
+
+func (p *pdfPage) path(pts []point, closed bool, st style) {
+	if len(pts) < 2 {
+		return
+	}
+	if !closed {
+		st.fill = nil
+	}
+	p.begin(st)
+	fmt.Fprintf(&p.content, "%s m\n", nums(pts[0].x, pts[0].y))
+	for _, pt := range pts[1:] {
+		fmt.Fprintf(&p.content, "%s l\n", nums(pt.x, pt.y))
+	}
+	if closed {
+		p.content.WriteString("h\n")
+	}
+	p.paint(st)
+}
+
+func (p *pdfPage) text(x, y float64, s string, ts textStyle) {
+	if s == "" {
+		return
+	}
+	encoded := winAnsi(s)
+	dx := 0.0
+	switch ts.anchor {
+	case anchorMiddle:
+		dx = -textWidth(encoded, ts) / 2
+	case anchorEnd:
+		dx = -textWidth(encoded, ts)
+	}
+	if ts.central {
+		y += ts.size * centralShift
+	}
+	font := "F1"
+	if ts.bold {
+		font = "F2"
+	}
+
+	p.begin(filled(ts.color))
+	// Glyphs are drawn y-up, so the text matrix flips them back upright
+	fmt.Fprintf(&p.content, "BT /%s %s Tf 1 0 0 -1 %s Tm (%s) Tj ET Q\n", font, nums(ts.size), nums(x+dx, y), escapePDF(encoded))
+}
+
+// Text that doesn't fit the Windows-1252 set is drawn with this instead
+const missingGlyph = '?'
+
+// winAnsiExtra maps the characters Windows-1252 places in 0x80-0x9F.
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case winAnsiExtra[r] != 0:
+			out = append(out, winAnsiExtra[r])
+		default:
+			out = append(out, missingGlyph)
+		}
+	}
+	return out
+}
+
+func escapePDF(b []byte) string {
+	var out strings.Builder
+	for _, c := range b {
+		if c == '(' || c == ')' || c == '\\' {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// textWidth measures Windows-1252 text in Helvetica. Bold is approximated.
+func textWidth(b []byte, ts textStyle) float64 {
+	total := 0
+	for _, c := range b {
+		total += helveticaWidth(c)
+	}
+	w := float64(total) * ts.size / 1000
+	if ts.bold {
+		w *= 1.06
+	}
+	return w
+}
+
+// helveticaASCII holds Helvetica's glyph widths for 0x20-0x7E, in 1/1000 em.
+var helveticaASCII = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func helveticaWidth(c byte) int {
+	switch {
+	case c >= 0x20 && c <= 0x7E:
+		return helveticaASCII[c-0x20]
+	case c >= 0xC0 && c <= 0xC5, c >= 0xC8 && c <= 0xCB, c == 0xDD, c == 0xDE:
+		return 667
+	case c == 0xC6:
+		return 1000
+	case c == 0xC7, c == 0xD0, c == 0xD1, c >= 0xD9 && c <= 0xDC:
+		return 722
+	case c >= 0xCC && c <= 0xCF, c >= 0xEC && c <= 0xEF:
+		return 278
+	case c >= 0xD2 && c <= 0xD6, c == 0xD8:
+		return 778
+	case c == 0xE6:
+		return 889
+	case c == 0xE7, c == 0xFD, c == 0xFF:
+		return 500
+	case c == 0xDF, c == 0xF8:
+		return 611
+	default:
+		return 556
+	}
+}
+
+func nums(fs ...float64) string {
+	parts := make([]string, len(fs))
+	for i, f := range fs {
+		parts[i] = strconv.FormatFloat(math.Round(f*1000)/1000, 'f', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
+
+func rgb(c color) string {
+	return nums(float64(c.r)/255, float64(c.g)/255, float64(c.b)/255)
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func pageCount(t *testing.T, data []byte) int {
+	t.Helper()
+	m := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no page tree in PDF")
+	}
+	var n int
+	fmt.Sscan(string(m[1]), &n)
+	return n
+}
+
+func TestWritePDF(t *testing.T) {
+	p := testPlan()
+	var buf bytes.Buffer
+	err := WritePDF(&buf, p, PDFOptions{
+		Layouts:  []string{LayoutFloorPlan, LayoutEscortCards, LayoutPlaceCards},
+		PageSize: "a4",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("not a complete PDF document")
+	}
+	if n := pageCount(t, data); n != 3 {
+		t.Errorf("expected 3 pages, got %d", n)
+	}
+
+	if err := WritePDF(&buf, p, PDFOptions{Layouts: []string{"poster"}, PageSize: "a4"}); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+	if err := WritePDF(&buf, p, PDFOptions{Layouts: []string{LayoutFloorPlan}, PageSize: "a3"}); err == nil {
+		t.Error("expected an error for an unknown page size")
+	}
+}
+
+func TestWritePDF_PaginatesCards(t *testing.T) {
+	table := models.Table{ID: uuid.NewString(), Name: "Table 1"}
+	p := &Plan{Name: "Big day", Tables: []models.Table{table}}
+	for i := 0; i < 100; i++ {
+		seat := i
+		p.Guests = append(p.Guests, models.Guest{
+			ID: uuid.NewString(), Name: fmt.Sprintf("Guest %03d", i), AssignedTableID: &table.ID, SeatPosition: &seat,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, p, PDFOptions{Layouts: []string{LayoutPlaceCards}, PageSize: "letter"}); err != nil {
+		t.Fatal(err)
+	}
+	// Eight place cards fit on a page
+	if n := pageCount(t, buf.Bytes()); n != 13 {
+		t.Errorf("expected 13 pages of place cards, got %d", n)
+	}
+
+	buf.Reset()
+	if err := WritePDF(&buf, p, PDFOptions{Layouts: []string{LayoutEscortCards}, PageSize: "letter"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := pageCount(t, buf.Bytes()); n < 2 {
+		t.Errorf("expected escort cards to continue on a second page, got %d pages", n)
+	}
+}
+
+func TestWinAnsi(t *testing.T) {
+	got := escapePDF(winAnsi("Åsa (née) – 李\\"))
+	want := "\xc5sa \\(n\xe9e\\) \x96 ?\\\\"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInitial(t *testing.T) {
+	for name, want := range map[string]string{"Örjan": "O", "  anna": "A", "42nd guest": "#", "": "#"} {
+		if got := initial(name); got != want {
+			t.Errorf("initial(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	ts := textStyle{size: 10}
+	if got := fitText("Short", ts, 100); got != "Short" {
+		t.Errorf("got %q", got)
+	}
+	got := fitText(strings.Repeat("W", 50), ts, 60)
+	if !strings.HasSuffix(got, "…") || measure(got, ts) > 60 {
+		t.Errorf("text not cut to fit: %q", got)
+	}
+}
@@ -0,0 +1,298 @@
+package export
+
+import (
+	"image"
+	stdcolor "image/color"
+	"image/png"
+	"io"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// WritePNG draws the floor plan as a PNG image.
+func WritePNG(w io.Writer, p *Plan, opts ImageOptions) error {
+	fonts, err := goFonts()
+	if err != nil {
+		return err
+	}
+	width, height := imageSize(p, opts)
+	c := &pngCanvas{
+		transforms: newTransforms(),
+		img:        image.NewRGBA(image.Rect(0, 0, width, height)),
+		z:          vector.NewRasterizer(0, 0),
+		fonts:      fonts,
+	}
+	c.rect(rect{0, 0, float64(width), float64(height)}, 0, filled(colorWhite))
+	fitPlan(c, p, rect{0, 0, float64(width), float64(height)})
+	drawFloorPlan(c, p, drawOptions{guestNames: opts.GuestNames})
+	return png.Encode(w, c.img)
+}
+
+// goFonts parses the Go fonts PNG text is drawn with: regular and bold.
+var goFonts = sync.OnceValues(func() ([2]*sfnt.Font, error) {
+	var fonts [2]*sfnt.Font
+	for i, ttf := range [][]byte{goregular.TTF, gobold.TTF} {
+		f, err := sfnt.Parse(ttf)
+		if err != nil {
+			return fonts, err
+		}
+		fonts[i] = f
+	}
+	return fonts, nil
+})
+
+// segment is a path segment in image coordinates. Lines use pts[0], quadratic
+// curves pts[:2] and cubic curves pts[:3].
+type segment struct {
+	op  sfnt.SegmentOp
+	pts [3]point
+}
+
+// points returns the points of pts the segment uses.
+func (s segment) points() []point {
+	switch s.op {
+	case sfnt.SegmentOpQuadTo:
+		return s.pts[:2]
+	case sfnt.SegmentOpCubeTo:
+		return s.pts[:3]
+	default:
+		return s.pts[:1]
+	}
+}
+
+// pngCanvas rasterizes shapes as filled paths. Strokes are filled outlines and
+// are always drawn solid.
+type pngCanvas struct {
+	transforms
+	img   *image.RGBA
+	z     *vector.Rasterizer
+	fonts [2]*sfnt.Font
+	buf   sfnt.Buffer
+}
+
+// fill paints the closed contours of path, given in canvas coordinates.
+// Contours winding the opposite way cut holes.
+func (c *pngCanvas) fill(path []segment, col color) {
+	if len(path) == 0 {
+		return
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i := range path {
+		for j := range path[i].points() {
+			x, y := c.m.apply(path[i].pts[j].x, path[i].pts[j].y)
+			path[i].pts[j] = point{x, y}
+			minX, minY = math.Min(minX, x), math.Min(minY, y)
+			maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+		}
+	}
+	// Only the part of the image the path covers is rasterized
+	area := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).
+		Intersect(c.img.Bounds())
+	if area.Empty() {
+		return
+	}
+	c.z.Reset(area.Dx(), area.Dy())
+	ox, oy := float64(area.Min.X), float64(area.Min.Y)
+	at := func(p point) (float32, float32) { return float32(p.x - ox), float32(p.y - oy) }
+	for _, s := range path {
+		switch s.op {
+		case sfnt.SegmentOpMoveTo:
+			c.z.ClosePath()
+			c.z.MoveTo(at(s.pts[0]))
+		case sfnt.SegmentOpLineTo:
+			c.z.LineTo(at(s.pts[0]))
+		case sfnt.SegmentOpQuadTo:
+			bx, by := at(s.pts[0])
+			cx, cy := at(s.pts[1])
+			c.z.QuadTo(bx, by, cx, cy)
+		case sfnt.SegmentOpCubeTo:
+			bx, by := at(s.pts[0])
+			cx, cy := at(s.pts[1])
+			dx, dy := at(s.pts[2])
+			c.z.CubeTo(bx, by, cx, cy, dx, dy)
+		}
+	}
+	c.z.ClosePath()
+	c.z.Draw(c.img, area, image.NewUniform(stdcolor.RGBA{col.r, col.g, col.b, 0xff}), image.Point{})
+}
+
+// polygon turns points into a closed contour.
+func polygon(pts []point) []segment {
+	path := make([]segment, len(pts))
+	for i, p := range pts {
+		path[i] = segment{op: sfnt.SegmentOpLineTo, pts: [3]point{p}}
+	}
+	path[0].op = sfnt.SegmentOpMoveTo
+	return path
+}
+
+func reversed(pts []point) []point {
+	out := make([]point, len(pts))
+	for i, p := range pts {
+		out[len(pts)-1-i] = p
+	}
+	return out
+}
+
+// arc returns points along a circle from angle a0 to a1, in radians.
+func arc(cx, cy, r, a0, a1 float64, steps int) []point {
+	pts := make([]point, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		a := a0 + (a1-a0)*float64(i)/float64(steps)
+		pts = append(pts, point{cx + r*math.Cos(a), cy + r*math.Sin(a)})
+	}
+	return pts
+}
+
+func roundedRect(r rect, radius float64) []point {
+	radius = math.Max(0, math.Min(radius, math.Min(r.w, r.h)/2))
+	if radius == 0 {
+		return []point{{r.x, r.y}, {r.x + r.w, r.y}, {r.x + r.w, r.y + r.h}, {r.x, r.y + r.h}}
+	}
+	x0, y0, x1, y1 := r.x+radius, r.y+radius, r.x+r.w-radius, r.y+r.h-radius
+	var pts []point
+	pts = append(pts, arc(x1, y0, radius, -math.Pi/2, 0, 6)...)
+	pts = append(pts, arc(x1, y1, radius, 0, math.Pi/2, 6)...)
+	pts = append(pts, arc(x0, y1, radius, math.Pi/2, math.Pi, 6)...)
+	pts = append(pts, arc(x0, y0, radius, math.Pi, 3*math.Pi/2, 6)...)
+	return pts
+}
+
+func (c *pngCanvas) rect(r rect, radius float64, st style) {
+	if st.fill != nil {
+		c.fill(polygon(roundedRect(r, radius)), *st.fill)
+	}
+	if st.stroke != nil {
+		hw := st.width / 2
+		path := polygon(roundedRect(rect{r.x - hw, r.y - hw, r.w + st.width, r.h + st.width}, radius+hw))
+		if r.w > st.width && r.h > st.width {
+			inner := roundedRect(rect{r.x + hw, r.y + hw, r.w - st.width, r.h - st.width}, radius-hw)
+			path = append(path, polygon(reversed(inner))...)
+		}
+		c.fill(path, *st.stroke)
+	}
+}
+
+// circleSteps is the number of sides circles are drawn with
+const circleSteps = 48
+
+func (c *pngCanvas) circle(cx, cy, r float64, st style) {
+	if st.fill != nil {
+		c.fill(polygon(arc(cx, cy, r, 0, 2*math.Pi, circleSteps)), *st.fill)
+	}
+	if st.stroke != nil {
+		hw := st.width / 2
+		path := polygon(arc(cx, cy, r+hw, 0, 2*math.Pi, circleSteps))
+		if r > hw {
+			path = append(path, polygon(arc(cx, cy, r-hw, 2*math.Pi, 0, circleSteps))...)
+		}
+		c.fill(path, *st.stroke)
+	}
+}
+
+func (c *pngCanvas) line(x1, y1, x2, y2 float64, st style) {
+	if st.stroke == nil {
+		return
+	}
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 {
+		return
+	}
+	nx, ny := -(y2-y1)/length*st.width/2, (x2-x1)/length*st.width/2
+	c.fill(polygon([]point{{x1 + nx, y1 + ny}, {x2 + nx, y2 + ny}, {x2 - nx, y2 - ny}, {x1 - nx, y1 - ny}}), *st.stroke)
+}
+
+func (c *pngCanvas) path(pts []point, closed bool, st style) {
+	if len(pts) < 2 {
+		return
+	}
+	if st.fill != nil && closed {
+		c.fill(polygon(pts), *st.fill)
+	}
+	if st.stroke == nil {
+		return
+	}
+	if closed {
+		pts = append(pts[:len(pts):len(pts)], pts[0])
+	}
+	// Segments are stroked one by one with round joins between them
+	for i := 1; i < len(pts); i++ {
+		c.line(pts[i-1].x, pts[i-1].y, pts[i].x, pts[i].y, st)
+	}
+	for _, p := range pts {
+		c.circle(p.x, p.y, st.width/2, filled(*st.stroke))
+	}
+}
+
+func (c *pngCanvas) text(x, y float64, s string, ts textStyle) {
+	if s == "" {
+		return
+	}
+	f := c.fonts[0]
+	if ts.bold {
+		f = c.fonts[1]
+	}
+	ppem := fixed.Int26_6(math.Round(ts.size * 64))
+
+	type glyph struct {
+		index sfnt.GlyphIndex
+		x     float64
+	}
+	var glyphs []glyph
+	pen := 0.0
+	prev := sfnt.GlyphIndex(0)
+	for i, r := range []rune(s) {
+		idx, err := f.GlyphIndex(&c.buf, r)
+		if err != nil {
+			continue
+		}
+		if i > 0 {
+			if kern, err := f.Kern(&c.buf, prev, idx, ppem, font.HintingNone); err == nil {
+				pen += fromFixed(kern)
+			}
+		}
+		glyphs = append(glyphs, glyph{idx, pen})
+		if adv, err := f.GlyphAdvance(&c.buf, idx, ppem, font.HintingNone); err == nil {
+			pen += fromFixed(adv)
+		}
+		prev = idx
+	}
+
+	switch ts.anchor {
+	case anchorMiddle:
+		x -= pen / 2
+	case anchorEnd:
+		x -= pen
+	}
+	if ts.central {
+		y += ts.size * centralShift
+	}
+
+	var path []segment
+	for _, g := range glyphs {
+		segs, err := f.LoadGlyph(&c.buf, g.index, ppem, nil)
+		if err != nil {
+			continue
+		}
+		for _, s := range segs {
+			seg := segment{op: s.Op}
+			for j, a := range s.Args {
+				seg.pts[j] = point{x + g.x + fromFixed(a.X), y + fromFixed(a.Y)}
+			}
+			path = append(path, seg)
+		}
+	}
+	c.fill(path, ts.color)
+}
+
+func fromFixed(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
@@ -0,0 +1,221 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// PDF layouts
+const (
+	// LayoutFloorPlan is the plan drawn to scale on a landscape page
+	LayoutFloorPlan = "floor-plan"
+	// LayoutEscortCards is an alphabetical list of guests and where they sit
+	LayoutEscortCards = "escort-cards"
+	// LayoutPlaceCards is a card per seated guest, grouped by table
+	LayoutPlaceCards = "place-cards"
+)
+
+// ValidLayout reports whether layout names a PDF layout.
+func ValidLayout(layout string) bool {
+	switch layout {
+	case LayoutFloorPlan, LayoutEscortCards, LayoutPlaceCards:
+		return true
+	}
+	return false
+}
+
+// PDFOptions configures WritePDF.
+type PDFOptions struct {
+	// Layouts are rendered in order, each starting on a new page
+	Layouts []string
+	// PageSize is "a4" or "letter"
+	PageSize string
+}
+
+const pageMargin = 36.0 // half an inch
+
+// WritePDF renders the requested layouts of p into a single PDF.
+func WritePDF(w io.Writer, p *Plan, opts PDFOptions) error {
+	size, ok := pageSizes[opts.PageSize]
+	if !ok {
+		return fmt.Errorf("unknown page size %q", opts.PageSize)
+	}
+	doc := &pdfDoc{}
+	for _, layout := range opts.Layouts {
+		switch layout {
+		case LayoutFloorPlan:
+			floorPlanPage(doc, p, size)
+		case LayoutEscortCards:
+			escortCardPages(doc, p, size)
+		case LayoutPlaceCards:
+			placeCardPages(doc, p, size)
+		default:
+			return fmt.Errorf("unknown layout %q", layout)
+		}
+	}
+	return doc.write(w)
+}
+
+func floorPlanPage(doc *pdfDoc, p *Plan, size [2]float64) {
+	page := doc.addPage(size[1], size[0])
+	title := textStyle{size: 16, bold: true, color: colorBlack}
+	page.text(pageMargin, pageMargin+16, p.Name, title)
+
+	top := pageMargin + 28
+	area := rect{pageMargin, top, page.w - 2*pageMargin, page.h - top - pageMargin}
+	page.save()
+	fitPlan(page, p, area)
+	drawFloorPlan(page, p, drawOptions{guestNames: true})
+	page.restore()
+}
+
+func escortCardPages(doc *pdfDoc, p *Plan, size [2]float64) {
+	const (
+		columns   = 2
+		rowHeight = 18.0
+		gutter    = 24.0
+	)
+	nameStyle := textStyle{size: 11, color: colorBlack}
+	seatStyle := textStyle{size: 11, color: colorMuted, anchor: anchorEnd}
+	letterStyle := textStyle{size: 13, bold: true, color: colorBlack}
+
+	type entry struct {
+		letter     string
+		name, seat string
+	}
+	var entries []entry
+	last := ""
+	for _, g := range p.sortedGuests() {
+		if letter := initial(g.Name); letter != last {
+			entries = append(entries, entry{letter: letter})
+			last = letter
+		}
+		seat := "Not yet seated"
+		if t := p.tableOf(g); t != nil {
+			seat = t.Name
+			if n := seatNumber(g); n != "" {
+				seat += ", seat " + n
+			}
+		}
+		entries = append(entries, entry{name: g.Name, seat: seat})
+	}
+
+	colW := (size[0] - 2*pageMargin - gutter*(columns-1)) / columns
+	top := pageMargin + 40
+	rows := int((size[1] - top - pageMargin) / rowHeight)
+
+	newPage := func() *pdfPage {
+		page := doc.addPage(size[0], size[1])
+		page.text(pageMargin, pageMargin+16, p.Name, textStyle{size: 16, bold: true, color: colorBlack})
+		page.text(pageMargin, pageMargin+30, "Find your table", textStyle{size: 10, color: colorMuted})
+		return page
+	}
+	page := newPage()
+	if len(entries) == 0 {
+		page.text(pageMargin, top+rowHeight, "No guests", nameStyle)
+		return
+	}
+	for i, e := range entries {
+		slot := i % (rows * columns)
+		if i > 0 && slot == 0 {
+			page = newPage()
+		}
+		x := pageMargin + float64(slot/rows)*(colW+gutter)
+		y := top + float64(slot%rows+1)*rowHeight
+		if e.letter != "" {
+			page.text(x, y, e.letter, letterStyle)
+			page.line(x, y+4, x+colW, y+4, stroked(colorRule, 0.5))
+			continue
+		}
+		seatW := measure(e.seat, seatStyle)
+		page.text(x+colW, y, e.seat, seatStyle)
+		page.text(x, y, fitText(e.name, nameStyle, colW-seatW-12), nameStyle)
+	}
+}
+
+func placeCardPages(doc *pdfDoc, p *Plan, size [2]float64) {
+	const (
+		columns = 2
+		rows    = 4
+		inset   = 14.0
+	)
+	type card struct {
+		name, table, seat, dietary string
+	}
+	var cards []card
+	for _, t := range p.sortedTables() {
+		for _, g := range p.tableGuests(t) {
+			cards = append(cards, card{name: g.Name, table: t.Name, seat: seatNumber(g), dietary: dietaryText(g)})
+		}
+	}
+
+	cardW := (size[0] - 2*pageMargin) / columns
+	cardH := (size[1] - 2*pageMargin) / rows
+	var page *pdfPage
+	if len(cards) == 0 {
+		page = doc.addPage(size[0], size[1])
+		page.text(pageMargin, pageMargin+16, "No seated guests", textStyle{size: 11, color: colorBlack})
+		return
+	}
+	for i, c := range cards {
+		slot := i % (columns * rows)
+		if slot == 0 {
+			page = doc.addPage(size[0], size[1])
+		}
+		box := rect{pageMargin + float64(slot%columns)*cardW, pageMargin + float64(slot/columns)*cardH, cardW, cardH}
+		cut := stroked(colorRule, 0.75)
+		cut.dashed = true
+		page.rect(box, 0, cut)
+
+		inner := box.w - 2*inset
+		nameStyle := textStyle{size: 22, bold: true, color: colorBlack, anchor: anchorMiddle, central: true}
+		for nameStyle.size > 10 && measure(c.name, nameStyle) > inner {
+			nameStyle.size--
+		}
+		cx, cy := box.x+box.w/2, box.y+box.h/2
+		page.text(cx, cy-8, fitText(c.name, nameStyle, inner), nameStyle)
+
+		where := c.table
+		if c.seat != "" {
+			where += " · Seat " + c.seat
+		}
+		page.text(cx, cy+18, fitText(where, textStyle{size: 11}, inner), textStyle{size: 11, color: colorMuted, anchor: anchorMiddle, central: true})
+		if c.dietary != "" {
+			ts := textStyle{size: 9, color: colorMuted, anchor: anchorMiddle, central: true}
+			page.text(cx, box.y+box.h-inset, fitText(c.dietary, ts, inner), ts)
+		}
+	}
+}
+
+// initial is the letter a name is listed under in the escort card list.
+// Accents are dropped, matching the collation guests are sorted by.
+func initial(name string) string {
+	for _, r := range norm.NFD.String(name) {
+		if unicode.IsLetter(r) {
+			return string(unicode.ToUpper(r))
+		}
+		if unicode.IsDigit(r) {
+			return "#"
+		}
+	}
+	return "#"
+}
+
+func measure(s string, ts textStyle) float64 {
+	return textWidth(winAnsi(s), ts)
+}
+
+// fitText cuts s short with an ellipsis so it fits within width.
+func fitText(s string, ts textStyle, width float64) string {
+	if measure(s, ts) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 1 && measure(string(r)+"…", ts) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Media types of the floor plan images
+const (
+	ContentTypeSVG = "image/svg+xml"
+	ContentTypePNG = "image/png"
+)
+
+// Image widths in pixels
+const (
+	DefaultImageWidth = 1200
+	MaxImageWidth     = 4000
+	// MaxSharedImageWidth bounds images rendered for public share links
+	MaxSharedImageWidth = 1600
+)
+
+// ImageOptions configures WriteSVG and WritePNG.
+type ImageOptions struct {
+	// Width of the image in pixels; the height follows the plan's shape
+	Width int
+	// GuestNames draws the name of each seated guest next to their seat
+	GuestNames bool
+	// MaxSize bounds both sides of the image in pixels, below MaxImageWidth;
+	// zero leaves it at MaxImageWidth
+	MaxSize int
+}
+
+// imageSize fits the plan into an image opts.Width wide, keeping both sides
+// within opts.MaxSize.
+func imageSize(p *Plan, opts ImageOptions) (int, int) {
+	limit := MaxImageWidth
+	if opts.MaxSize > 0 {
+		limit = min(opts.MaxSize, MaxImageWidth)
+	}
+	b := p.bounds()
+	w := float64(min(max(opts.Width, 1), limit))
+	h := w * b.h / b.w
+	if h > float64(limit) {
+		w, h = w*float64(limit)/h, float64(limit)
+	}
+	return max(int(math.Round(w)), 1), max(int(math.Round(h)), 1)
+}
+
+// WriteSVG draws the floor plan as an SVG image.
+func WriteSVG(w io.Writer, p *Plan, opts ImageOptions) error {
+	width, height := imageSize(p, opts)
+	c := &svgCanvas{transforms: newTransforms()}
+	fmt.Fprintf(&c.out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&c.out, "<title>%s</title>\n", escape(p.Name))
+	c.rect(rect{0, 0, float64(width), float64(height)}, 0, filled(colorWhite))
+	fitPlan(c, p, rect{0, 0, float64(width), float64(height)})
+	drawFloorPlan(c, p, drawOptions{guestNames: opts.GuestNames})
+	c.out.WriteString("</svg>\n")
+	_, err := io.WriteString(w, c.out.String())
+	return err
+}
+
+// svgCanvas writes each shape as an element carrying the current transform.
+type svgCanvas struct {
+	transforms
+	out strings.Builder
+}
+
+func (c *svgCanvas) attrs(st style) string {
+	var a strings.Builder
+	if c.m != identity {
+		fmt.Fprintf(&a, ` transform="matrix(%s)"`, nums(c.m[:]...))
+	}
+	if st.fill != nil {
+		fmt.Fprintf(&a, ` fill="%s"`, st.fill.css())
+	} else {
+		a.WriteString(` fill="none"`)
+	}
+	if st.stroke != nil {
+		fmt.Fprintf(&a, ` stroke="%s" stroke-width="%s"`, st.stroke.css(), nums(st.width))
+		if st.dashed {
+			fmt.Fprintf(&a, ` stroke-dasharray="%s"`, nums(st.width*3, st.width*2))
+		}
+	}
+	return a.String()
+}
+
+func (c *svgCanvas) rect(r rect, radius float64, st style) {
+	fmt.Fprintf(&c.out, `<rect x="%s" y="%s" width="%s" height="%s"`, nums(r.x), nums(r.y), nums(r.w), nums(r.h))
+	if radius > 0 {
+		fmt.Fprintf(&c.out, ` rx="%s"`, nums(radius))
+	}
+	fmt.Fprintf(&c.out, "%s/>\n", c.attrs(st))
+}
+
+func (c *svgCanvas) circle(cx, cy, r float64, st style) {
+	fmt.Fprintf(&c.out, `<circle cx="%s" cy="%s" r="%s"%s/>`+"\n", nums(cx), nums(cy), nums(r), c.attrs(st))
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2 float64, st style) {
+	st.fill = nil
+	fmt.Fprintf(&c.out, `<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`+"\n", nums(x1), nums(y1), nums(x2), nums(y2), c.attrs(st))
+}
+
+func (c *svgCanvas) path(pts []point, closed bool, st style) {
+	if len(pts) < 2 {
+		return
+	}
+	coords := make([]float64, 0, 2*len(pts))
+	for _, p := range pts {
+		coords = append(coords, p.x, p.y)
+	}
+	element := "polyline"
+	if closed {
+		element = "polygon"
+	} else {
+		st.fill = nil
+	}
+	fmt.Fprintf(&c.out, `<%s points="%s"%s/>`+"\n", element, nums(coords...), c.attrs(st))
+}
+
+func (c *svgCanvas) text(x, y float64, s string, ts textStyle) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&c.out, `<text x="%s" y="%s" font-size="%s"`, nums(x), nums(y), nums(ts.size))
+	if ts.bold {
+		c.out.WriteString(` font-weight="bold"`)
+	}
+	switch ts.anchor {
+	case anchorMiddle:
+		c.out.WriteString(` text-anchor="middle"`)
+	case anchorEnd:
+		c.out.WriteString(` text-anchor="end"`)
+	}
+	if ts.central {
+		c.out.WriteString(` dominant-baseline="central"`)
+	}
+	fmt.Fprintf(&c.out, "%s>%s</text>\n", c.attrs(filled(ts.color)), escape(s))
+}
+
+func (c color) css() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+}
@@ -0,0 +1,251 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+// ContentTypeXLSX is the media type of an Excel workbook.
+const ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// cell is a spreadsheet cell holding either text or a number.
+type cell struct {
+	text   string
+	number *int
+}
+
+func text(s string) cell { return cell{text: s} }
+func number(n int) cell  { return cell{number: &n} }
+
+type sheet struct {
+	name string
+	rows [][]cell
+}
+
+// WriteXLSX writes the seating of p as a workbook with three sheets: guests in
+// alphabetical order with their table and seat, tables with their seated
+// guests, and dietary restriction counts per table.
+func WriteXLSX(w io.Writer, p *Plan) error {
+	return writeWorkbook(w, []sheet{guestSheet(p), tableSheet(p), dietarySheet(p)})
+}
+
+func guestSheet(p *Plan) sheet {
+	s := sheet{name: "Guests", rows: [][]cell{
+		{text("Name"), text("Table"), text("Seat"), text("Dietary restrictions"), text("Guest of")},
+	}}
+	names := make(map[string]string, len(p.Guests))
+	for _, g := range p.Guests {
+		names[g.ID] = g.Name
+	}
+	for _, g := range p.sortedGuests() {
+		table := ""
+		if t := p.tableOf(g); t != nil {
+			table = t.Name
+		}
+		host := ""
+		if g.GuestOf != nil {
+			host = names[*g.GuestOf]
+		}
+		s.rows = append(s.rows, []cell{text(g.Name), text(table), text(seatNumber(g)), text(dietaryText(g)), text(host)})
+	}
+	return s
+}
+
+func tableSheet(p *Plan) sheet {
+	s := sheet{name: "Tables", rows: [][]cell{
+		{text("Table"), text("Seat"), text("Guest"), text("Dietary restrictions")},
+	}}
+	for _, t := range p.sortedTables() {
+		guests := p.tableGuests(t)
+		if len(guests) == 0 {
+			s.rows = append(s.rows, []cell{text(t.Name), text(""), text(fmt.Sprintf("(empty, %d seats)", t.Capacity))})
+			continue
+		}
+		for _, g := range guests {
+			s.rows = append(s.rows, []cell{text(t.Name), text(seatNumber(g)), text(g.Name), text(dietaryText(g))})
+		}
+	}
+	return s
+}
+
+func dietarySheet(p *Plan) sheet {
+	tables := p.sortedTables()
+	header := []cell{text("Dietary restriction"), text("Total")}
+	for _, t := range tables {
+		header = append(header, text(t.Name))
+	}
+	header = append(header, text("Unassigned"))
+	s := sheet{name: "Dietary", rows: [][]cell{header}}
+
+	// Column of each table, after the label and total columns
+	column := make(map[string]int, len(tables))
+	for i, t := range tables {
+		column[t.ID] = i + 2
+	}
+	unassigned := len(tables) + 2
+
+	count := func(match func(g models.Guest) bool) []cell {
+		counts := make([]int, len(tables)+3)
+		for _, g := range p.Guests {
+			if !match(g) {
+				continue
+			}
+			counts[1]++
+			if t := p.tableOf(g); t != nil {
+				counts[column[t.ID]]++
+			} else {
+				counts[unassigned]++
+			}
+		}
+		row := make([]cell, len(counts))
+		for i := 1; i < len(counts); i++ {
+			row[i] = number(counts[i])
+		}
+		return row
+	}
+
+	for _, d := range dietaryOrder {
+		row := count(func(g models.Guest) bool { return hasDietary(g, d) })
+		row[0] = text(dietaryLabels[d])
+		s.rows = append(s.rows, row)
+	}
+	row := count(func(g models.Guest) bool { return dietaryText(g) == "" })
+	row[0] = text("No restrictions")
+	s.rows = append(s.rows, row)
+	row = count(func(models.Guest) bool { return true })
+	row[0] = text("All guests")
+	s.rows = append(s.rows, row)
+	return s
+}
+
+// writeWorkbook writes a minimal Office Open XML workbook. Cells hold inline
+// strings so no shared string table is needed; the header row is bold and
+// frozen.
+func writeWorkbook(w io.Writer, sheets []sheet) error {
+	zw := zip.NewWriter(w)
+	add := func(name, content string) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, content)
+		return err
+	}
+
+	var overrides, workbookSheets, rels bytes.Buffer
+	for i, s := range sheets {
+		n := i + 1
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbookSheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			overrides.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + workbookSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+	}
+	for _, part := range parts {
+		if err := add(part.name, part.content); err != nil {
+			return err
+		}
+	}
+	for i, s := range sheets {
+		if err := add(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), worksheetXML(s)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+const maxColumnWidth = 60
+
+func worksheetXML(s sheet) string {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	// Size columns to their longest value
+	var widths []int
+	for _, row := range s.rows {
+		for i, c := range row {
+			for len(widths) <= i {
+				widths = append(widths, 8)
+			}
+			n := utf8.RuneCountInString(c.text) + 2
+			if c.number != nil {
+				n = len(strconv.Itoa(*c.number)) + 2
+			}
+			widths[i] = max(widths[i], min(n, maxColumnWidth))
+		}
+	}
+	if len(widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		style := ""
+		if r == 0 {
+			style = ` s="1"`
+		}
+		for c, value := range row {
+			ref := columnName(c) + strconv.Itoa(r+1)
+			switch {
+			case value.number != nil:
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%d</v></c>`, ref, style, *value.number)
+			case value.text != "":
+				fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(value.text))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// columnName converts a 0-based column index to its letters: A, B, ..., Z, AA.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
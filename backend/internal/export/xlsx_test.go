@@ -0,0 +1,114 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func testPlan() *Plan {
+	t1 := models.Table{ID: uuid.NewString(), Name: "Table 10", Capacity: 4}
+	t2 := models.Table{ID: uuid.NewString(), Name: "Table 2", Capacity: 4}
+	seat0, seat2 := 0, 2
+	return &Plan{
+		Name:   "Wedding",
+		Tables: []models.Table{t1, t2},
+		Guests: []models.Guest{
+			{ID: uuid.NewString(), Name: "Örjan", AssignedTableID: &t1.ID, SeatPosition: &seat2,
+				DietaryRestrictions: []models.DietaryRestriction{models.DietaryVegan}},
+			{ID: uuid.NewString(), Name: "anna & <co>", AssignedTableID: &t2.ID, SeatPosition: &seat0,
+				DietaryRestrictions: []models.DietaryRestriction{models.DietaryVegan, models.DietaryLactoseIntolerant}},
+			{ID: uuid.NewString(), Name: "Bertil", DietaryRestrictions: []models.DietaryRestriction{models.DietaryNone}},
+		},
+	}
+}
+
+// readSheets returns the XML of every part in an XLSX file, checking each is well-formed.
+func readSheets(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		dec := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(content)
+	}
+	return parts
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, testPlan()); err != nil {
+		t.Fatalf("WriteXLSX: %v", err)
+	}
+	parts := readSheets(t, buf.Bytes())
+
+	for _, name := range []string{"[Content_Types].xml", "xl/workbook.xml", "xl/styles.xml", "xl/worksheets/sheet3.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Guests"`) {
+		t.Error("expected a Guests sheet")
+	}
+
+	// Guests are alphabetical regardless of case and accents
+	guests := parts["xl/worksheets/sheet1.xml"]
+	a, b, o := strings.Index(guests, "anna &amp; &lt;co&gt;"), strings.Index(guests, "Bertil"), strings.Index(guests, "Örjan")
+	if a < 0 || b < a || o < b {
+		t.Errorf("expected guests in alphabetical order, got %s", guests)
+	}
+	if !strings.Contains(guests, "Vegan, Lactose Intolerant") {
+		t.Error("expected dietary labels")
+	}
+
+	// Tables sort numerically by name
+	tables := parts["xl/worksheets/sheet2.xml"]
+	if strings.Index(tables, "Table 2") > strings.Index(tables, "Table 10") {
+		t.Error("expected Table 2 before Table 10")
+	}
+
+	// Vegan: 2 in total, one at each table, none unassigned
+	dietary := parts["xl/worksheets/sheet3.xml"]
+	if !strings.Contains(dietary, `<t xml:space="preserve">Vegan</t></is></c><c r="B3"><v>2</v></c><c r="C3"><v>1</v></c><c r="D3"><v>1</v></c><c r="E3"><v>0</v></c>`) {
+		t.Errorf("unexpected vegan counts in %s", dietary)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestFilename(t *testing.T) {
+	if got := Filename(" Gala 2026/Main ", "xlsx"); got != "Gala 2026_Main.xlsx" {
+		t.Errorf("unexpected filename %q", got)
+	}
+	if got := Filename("", "pdf"); got != "floor-plan.pdf" {
+		t.Errorf("unexpected filename %q", got)
+	}
+}
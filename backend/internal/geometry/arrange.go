@@ -0,0 +1,329 @@
+package geometry
+
+import (
+	"errors"
+	"math"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+// ErrNoFit is returned when the tables can't all be placed in the room.
+var ErrNoFit = errors.New("the tables don't fit in the room")
+
+// ErrTooLarge is returned when the room or spacing is too large to arrange
+// tables in, either beyond what float64 can hold or in more steps than
+// maxAttempts.
+var ErrTooLarge = errors.New("the room is too large to arrange tables in")
+
+// maxAttempts bounds how many positions Arrange tries across all tables.
+const maxAttempts = 50000
+
+// ArrangeOptions tunes an automatic layout. Lengths are in room units, or
+// plan coordinates without a room.
+type ArrangeOptions struct {
+	Style models.AutoLayoutStyle
+	// Spacing is the smallest gap left between table footprints and in front
+	// of doors
+	Spacing float64
+	// Width and Height give the area to fill when the room has no outline;
+	// zero leaves it unbounded
+	Width, Height float64
+}
+
+// Placement is where Arrange puts a table.
+type Placement struct {
+	Position models.Position
+	Rotation float64
+}
+
+// Arrange proposes a position and rotation for each table, in order, so no
+// two footprints are closer than opts.Spacing and none is outside the room,
+// on an obstacle or in front of a door. Check finds no issues in the result
+// with the same spacing as MinAisle.
+//
+// Grid lines the tables up in equal cells, banquet packs them into long rows
+// along the room's longer side, and radial puts them in rings around the
+// room's stage, or the middle of the room without one.
+func Arrange(tables []models.Table, room *models.RoomGeometry, opts ArrangeOptions) ([]Placement, error) {
+	scale := 1.0
+	if room != nil && room.Scale > 0 {
+		scale = room.Scale
+	}
+	s := newSite(room, opts, scale, tables)
+	if !s.finite() {
+		return nil, ErrTooLarge
+	}
+
+	switch opts.Style {
+	case models.AutoLayoutBanquet:
+		return s.arrangeRows(tables)
+	case models.AutoLayoutRadial:
+		return s.arrangeRings(tables, room)
+	default:
+		return s.arrangeGrid(tables)
+	}
+}
+
+// site is the area tables are arranged in, with what has been placed so far.
+type site struct {
+	outline   Polygon
+	obstacles []models.Obstacle
+	shapes    []Polygon
+	gap       float64
+	// stride is gap plus a little slack, so rounding can't bring tables
+	// placed side by side closer than gap
+	stride float64
+
+	minX, minY, maxX, maxY float64
+	placed                 []Polygon
+	attempts               int
+}
+
+func newSite(room *models.RoomGeometry, opts ArrangeOptions, scale float64, tables []models.Table) *site {
+	s := &site{gap: opts.Spacing * scale, stride: opts.Spacing*scale + areaEpsilon}
+	if room != nil {
+		s.obstacles = room.Obstacles
+		for _, o := range room.Obstacles {
+			s.shapes = append(s.shapes, points(o.Points))
+		}
+		if len(room.Outline) >= 3 {
+			s.outline = points(room.Outline)
+		}
+	}
+
+	switch {
+	case s.outline != nil:
+		s.minX, s.minY, s.maxX, s.maxY = s.outline.bounds()
+	case opts.Width > 0 && opts.Height > 0:
+		s.maxX, s.maxY = opts.Width*scale, opts.Height*scale
+		s.outline = Polygon{{0, 0}, {s.maxX, 0}, {s.maxX, s.maxY}, {0, s.maxY}}
+	default:
+		// Unbounded: about as many columns as rows of the largest table
+		w := 0.0
+		for _, t := range tables {
+			fw, _ := size(t, 0)
+			w = math.Max(w, fw)
+		}
+		cols := math.Ceil(math.Sqrt(float64(len(tables))))
+		s.maxX, s.maxY = cols*(w+s.stride), math.Inf(1)
+	}
+	return s
+}
+
+// finite reports whether the site's bounds and spacing are finite numbers.
+// Only an unbounded site may have an infinite bottom edge.
+func (s *site) finite() bool {
+	for _, v := range []float64{s.gap, s.stride, s.minX, s.minY, s.maxX} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return !math.IsNaN(s.maxY) && !math.IsInf(s.maxY, -1)
+}
+
+// try counts a position tried, reporting false once maxAttempts are used up.
+// Far enough from the origin, a step can also round away to nothing, so this
+// is what ends the search there.
+func (s *site) try() bool {
+	s.attempts++
+	return s.attempts <= maxAttempts
+}
+
+// fits reports whether a footprint can go in the room next to the tables
+// placed so far.
+func (s *site) fits(fp Polygon) bool {
+	if s.outline != nil && !Inside(fp, s.outline) {
+		return false
+	}
+	for i, o := range s.obstacles {
+		switch {
+		case o.Type == models.ObstacleDoor:
+			if LineDistance(fp, s.shapes[i]) < s.gap {
+				return false
+			}
+		case o.Type.Open():
+			if Crosses(fp, s.shapes[i]) {
+				return false
+			}
+		default:
+			if Overlaps(fp, s.shapes[i]) {
+				return false
+			}
+		}
+	}
+	for _, p := range s.placed {
+		if boxesMeet(fp, p, s.gap) && Distance(fp, p) < s.gap {
+			return false
+		}
+	}
+	return true
+}
+
+// place puts t with its footprint's top left corner at x, y if it fits.
+func (s *site) place(t models.Table, rotation, x, y float64) (Placement, bool) {
+	t.Rotation, t.Position = rotation, models.Position{}
+	minX, minY, _, _ := Footprint(t).bounds()
+	t.Position = models.Position{X: x - minX, Y: y - minY}
+	return s.placeAt(t)
+}
+
+// placeAt places t at its position and rotation if it fits.
+func (s *site) placeAt(t models.Table) (Placement, bool) {
+	fp := Footprint(t)
+	if !s.fits(fp) {
+		return Placement{}, false
+	}
+	s.placed = append(s.placed, fp)
+	return Placement{Position: t.Position, Rotation: t.Rotation}, true
+}
+
+// arrangeGrid places the tables unrotated in equal cells, row by row,
+// skipping cells that are blocked. The grid is centered across the room.
+func (s *site) arrangeGrid(tables []models.Table) ([]Placement, error) {
+	cw, ch := 0.0, 0.0
+	for _, t := range tables {
+		w, h := size(t, 0)
+		cw, ch = math.Max(cw, w), math.Max(ch, h)
+	}
+	cols := math.Floor((s.maxX - s.minX + s.stride) / (cw + s.stride))
+	left := s.minX + (s.maxX-s.minX-cols*(cw+s.stride)+s.stride)/2
+
+	out := make([]Placement, len(tables))
+	x, y := left, s.minY
+	for i, t := range tables {
+		w, h := size(t, 0)
+		for {
+			if x+cw > s.maxX {
+				x, y = left, y+ch+s.stride
+			}
+			if y+ch > s.maxY {
+				return nil, ErrNoFit
+			}
+			if !s.try() {
+				return nil, ErrTooLarge
+			}
+			// Center the table in its cell
+			p, ok := s.place(t, 0, x+(cw-w)/2, y+(ch-h)/2)
+			x += cw + s.stride
+			if ok {
+				out[i] = p
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// arrangeRows packs the tables end to end into straight rows along the longer
+// side of the room, sliding past anything in the way.
+func (s *site) arrangeRows(tables []models.Table) ([]Placement, error) {
+	rotation := 0.0
+	if !math.IsInf(s.maxY, 1) && s.maxY-s.minY > s.maxX-s.minX {
+		rotation = 90
+	}
+
+	rowHeight := 0.0
+	for _, t := range tables {
+		_, h := size(t, rotation)
+		rowHeight = math.Max(rowHeight, h)
+	}
+
+	out := make([]Placement, len(tables))
+	x, y := s.minX, s.minY
+	for i, t := range tables {
+		w, h := size(t, rotation)
+		step := (w + s.stride) / 4
+		for {
+			if x+w > s.maxX {
+				x, y = s.minX, y+rowHeight+s.stride
+			}
+			if y+rowHeight > s.maxY {
+				return nil, ErrNoFit
+			}
+			if !s.try() {
+				return nil, ErrTooLarge
+			}
+			if p, ok := s.place(t, rotation, x, y+(rowHeight-h)/2); ok {
+				out[i] = p
+				x += w + s.stride
+				break
+			}
+			x += step
+		}
+	}
+	return out, nil
+}
+
+// arrangeRings places the tables in rings around the stage, turned to face
+// it, going clockwise from the top of each ring.
+func (s *site) arrangeRings(tables []models.Table, room *models.RoomGeometry) ([]Placement, error) {
+	center := Point{(s.minX + s.maxX) / 2, (s.minY + s.maxY) / 2}
+	if math.IsInf(s.maxY, 1) {
+		center = Point{}
+	}
+	inner := 0.0
+	if room != nil {
+		for i, o := range room.Obstacles {
+			if o.Type == models.ObstacleStage {
+				center = s.shapes[i].centroid()
+				for _, pt := range s.shapes[i] {
+					inner = math.Max(inner, math.Hypot(pt.X-center.X, pt.Y-center.Y))
+				}
+				break
+			}
+		}
+	}
+
+	// Ring width is the deepest footprint; tables are turned so their depth
+	// points at the center
+	depth, width := 0.0, 0.0
+	for _, t := range tables {
+		w, h := size(t, 0)
+		depth, width = math.Max(depth, h), math.Max(width, w)
+	}
+	outer := math.Inf(1)
+	if !math.IsInf(s.maxY, 1) {
+		outer = math.Hypot(s.maxX-s.minX, s.maxY-s.minY) + width
+	}
+
+	out := make([]Placement, len(tables))
+	radius := inner + s.stride + depth/2
+	angle := -math.Pi / 2
+	for i, t := range tables {
+		w, _ := size(t, 0)
+		for {
+			if radius > outer {
+				return nil, ErrNoFit
+			}
+			if !s.try() {
+				return nil, ErrTooLarge
+			}
+			// Angle taken up by the table at this radius, and where the ring ends
+			span := 2 * math.Asin(math.Min(1, (w+s.stride)/(2*radius)))
+			if angle+span/2 > 3*math.Pi/2 {
+				radius, angle = radius+depth+s.stride, -math.Pi/2
+				continue
+			}
+			t.Position = models.Position{
+				X: center.X + radius*math.Cos(angle+span/2),
+				Y: center.Y + radius*math.Sin(angle+span/2),
+			}
+			t.Rotation = math.Mod((angle+span/2)*180/math.Pi+90+360, 360)
+			if p, ok := s.placeAt(t); ok {
+				out[i] = p
+				angle += span
+				break
+			}
+			angle += span / 4
+		}
+	}
+	return out, nil
+}
+
+// size returns the width and height of a table's footprint when turned by
+// rotation degrees.
+func size(t models.Table, rotation float64) (w, h float64) {
+	t.Rotation, t.Position = rotation, models.Position{}
+	minX, minY, maxX, maxY := Footprint(t).bounds()
+	return maxX - minX, maxY - minY
+}
@@ -0,0 +1,104 @@
+package geometry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+func arrangeTables() []models.Table {
+	var tables []models.Table
+	for i := 0; i < 12; i++ {
+		t := lineTable(fmt.Sprintf("t%d", i), 0, 0, 0)
+		if i%3 == 0 {
+			t.TableType = models.TableTypeRound
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
+
+func TestArrange(t *testing.T) {
+	room := &models.RoomGeometry{
+		Units: models.RoomUnitMeters,
+		Scale: 100,
+		Outline: []models.Position{
+			{X: 0, Y: 0}, {X: 2400, Y: 0}, {X: 2400, Y: 1600}, {X: 0, Y: 1600},
+		},
+		Obstacles: []models.Obstacle{
+			{Type: models.ObstacleStage, Points: []models.Position{{X: 1000, Y: 0}, {X: 1400, Y: 0}, {X: 1400, Y: 200}, {X: 1000, Y: 200}}},
+			{Type: models.ObstaclePillar, Points: []models.Position{{X: 600, Y: 600}, {X: 640, Y: 600}, {X: 640, Y: 640}, {X: 600, Y: 640}}},
+			{Type: models.ObstacleDoor, Points: []models.Position{{X: 0, Y: 700}, {X: 0, Y: 900}}},
+		},
+	}
+
+	for _, style := range []models.AutoLayoutStyle{models.AutoLayoutGrid, models.AutoLayoutBanquet, models.AutoLayoutRadial} {
+		t.Run(string(style), func(t *testing.T) {
+			tables := arrangeTables()
+			placements, err := Arrange(tables, room, ArrangeOptions{Style: style, Spacing: 0.9})
+			if err != nil {
+				t.Fatalf("Arrange() error = %v", err)
+			}
+			for i, p := range placements {
+				tables[i].Position, tables[i].Rotation = p.Position, p.Rotation
+			}
+			if issues := Check(tables, room, Options{MinAisle: 0.9}); len(issues) != 0 {
+				t.Errorf("expected a clean layout, got %+v", issues)
+			}
+		})
+	}
+}
+
+func TestArrange_Rotation(t *testing.T) {
+	tall := ArrangeOptions{Style: models.AutoLayoutBanquet, Spacing: 50, Width: 1000, Height: 3000}
+	placements, err := Arrange(arrangeTables()[1:3], nil, tall)
+	if err != nil {
+		t.Fatalf("Arrange() error = %v", err)
+	}
+	if placements[0].Rotation != 90 {
+		t.Errorf("expected banquet rows along the long side, got rotation %v", placements[0].Rotation)
+	}
+
+	radial := ArrangeOptions{Style: models.AutoLayoutRadial, Spacing: 50}
+	placements, err = Arrange(arrangeTables()[1:3], nil, radial)
+	if err != nil {
+		t.Fatalf("Arrange() error = %v", err)
+	}
+	for _, p := range placements {
+		// The table's long side runs across the line to the center
+		angle := math.Atan2(p.Position.Y, p.Position.X) * 180 / math.Pi
+		if d := math.Mod(p.Rotation-angle+360, 180); math.Abs(d-90) > 1e-6 {
+			t.Errorf("table at %v turned %v, not facing the center", p.Position, p.Rotation)
+		}
+	}
+}
+
+func TestArrange_NoFit(t *testing.T) {
+	small := ArrangeOptions{Style: models.AutoLayoutGrid, Spacing: 50, Width: 500, Height: 500}
+	for _, style := range []models.AutoLayoutStyle{models.AutoLayoutGrid, models.AutoLayoutBanquet, models.AutoLayoutRadial} {
+		small.Style = style
+		if _, err := Arrange(arrangeTables(), nil, small); !errors.Is(err, ErrNoFit) {
+			t.Errorf("%s: expected ErrNoFit, got %v", style, err)
+		}
+	}
+}
+
+func TestArrange_TooLarge(t *testing.T) {
+	// Bounds past what float64 holds
+	huge := ArrangeOptions{Style: models.AutoLayoutGrid, Spacing: 50, Width: 1e308, Height: 1e308}
+	if _, err := Arrange(arrangeTables(), &models.RoomGeometry{Scale: 10}, huge); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+
+	// Far enough out that a step rounds away to nothing
+	far := &models.RoomGeometry{Outline: []models.Position{{X: 1e20, Y: 0}, {X: 1e20 + 1e6, Y: 0}, {X: 1e20 + 1e6, Y: 1e6}, {X: 1e20, Y: 1e6}}}
+	for _, style := range []models.AutoLayoutStyle{models.AutoLayoutGrid, models.AutoLayoutBanquet} {
+		huge.Style = style
+		if _, err := Arrange(arrangeTables(), far, huge); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s: expected ErrTooLarge, got %v", style, err)
+		}
+	}
+}
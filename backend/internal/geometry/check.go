@@ -0,0 +1,188 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+// roundSides is the number of sides round footprints are approximated with.
+const roundSides = 32
+
+// maxIssues caps the issues a check reports.
+const maxIssues = 200
+
+// Default aisle widths: wide enough for a wheelchair or a serving trolley.
+const (
+	defaultAisleMeters = 0.9
+	defaultAisleFeet   = 3.0
+)
+
+// Footprint returns the floor area a table takes up with its chairs, in
+// plan coordinates. Round tables are approximated by a regular polygon, the
+// other types by the rotated box around the table and its seats.
+func Footprint(t models.Table) Polygon {
+	l := LayoutTable(t)
+	if t.TableType == models.TableTypeRound {
+		r := l.Radius
+		if len(l.Seats) > 0 {
+			r += 2*SeatRadius + seatGap
+		}
+		p := make(Polygon, roundSides)
+		for i := range p {
+			a := 2 * math.Pi * float64(i) / roundSides
+			p[i] = Point{t.Position.X + r*math.Cos(a), t.Position.Y + r*math.Sin(a)}
+		}
+		return p
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, r := range l.Rects {
+		minX, minY = math.Min(minX, r.X), math.Min(minY, r.Y)
+		maxX, maxY = math.Max(maxX, r.X+r.W), math.Max(maxY, r.Y+r.H)
+	}
+	for _, s := range l.Seats {
+		minX, minY = math.Min(minX, s.X-SeatRadius), math.Min(minY, s.Y-SeatRadius)
+		maxX, maxY = math.Max(maxX, s.X+SeatRadius), math.Max(maxY, s.Y+SeatRadius)
+	}
+
+	// Rotate clockwise on screen like the frontend's rotate(), then move into place
+	sin, cos := math.Sincos(t.Rotation * math.Pi / 180)
+	corners := Polygon{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}}
+	for i, c := range corners {
+		corners[i] = Point{t.Position.X + c.X*cos - c.Y*sin, t.Position.Y + c.X*sin + c.Y*cos}
+	}
+	return corners
+}
+
+// Options tunes a layout check.
+type Options struct {
+	// MinAisle is the narrowest allowed gap between tables and in front of
+	// doors, in room units, or plan coordinates without a room.
+	MinAisle float64
+}
+
+// DefaultOptions returns the options used when the planner hasn't chosen
+// any: a 0.9 m or 3 ft aisle.
+func DefaultOptions(room *models.RoomGeometry) Options {
+	if room == nil {
+		return Options{MinAisle: defaultAisleMeters * models.DefaultRoomScale}
+	}
+	if room.Units == models.RoomUnitFeet {
+		return Options{MinAisle: defaultAisleFeet}
+	}
+	return Options{MinAisle: defaultAisleMeters}
+}
+
+// Check finds overlapping tables, tables outside the room or on top of its
+// obstacles, and aisles narrower than opts.MinAisle. Gaps are reported in
+// the same units as MinAisle. At most maxIssues issues are returned.
+func Check(tables []models.Table, room *models.RoomGeometry, opts Options) []models.LayoutIssue {
+	scale, unit := 1.0, ""
+	if room != nil && room.Scale > 0 {
+		scale, unit = room.Scale, " "+string(room.Units)
+	}
+	aisle := opts.MinAisle * scale
+
+	footprints := make([]Polygon, len(tables))
+	for i, t := range tables {
+		footprints[i] = Footprint(t)
+	}
+
+	issues := []models.LayoutIssue{}
+	add := func(issue models.LayoutIssue) bool {
+		issues = append(issues, issue)
+		return len(issues) < maxIssues
+	}
+	gapIssue := func(gap float64, obstacle *int, ids []string, format string, args ...any) models.LayoutIssue {
+		g := math.Round(gap/scale*100) / 100
+		args = append(args, strconv.FormatFloat(g, 'f', -1, 64)+unit)
+		return models.LayoutIssue{Type: models.LayoutAisle, Tables: ids, Obstacle: obstacle, Gap: &g, Message: fmt.Sprintf(format, args...)}
+	}
+
+	for i := range tables {
+		for j := i + 1; j < len(tables); j++ {
+			if !boxesMeet(footprints[i], footprints[j], aisle) {
+				continue
+			}
+			ids := []string{tables[i].ID, tables[j].ID}
+			if Overlaps(footprints[i], footprints[j]) {
+				msg := fmt.Sprintf("%s and %s overlap", tableName(tables[i]), tableName(tables[j]))
+				if !add(models.LayoutIssue{Type: models.LayoutOverlap, Tables: ids, Message: msg}) {
+					return issues
+				}
+			} else if gap := Distance(footprints[i], footprints[j]); gap < aisle {
+				if !add(gapIssue(gap, nil, ids, "%s and %s are only %s apart", tableName(tables[i]), tableName(tables[j]))) {
+					return issues
+				}
+			}
+		}
+	}
+	if room == nil {
+		return issues
+	}
+
+	outline := points(room.Outline)
+	for i, t := range tables {
+		ids := []string{t.ID}
+		if len(outline) >= 3 && !Inside(footprints[i], outline) {
+			msg := fmt.Sprintf("%s is outside the room", tableName(t))
+			if !add(models.LayoutIssue{Type: models.LayoutOutOfRoom, Tables: ids, Message: msg}) {
+				return issues
+			}
+		}
+		for k, o := range room.Obstacles {
+			shape := points(o.Points)
+			obstacle := k
+			switch {
+			case o.Type == models.ObstacleDoor:
+				if gap := LineDistance(footprints[i], shape); gap < aisle {
+					if !add(gapIssue(gap, &obstacle, ids, "%s is in front of %s, only %s away", tableName(t), obstacleName(o))) {
+						return issues
+					}
+				}
+			case o.Type.Open() && Crosses(footprints[i], shape),
+				!o.Type.Open() && Overlaps(footprints[i], shape):
+				msg := fmt.Sprintf("%s is on %s", tableName(t), obstacleName(o))
+				if !add(models.LayoutIssue{Type: models.LayoutObstacle, Tables: ids, Obstacle: &obstacle, Message: msg}) {
+					return issues
+				}
+			}
+		}
+	}
+	return issues
+}
+
+func points(ps []models.Position) Polygon {
+	p := make(Polygon, len(ps))
+	for i, pt := range ps {
+		p[i] = Point{pt.X, pt.Y}
+	}
+	return p
+}
+
+func tableName(t models.Table) string {
+	if t.Name != "" {
+		return strconv.Quote(t.Name)
+	}
+	return "table " + t.ID
+}
+
+// obstacleName describes an obstacle by its label, or "a pillar" and the like.
+func obstacleName(o models.Obstacle) string {
+	if o.Label != "" {
+		return strconv.Quote(o.Label)
+	}
+	kind := strings.ReplaceAll(strings.ToLower(string(o.Type)), "_", " ")
+	switch o.Type {
+	case models.ObstacleWall, models.ObstaclePillar, models.ObstacleDoor:
+		return "a " + kind
+	case models.ObstacleOther:
+		return "an obstacle"
+	}
+	return "the " + kind
+}
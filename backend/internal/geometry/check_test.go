@@ -0,0 +1,85 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+func lineTable(id string, x, y, rotation float64) models.Table {
+	return models.Table{
+		ID: id, Name: id, TableType: models.TableTypeLine, Capacity: 6, Seats: make([]models.Seat, 6),
+		Position: models.Position{X: x, Y: y}, Rotation: rotation,
+	}
+}
+
+func TestFootprint(t *testing.T) {
+	// 3 seats a side: a 180 wide table with seats above and below
+	minX, minY, maxX, maxY := Footprint(lineTable("t", 0, 0, 0)).bounds()
+	if maxX-minX != 180 || maxY-minY != TableDepth+4*SeatRadius+2*seatGap {
+		t.Errorf("unexpected footprint %vx%v", maxX-minX, maxY-minY)
+	}
+
+	minX, minY, maxX, maxY = Footprint(lineTable("t", 100, 50, 90)).bounds()
+	if math.Abs(maxY-minY-180) > 1e-9 || math.Abs((minX+maxX)/2-100) > 1e-9 || math.Abs((minY+maxY)/2-50) > 1e-9 {
+		t.Errorf("rotated footprint not turned around the table position: %v,%v %v,%v", minX, minY, maxX, maxY)
+	}
+
+	round := models.Table{ID: "r", TableType: models.TableTypeRound, Capacity: 8, Seats: make([]models.Seat, 8)}
+	minX, _, maxX, _ = Footprint(round).bounds()
+	if want := 2 * (56 + 2*SeatRadius + seatGap); math.Abs(maxX-minX-want) > 1e-9 {
+		t.Errorf("round footprint is %v wide, want %v", maxX-minX, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	room := &models.RoomGeometry{
+		Units: models.RoomUnitMeters,
+		Scale: 100,
+		Outline: []models.Position{
+			{X: 0, Y: 0}, {X: 2000, Y: 0}, {X: 2000, Y: 1000}, {X: 0, Y: 1000},
+		},
+		Obstacles: []models.Obstacle{
+			{Type: models.ObstaclePillar, Points: []models.Position{{X: 990, Y: 490}, {X: 1010, Y: 490}, {X: 1010, Y: 510}, {X: 990, Y: 510}}},
+			{Type: models.ObstacleDoor, Label: "Kitchen", Points: []models.Position{{X: 2000, Y: 400}, {X: 2000, Y: 600}}},
+		},
+	}
+	tables := []models.Table{
+		lineTable("a", 200, 200, 0),
+		lineTable("b", 250, 200, 0),   // overlaps a
+		lineTable("c", 200, 408, 0),   // 0.8 m below a
+		lineTable("d", 1000, 500, 0),  // on the pillar
+		lineTable("e", 1934, 500, 90), // against the kitchen door
+		lineTable("f", 2100, 800, 0),  // outside
+	}
+
+	issues := Check(tables, room, DefaultOptions(room))
+
+	got := map[string]models.LayoutIssue{}
+	for _, issue := range issues {
+		key := issue.Type
+		for _, id := range issue.Tables {
+			key += ":" + id
+		}
+		got[key] = issue
+	}
+	for _, want := range []string{"overlap:a:b", "aisle:a:c", "obstacle:d", "aisle:e", "out_of_room:f"} {
+		if _, ok := got[want]; !ok {
+			t.Errorf("missing issue %s", want)
+		}
+	}
+	if len(issues) != 6 {
+		t.Errorf("expected 6 issues, got %d: %+v", len(issues), issues)
+	}
+	if gap := got["aisle:a:c"].Gap; gap == nil || *gap != 0.8 {
+		t.Errorf("expected a 0.8 m gap, got %v", gap)
+	}
+	if door := got["aisle:e"]; door.Obstacle == nil || *door.Obstacle != 1 || door.Message != `"e" is in front of "Kitchen", only 0.02 m away` {
+		t.Errorf("unexpected door issue %+v", door)
+	}
+
+	if issues := Check(tables[:1], nil, DefaultOptions(nil)); len(issues) != 0 {
+		t.Errorf("expected a single table without a room to be fine, got %+v", issues)
+	}
+}
@@ -0,0 +1,257 @@
+// Package geometry works out the space tables take up on the floor and checks
+// that a layout fits its room: no overlapping tables, nothing outside the
+// walls or on top of obstacles, and room to walk between tables.
+package geometry
+
+import "math"
+
+// Point is a position in floor plan coordinates, with y pointing down.
+type Point struct {
+	X, Y float64
+}
+
+// Polygon is a closed shape given by its corners.
+type Polygon []Point
+
+// epsilon and areaEpsilon absorb rounding, so shapes that only touch don't
+// overlap.
+const (
+	epsilon     = 1e-6
+	areaEpsilon = 1e-3
+)
+
+// bounds returns the corners of the axis-aligned box around p.
+func (p Polygon) bounds() (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, pt := range p {
+		minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+		maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+	}
+	return minX, minY, maxX, maxY
+}
+
+func (p Polygon) centroid() Point {
+	var c Point
+	for _, pt := range p {
+		c.X, c.Y = c.X+pt.X, c.Y+pt.Y
+	}
+	return Point{c.X / float64(len(p)), c.Y / float64(len(p))}
+}
+
+// edges calls fn with each side of the polygon.
+func (p Polygon) edges(fn func(a, b Point) bool) bool {
+	for i := range p {
+		if !fn(p[i], p[(i+1)%len(p)]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains reports whether pt is strictly inside p. The polygon may be
+// concave.
+func (p Polygon) Contains(pt Point) bool {
+	inside := false
+	onEdge := false
+	p.edges(func(a, b Point) bool {
+		if pointSegmentDistance(pt, a, b) < epsilon {
+			onEdge = true
+			return false
+		}
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < a.X+(pt.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+		return true
+	})
+	return inside && !onEdge
+}
+
+// Overlaps reports whether the insides of a and b intersect. a must be
+// convex, as footprints are; b may be any simple polygon. Polygons that only
+// share an edge or a corner don't overlap.
+func Overlaps(a, b Polygon) bool {
+	if len(a) < 3 || len(b) < 3 || !boxesMeet(a, b, 0) {
+		return false
+	}
+	return math.Abs(clip(b, a).area()) > areaEpsilon
+}
+
+// Inside reports whether the convex polygon a lies entirely within the
+// outline, which may be concave.
+func Inside(a, outline Polygon) bool {
+	return math.Abs(a.area())-math.Abs(clip(outline, a).area()) <= areaEpsilon
+}
+
+// Crosses reports whether the polyline runs through the inside of the convex
+// polygon a. Lines along its edges don't cross it.
+func Crosses(a Polygon, line []Point) bool {
+	if len(a) < 3 {
+		return false
+	}
+	sign := orientation(a)
+	for i := 1; i < len(line); i++ {
+		// Cyrus-Beck: narrow the segment to the part on the inner side of every edge
+		p1, p2 := line[i-1], line[i]
+		t0, t1 := 0.0, 1.0
+		a.edges(func(e1, e2 Point) bool {
+			n1, n2 := sign*cross(e1, e2, p1), sign*cross(e1, e2, p2)
+			switch {
+			case n1 < 0 && n2 < 0:
+				t0, t1 = 1, 0
+				return false
+			case n1 < 0:
+				t0 = math.Max(t0, n1/(n1-n2))
+			case n2 < 0:
+				t1 = math.Min(t1, n1/(n1-n2))
+			}
+			return true
+		})
+		if t1-t0 <= epsilon {
+			continue
+		}
+		t := (t0 + t1) / 2
+		if a.Contains(Point{p1.X + t*(p2.X-p1.X), p1.Y + t*(p2.Y-p1.Y)}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance returns the gap between the outlines of a and b, or 0 if they
+// overlap.
+func Distance(a, b Polygon) float64 {
+	if Overlaps(a, b) {
+		return 0
+	}
+	d := math.Inf(1)
+	a.edges(func(a1, a2 Point) bool {
+		b.edges(func(b1, b2 Point) bool {
+			d = math.Min(d, segmentDistance(a1, a2, b1, b2))
+			return true
+		})
+		return true
+	})
+	return d
+}
+
+// LineDistance returns the gap between a and the polyline, or 0 if it
+// crosses a.
+func LineDistance(a Polygon, line []Point) float64 {
+	if Crosses(a, line) {
+		return 0
+	}
+	d := math.Inf(1)
+	for i := 1; i < len(line); i++ {
+		a.edges(func(b1, b2 Point) bool {
+			d = math.Min(d, segmentDistance(line[i-1], line[i], b1, b2))
+			return true
+		})
+	}
+	return d
+}
+
+// boxesMeet reports whether the bounding boxes of a and b are at most gap
+// apart, a cheap test before the exact ones.
+func boxesMeet(a, b Polygon, gap float64) bool {
+	aMinX, aMinY, aMaxX, aMaxY := a.bounds()
+	bMinX, bMinY, bMaxX, bMaxY := b.bounds()
+	return aMinX <= bMaxX+gap && bMinX <= aMaxX+gap && aMinY <= bMaxY+gap && bMinY <= aMaxY+gap
+}
+
+// distanceTo returns the distance from pt to the nearest edge of p.
+func (p Polygon) distanceTo(pt Point) float64 {
+	d := math.Inf(1)
+	p.edges(func(a, b Point) bool {
+		d = math.Min(d, pointSegmentDistance(pt, a, b))
+		return true
+	})
+	return d
+}
+
+// cross returns the z component of (b-a)×(c-a): positive when c is
+// clockwise of ab on screen.
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// segmentsCross reports whether segments a1a2 and b1b2 cross at a single
+// point inside both. Touching ends and collinear overlaps don't count.
+func segmentsCross(a1, a2, b1, b2 Point) bool {
+	d1, d2 := cross(b1, b2, a1), cross(b1, b2, a2)
+	d3, d4 := cross(a1, a2, b1), cross(a1, a2, b2)
+	return ((d1 > epsilon && d2 < -epsilon) || (d1 < -epsilon && d2 > epsilon)) &&
+		((d3 > epsilon && d4 < -epsilon) || (d3 < -epsilon && d4 > epsilon))
+}
+
+func pointSegmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSq := dx*dx + dy*dy
+	if lengthSq == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lengthSq))
+	return math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy)
+}
+
+func segmentDistance(a1, a2, b1, b2 Point) float64 {
+	if segmentsCross(a1, a2, b1, b2) {
+		return 0
+	}
+	return math.Min(
+		math.Min(pointSegmentDistance(a1, b1, b2), pointSegmentDistance(a2, b1, b2)),
+		math.Min(pointSegmentDistance(b1, a1, a2), pointSegmentDistance(b2, a1, a2)),
+	)
+}
+
+// area returns the signed area of p: positive when its corners run clockwise
+// on screen.
+func (p Polygon) area() float64 {
+	a := 0.0
+	p.edges(func(p1, p2 Point) bool {
+		a += p1.X*p2.Y - p2.X*p1.Y
+		return true
+	})
+	return a / 2
+}
+
+// orientation returns 1 for clockwise and -1 for counterclockwise polygons.
+func orientation(p Polygon) float64 {
+	if p.area() < 0 {
+		return -1
+	}
+	return 1
+}
+
+// clip returns the part of subject inside the convex polygon c
+// (Sutherland-Hodgman). A concave subject may come back with zero-width
+// bridges, which don't change its area.
+func clip(subject, c Polygon) Polygon {
+	sign := orientation(c)
+	out := subject
+	c.edges(func(e1, e2 Point) bool {
+		in := out
+		out = nil
+		for i, cur := range in {
+			prev := in[(i+len(in)-1)%len(in)]
+			dCur, dPrev := sign*cross(e1, e2, cur), sign*cross(e1, e2, prev)
+			if dCur >= 0 {
+				if dPrev < 0 {
+					out = append(out, intersect(prev, cur, dPrev, dCur))
+				}
+				out = append(out, cur)
+			} else if dPrev >= 0 {
+				out = append(out, intersect(prev, cur, dPrev, dCur))
+			}
+		}
+		return len(out) > 0
+	})
+	return out
+}
+
+// intersect returns the point between p and q where the signed distances dp
+// and dq to a clipping edge reach zero.
+func intersect(p, q Point, dp, dq float64) Point {
+	t := dp / (dp - dq)
+	return Point{p.X + t*(q.X-p.X), p.Y + t*(q.Y-p.Y)}
+}
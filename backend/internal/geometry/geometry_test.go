@@ -0,0 +1,94 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func square(x, y, size float64) Polygon {
+	return Polygon{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}
+}
+
+// lShape is a 20x20 square with its top right quarter cut out.
+var lShape = Polygon{{0, 0}, {10, 0}, {10, 10}, {20, 10}, {20, 20}, {0, 20}}
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Polygon
+		want bool
+	}{
+		{"apart", square(0, 0, 10), square(20, 0, 10), false},
+		{"shared edge", square(0, 0, 10), square(10, 0, 10), false},
+		{"shared corner", square(0, 0, 10), square(10, 10, 10), false},
+		{"shifted along an edge", square(0, 0, 10), square(5, 0, 10), true},
+		{"identical", square(0, 0, 10), square(0, 0, 10), true},
+		{"contained", square(0, 0, 10), square(2, 2, 2), true},
+		{"containing", square(2, 2, 2), square(0, 0, 10), true},
+		{"in the notch of a concave polygon", square(12, 0, 6), lShape, false},
+		{"across the notch", square(5, 5, 10), lShape, true},
+		{"reversed winding", Polygon{{0, 10}, {10, 10}, {10, 0}, {0, 0}}, square(5, 5, 10), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlaps(tt.a, tt.b); got != tt.want {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInside(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Polygon
+		want bool
+	}{
+		{"inside", square(2, 12, 5), true},
+		{"against the walls", square(0, 10, 10), true},
+		{"in the notch", square(12, 2, 5), false},
+		{"across a wall", square(-2, 12, 5), false},
+		{"spanning the notch corner", square(8, 8, 4), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Inside(tt.a, lShape); got != tt.want {
+				t.Errorf("Inside() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrosses(t *testing.T) {
+	box := square(0, 0, 10)
+	tests := []struct {
+		name string
+		line []Point
+		want bool
+	}{
+		{"through", []Point{{-5, 5}, {15, 5}}, true},
+		{"ending inside", []Point{{-5, 5}, {5, 5}}, true},
+		{"along an edge", []Point{{-5, 0}, {15, 0}}, false},
+		{"outside", []Point{{-5, -5}, {15, -5}}, false},
+		{"second segment", []Point{{-5, -5}, {-5, 5}, {5, 5}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Crosses(box, tt.line); got != tt.want {
+				t.Errorf("Crosses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if d := Distance(square(0, 0, 10), square(13, 14, 10)); math.Abs(d-5) > 1e-9 {
+		t.Errorf("expected corner-to-corner distance 5, got %v", d)
+	}
+	if d := Distance(square(0, 0, 10), square(5, 5, 10)); d != 0 {
+		t.Errorf("expected 0 for overlapping squares, got %v", d)
+	}
+	if d := LineDistance(square(0, 0, 10), []Point{{12, -5}, {12, 15}}); math.Abs(d-2) > 1e-9 {
+		t.Errorf("expected line distance 2, got %v", d)
+	}
+}
@@ -0,0 +1,175 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+// Table drawing dimensions, as in the frontend's TableRenderer.tsx.
+const (
+	SeatRadius  = 16.0
+	seatSpacing = 36.0
+	TableDepth  = 48.0
+	seatGap     = 8.0
+	tablePad    = 25.0
+)
+
+// Sides of a seat that its guest's name is drawn on
+const (
+	SideTop    = "top"
+	SideBottom = "bottom"
+	SideLeft   = "left"
+	SideRight  = "right"
+	SideRadial = "radial"
+)
+
+// Rect is an axis-aligned rectangle with its top left corner at X, Y.
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// SeatSpot is where a seat is drawn, relative to the table center before rotation.
+type SeatSpot struct {
+	X, Y  float64
+	Index int
+	// Side is the side of the seat its guest's name is drawn on
+	Side string
+	// Angle is the direction of a radial seat from the table center, in degrees
+	Angle float64
+}
+
+// Layout is a table's drawing in table coordinates: centered on the table
+// position, before rotation.
+type Layout struct {
+	Rects []Rect
+	// Radius is set for round tables
+	Radius float64
+	// NameY is where the table name is drawn
+	NameY float64
+	Seats []SeatSpot
+}
+
+// LayoutTable ports the seat placement of LineTable, UShapeTable and
+// RoundTable in TableRenderer.tsx so exports and layout checks match what
+// planners see.
+func LayoutTable(t models.Table) Layout {
+	switch t.TableType {
+	case models.TableTypeUShape:
+		return layoutUShape(t)
+	case models.TableTypeRound:
+		return layoutRound(t)
+	default:
+		return layoutLine(t)
+	}
+}
+
+func layoutLine(t models.Table) Layout {
+	ends := 0
+	if t.EndSeatLeft {
+		ends++
+	}
+	if t.EndSeatRight {
+		ends++
+	}
+	sideSeats := len(t.Seats) - ends
+	perSide := sideSeats
+	if !t.SingleSided {
+		perSide = (sideSeats + 1) / 2
+	}
+	w := math.Max(180, float64(perSide)*seatSpacing+tablePad*2)
+
+	l := Layout{Rects: []Rect{{-w / 2, -TableDepth / 2, w, TableDepth}}}
+	sx, ex := -w/2+tablePad, w/2-tablePad
+	sp := 0.0
+	if perSide > 1 {
+		sp = (ex - sx) / float64(perSide-1)
+	}
+	seatX := func(i int) float64 {
+		if perSide > 1 {
+			return sx + float64(i)*sp
+		}
+		return 0
+	}
+	offset := TableDepth/2 + SeatRadius + seatGap
+
+	for i := 0; i < perSide; i++ {
+		l.Seats = append(l.Seats, SeatSpot{X: seatX(i), Y: -offset, Index: i, Side: SideTop})
+	}
+	if !t.SingleSided {
+		for i := 0; i < perSide && perSide+i < sideSeats; i++ {
+			l.Seats = append(l.Seats, SeatSpot{X: seatX(i), Y: offset, Index: perSide + i, Side: SideBottom})
+		}
+	}
+	end := sideSeats
+	if t.EndSeatLeft {
+		l.Seats = append(l.Seats, SeatSpot{X: -w/2 - SeatRadius - seatGap, Index: end, Side: SideLeft})
+		end++
+	}
+	if t.EndSeatRight {
+		l.Seats = append(l.Seats, SeatSpot{X: w/2 + SeatRadius + seatGap, Index: end, Side: SideRight})
+	}
+	return l
+}
+
+func layoutUShape(t models.Table) Layout {
+	nTop, nLeft, nRight := t.TopSeats, t.LeftSeats, t.RightSeats
+	topW := math.Max(300, float64(max(0, nTop-2))*seatSpacing+100)
+	sideH := math.Max(250, float64(max(nLeft, nRight))*seatSpacing+60)
+	totalH := TableDepth + sideH
+
+	top := Rect{-topW / 2, -totalH / 2, topW, TableDepth}
+	left := Rect{-topW / 2, -totalH/2 + TableDepth, TableDepth, sideH}
+	right := Rect{topW/2 - TableDepth, -totalH/2 + TableDepth, TableDepth, sideH}
+	l := Layout{Rects: []Rect{top, left, right}, NameY: top.Y + TableDepth/2}
+
+	if nTop >= 2 {
+		l.Seats = append(l.Seats,
+			SeatSpot{X: top.X - SeatRadius - seatGap, Y: top.Y + TableDepth/2, Index: 0, Side: SideLeft},
+			SeatSpot{X: top.X + top.W + SeatRadius + seatGap, Y: top.Y + TableDepth/2, Index: nTop - 1, Side: SideRight},
+		)
+		if mc := nTop - 2; mc > 0 {
+			sx, ex := top.X+35, top.X+top.W-35
+			for i := 0; i < mc; i++ {
+				x := (sx + ex) / 2
+				if mc > 1 {
+					x = sx + float64(i)*(ex-sx)/float64(mc-1)
+				}
+				l.Seats = append(l.Seats, SeatSpot{X: x, Y: top.Y - SeatRadius - seatGap, Index: 1 + i, Side: SideTop})
+			}
+		}
+	} else if nTop == 1 {
+		l.Seats = append(l.Seats, SeatSpot{Y: top.Y - SeatRadius - seatGap, Index: 0, Side: SideTop})
+	}
+
+	arm := func(r Rect, n, offset int, x float64, side string) {
+		sy, ey := r.Y+20, r.Y+r.H-20
+		for i := 0; i < n; i++ {
+			y := (sy + ey) / 2
+			if n > 1 {
+				y = sy + float64(i)*(ey-sy)/float64(n-1)
+			}
+			l.Seats = append(l.Seats, SeatSpot{X: x, Y: y, Index: offset + i, Side: side})
+		}
+	}
+	arm(left, nLeft, nTop, left.X-SeatRadius-seatGap, SideLeft)
+	arm(right, nRight, nTop+nLeft, right.X+right.W+SeatRadius+seatGap, SideRight)
+	return l
+}
+
+func layoutRound(t models.Table) Layout {
+	n := len(t.Seats)
+	r := math.Max(35, float64(n)*7)
+	l := Layout{Radius: r}
+	for i := 0; i < n; i++ {
+		a := 2*math.Pi*float64(i)/float64(n) - math.Pi/2
+		l.Seats = append(l.Seats, SeatSpot{
+			X:     math.Cos(a) * (r + SeatRadius + seatGap),
+			Y:     math.Sin(a) * (r + SeatRadius + seatGap),
+			Index: i,
+			Side:  SideRadial,
+			Angle: a * 180 / math.Pi,
+		})
+	}
+	return l
+}
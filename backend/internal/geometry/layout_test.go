@@ -0,0 +1,36 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+)
+
+func TestLayoutTable(t *testing.T) {
+	tests := []struct {
+		name  string
+		table models.Table
+		seats int
+	}{
+		{"line", models.Table{TableType: models.TableTypeLine, Seats: make([]models.Seat, 7)}, 7},
+		{"line with ends", models.Table{TableType: models.TableTypeLine, Seats: make([]models.Seat, 8), EndSeatLeft: true, EndSeatRight: true}, 8},
+		{"single sided", models.Table{TableType: models.TableTypeLine, Seats: make([]models.Seat, 5), SingleSided: true}, 5},
+		{"round", models.Table{TableType: models.TableTypeRound, Seats: make([]models.Seat, 10)}, 10},
+		{"u-shape", models.Table{TableType: models.TableTypeUShape, TopSeats: 4, LeftSeats: 3, RightSeats: 2, Seats: make([]models.Seat, 9)}, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := LayoutTable(tt.table)
+			if len(l.Seats) != tt.seats {
+				t.Fatalf("expected %d seats, got %d", tt.seats, len(l.Seats))
+			}
+			seen := map[int]bool{}
+			for _, s := range l.Seats {
+				if seen[s.Index] || s.Index >= tt.seats {
+					t.Errorf("bad or repeated seat index %d", s.Index)
+				}
+				seen[s.Index] = true
+			}
+		})
+	}
+}
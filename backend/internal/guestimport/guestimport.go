@@ -0,0 +1,352 @@
+// Package guestimport turns guest lists exported from spreadsheets into guest
+// documents, matching them against the guests and tables a plan already has.
+package guestimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxNameLength = 200
+
+// Options configures Parse.
+type Options struct {
+	Columns   models.GuestColumns
+	Delimiter rune
+	// Existing and Tables are the plan's current guests and tables
+	Existing []models.Guest
+	Tables   []models.Table
+	// IncludeDuplicates imports likely duplicates instead of skipping them
+	IncludeDuplicates bool
+	// Now is stored as the new guests' createdAt
+	Now time.Time
+}
+
+// Result is the outcome of parsing a guest list.
+type Result struct {
+	Rows []models.GuestImportRow
+	// Guests are the guests to add, in row order
+	Guests     []models.Guest
+	Imported   int
+	Duplicates int
+	Invalid    int
+}
+
+// Optional columns that are looked up by these headers when not mapped
+var defaultHeaders = struct {
+	dietary, guestOf, table []string
+}{
+	dietary: []string{"dietaryRestrictions", "dietary restrictions", "dietary"},
+	guestOf: []string{"guestOf", "guest of"},
+	table:   []string{"table"},
+}
+
+// dietaryAliases maps common spellings to restrictions, keyed like normalizeDietary
+var dietaryAliases = map[string]models.DietaryRestriction{
+	"VEGGIE":       models.DietaryVegetarian,
+	"LACTOSE":      models.DietaryLactoseIntolerant,
+	"LACTOSE_FREE": models.DietaryLactoseIntolerant,
+}
+
+type columns struct {
+	name, dietary, guestOf, table int
+}
+
+// Parse reads a CSV guest list with a header row. Rows that can't become a
+// guest are reported as invalid, and rows whose name matches an existing
+// guest or an earlier row are reported as duplicates and skipped unless
+// opts.IncludeDuplicates is set. The returned error is only set when the CSV
+// as a whole can't be used.
+func Parse(data string, opts Options) (*Result, error) {
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(data, "\ufeff")))
+	r.Comma = opts.Delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	cols, err := mapColumns(header, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]string, len(opts.Existing))
+	existingIDs := make(map[string]bool, len(opts.Existing))
+	for _, g := range opts.Existing {
+		existingIDs[strings.ToLower(g.ID)] = true
+		if key := NameKey(g.Name); key != "" {
+			if _, ok := existing[key]; !ok {
+				existing[key] = g.ID
+			}
+		}
+	}
+	tableIDs, tableNames := indexTables(opts.Tables)
+
+	res := &Result{Rows: []models.GuestImportRow{}, Guests: []models.Guest{}}
+	imported := map[string]string{} // name key to the first imported guest's ID
+	type hostRef struct {
+		row, guest int
+		host       string
+	}
+	var hosts []hostRef
+	createdAt := opts.Now.UTC().Format(time.RFC3339)
+
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		if blank(rec) {
+			continue
+		}
+		if len(res.Rows) == models.MaxPlanEntities {
+			return nil, fmt.Errorf("csv exceeds maximum of %d rows", models.MaxPlanEntities)
+		}
+		line, _ := r.FieldPos(0)
+
+		row := models.GuestImportRow{Line: line, Name: strings.TrimSpace(field(rec, cols.name))}
+		if row.Name == "" {
+			row.Errors = append(row.Errors, "name is required")
+		} else if len(row.Name) > maxNameLength {
+			row.Errors = append(row.Errors, fmt.Sprintf("name must be at most %d characters", maxNameLength))
+		}
+		dietary, unknown := parseDietary(field(rec, cols.dietary))
+		for _, v := range unknown {
+			row.Errors = append(row.Errors, fmt.Sprintf("unknown dietary restriction %q", v))
+		}
+		if len(row.Errors) > 0 {
+			row.Status = models.ImportRowInvalid
+			res.Invalid++
+			res.Rows = append(res.Rows, row)
+			continue
+		}
+
+		key := NameKey(row.Name)
+		if id, ok := existing[key]; ok {
+			row.DuplicateOf = &id
+		} else if id, ok := imported[key]; ok {
+			row.DuplicateOf = &id
+		}
+		if row.DuplicateOf != nil {
+			res.Duplicates++
+			if !opts.IncludeDuplicates {
+				row.Status = models.ImportRowDuplicate
+				res.Rows = append(res.Rows, row)
+				continue
+			}
+			row.Warnings = append(row.Warnings, "imported although a guest with the same name exists")
+		}
+
+		g := models.Guest{
+			ID:                  uuid.NewString(),
+			Name:                row.Name,
+			DietaryRestrictions: dietary,
+			CreatedAt:           createdAt,
+		}
+		if table := strings.TrimSpace(field(rec, cols.table)); table != "" {
+			if id, ok := tableIDs[strings.ToLower(table)]; ok {
+				g.AssignedTableID = &id
+			} else if ids := tableNames[fold(table)]; len(ids) == 1 {
+				g.AssignedTableID = &ids[0]
+			} else if len(ids) > 1 {
+				row.Warnings = append(row.Warnings, fmt.Sprintf("table %q matches %d tables, left unassigned", table, len(ids)))
+			} else {
+				row.Warnings = append(row.Warnings, fmt.Sprintf("table %q not found, left unassigned", table))
+			}
+		}
+		if host := strings.TrimSpace(field(rec, cols.guestOf)); host != "" {
+			hosts = append(hosts, hostRef{row: len(res.Rows), guest: len(res.Guests), host: host})
+		}
+
+		if _, ok := imported[key]; !ok {
+			imported[key] = g.ID
+		}
+		row.Status = models.ImportRowNew
+		row.GuestID = &g.ID
+		res.Imported++
+		res.Rows = append(res.Rows, row)
+		res.Guests = append(res.Guests, g)
+	}
+
+	// Hosts may appear anywhere in the list, so they are resolved last
+	importedIDs := make(map[string]bool, len(res.Guests))
+	for _, g := range res.Guests {
+		importedIDs[g.ID] = true
+	}
+	for _, h := range hosts {
+		g := &res.Guests[h.guest]
+		row := &res.Rows[h.row]
+		hostID, ok := "", false
+		if id, err := uuid.Parse(h.host); err == nil && (existingIDs[id.String()] || importedIDs[id.String()]) {
+			hostID, ok = id.String(), true
+		} else if id, found := existing[NameKey(h.host)]; found {
+			hostID, ok = id, true
+		} else if id, found := imported[NameKey(h.host)]; found {
+			hostID, ok = id, true
+		}
+		switch {
+		case !ok:
+			row.Warnings = append(row.Warnings, fmt.Sprintf("host %q not found", h.host))
+		case hostID == g.ID:
+			row.Warnings = append(row.Warnings, "guest can't be their own host")
+		default:
+			g.GuestOf = &hostID
+		}
+	}
+
+	return res, nil
+}
+
+// NameKey reduces a name to a key that is equal for likely duplicates. Case,
+// accents, punctuation and word order are ignored, so "Smith, Jöhn" and
+// "john smith" share a key.
+func NameKey(name string) string {
+	words := NameWords(name)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// NameWords splits a name into lowercase words without accents or
+// punctuation, so "Jöhn O'Neil" becomes john, o and neil.
+func NameWords(name string) []string {
+	return strings.Fields(fold(name))
+}
+
+// fold lowercases s, strips accents and turns punctuation into spaces.
+func fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	folded = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, folded)
+	return strings.Join(strings.Fields(folded), " ")
+}
+
+func mapColumns(header []string, mapping models.GuestColumns) (columns, error) {
+	find := func(names ...string) int {
+		for _, name := range names {
+			for i, h := range header {
+				if fold(h) == fold(name) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	required := func(name string) (int, error) {
+		if i := find(name); i >= 0 {
+			return i, nil
+		}
+		return -1, fmt.Errorf("column %q not found", name)
+	}
+	optional := func(name string, defaults []string) (int, error) {
+		if name == "" {
+			return find(defaults...), nil
+		}
+		return required(name)
+	}
+
+	var cols columns
+	var err error
+	if cols.name, err = required(mapping.Name); err != nil {
+		return cols, err
+	}
+	if cols.dietary, err = optional(mapping.DietaryRestrictions, defaultHeaders.dietary); err != nil {
+		return cols, err
+	}
+	if cols.guestOf, err = optional(mapping.GuestOf, defaultHeaders.guestOf); err != nil {
+		return cols, err
+	}
+	if cols.table, err = optional(mapping.Table, defaultHeaders.table); err != nil {
+		return cols, err
+	}
+	return cols, nil
+}
+
+// indexTables indexes tables by lowercased ID and by folded name.
+func indexTables(tables []models.Table) (map[string]string, map[string][]string) {
+	byID := make(map[string]string, len(tables))
+	byName := make(map[string][]string, len(tables))
+	for _, t := range tables {
+		byID[strings.ToLower(t.ID)] = t.ID
+		if name := fold(t.Name); name != "" {
+			byName[name] = append(byName[name], t.ID)
+		}
+	}
+	return byID, byName
+}
+
+// parseDietary splits a cell such as "Vegan; lactose-free" into restrictions,
+// returning the values it didn't recognise separately.
+func parseDietary(cell string) ([]models.DietaryRestriction, []string) {
+	restrictions := []models.DietaryRestriction{}
+	var unknown []string
+	seen := map[models.DietaryRestriction]bool{}
+	for _, value := range strings.FieldsFunc(cell, func(r rune) bool { return strings.ContainsRune(",;|/", r) }) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		d := models.DietaryRestriction(normalizeDietary(value))
+		if alias, ok := dietaryAliases[string(d)]; ok {
+			d = alias
+		}
+		if !d.Valid() {
+			unknown = append(unknown, value)
+			continue
+		}
+		if !seen[d] {
+			seen[d] = true
+			restrictions = append(restrictions, d)
+		}
+	}
+	return restrictions, unknown
+}
+
+// normalizeDietary turns "lactose intolerant" into "LACTOSE_INTOLERANT".
+func normalizeDietary(value string) string {
+	return strings.ReplaceAll(strings.ToUpper(fold(value)), " ", "_")
+}
+
+func field(rec []string, i int) string {
+	if i < 0 || i >= len(rec) {
+		return ""
+	}
+	return rec[i]
+}
+
+func blank(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package guestimport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func parse(t *testing.T, data string, opts Options) *Result {
+	t.Helper()
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	if opts.Columns.Name == "" {
+		opts.Columns.Name = "name"
+	}
+	res, err := Parse(data, opts)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return res
+}
+
+func TestParse_ColumnMapping(t *testing.T) {
+	table := models.Table{ID: uuid.NewString(), Name: "Head Table"}
+	data := "Full Name;Diet;Plus one of;Table\n" +
+		"Alice Smith;Vegan, lactose-free;;head table\n" +
+		"Bob Jones;;Alice Smith;Nowhere\n"
+
+	res := parse(t, data, Options{
+		Columns:   models.GuestColumns{Name: "full name", DietaryRestrictions: "Diet", GuestOf: "Plus one of", Table: "Table"},
+		Delimiter: ';',
+		Tables:    []models.Table{table},
+		Now:       time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+
+	if res.Imported != 2 || len(res.Guests) != 2 {
+		t.Fatalf("expected 2 guests, got %+v", res)
+	}
+	alice, bob := res.Guests[0], res.Guests[1]
+	if len(alice.DietaryRestrictions) != 2 || alice.DietaryRestrictions[0] != models.DietaryVegan ||
+		alice.DietaryRestrictions[1] != models.DietaryLactoseIntolerant {
+		t.Errorf("unexpected dietary restrictions %v", alice.DietaryRestrictions)
+	}
+	if alice.AssignedTableID == nil || *alice.AssignedTableID != table.ID {
+		t.Errorf("expected Alice at the head table, got %v", alice.AssignedTableID)
+	}
+	if alice.CreatedAt != "2026-01-02T03:04:05Z" {
+		t.Errorf("unexpected createdAt %q", alice.CreatedAt)
+	}
+	if bob.GuestOf == nil || *bob.GuestOf != alice.ID {
+		t.Errorf("expected Bob to be Alice's guest, got %v", bob.GuestOf)
+	}
+	if bob.AssignedTableID != nil || len(res.Rows[1].Warnings) != 1 {
+		t.Errorf("expected unknown table to be warned about, got %+v", res.Rows[1])
+	}
+	if res.Rows[0].Line != 2 || res.Rows[1].Line != 3 {
+		t.Errorf("unexpected lines %d, %d", res.Rows[0].Line, res.Rows[1].Line)
+	}
+}
+
+func TestParse_Duplicates(t *testing.T) {
+	existing := models.Guest{ID: uuid.NewString(), Name: "José García"}
+	data := "name\n\"Garcia, Jose\"\nCarol\n carol \n"
+
+	res := parse(t, data, Options{Existing: []models.Guest{existing}})
+	if res.Imported != 1 || res.Duplicates != 2 {
+		t.Fatalf("expected 1 imported and 2 duplicates, got %+v", res)
+	}
+	if res.Rows[0].Status != models.ImportRowDuplicate || *res.Rows[0].DuplicateOf != existing.ID {
+		t.Errorf("expected row 1 to duplicate the existing guest, got %+v", res.Rows[0])
+	}
+	if res.Rows[2].Status != models.ImportRowDuplicate || *res.Rows[2].DuplicateOf != *res.Rows[1].GuestID {
+		t.Errorf("expected row 3 to duplicate row 2, got %+v", res.Rows[2])
+	}
+
+	res = parse(t, data, Options{Existing: []models.Guest{existing}, IncludeDuplicates: true})
+	if res.Imported != 3 || res.Duplicates != 2 || res.Rows[0].Status != models.ImportRowNew {
+		t.Errorf("expected duplicates to be imported, got %+v", res)
+	}
+}
+
+func TestParse_InvalidRows(t *testing.T) {
+	data := "name,dietary\n,VEGAN\nDave,keto\n\nErin,\n"
+
+	res := parse(t, data, Options{})
+	if res.Imported != 1 || res.Invalid != 2 || len(res.Rows) != 3 {
+		t.Fatalf("expected 1 imported and 2 invalid, got %+v", res)
+	}
+	if res.Rows[0].Errors[0] != "name is required" {
+		t.Errorf("unexpected errors %v", res.Rows[0].Errors)
+	}
+	if !strings.Contains(res.Rows[1].Errors[0], `"keto"`) {
+		t.Errorf("unexpected errors %v", res.Rows[1].Errors)
+	}
+}
+
+func TestParse_MissingColumn(t *testing.T) {
+	_, err := Parse("first,last\nA,B\n", Options{Delimiter: ',', Columns: models.GuestColumns{Name: "name"}})
+	if err == nil || !strings.Contains(err.Error(), `"name"`) {
+		t.Errorf("expected missing column error, got %v", err)
+	}
+
+	_, err = Parse("", Options{Delimiter: ',', Columns: models.GuestColumns{Name: "name"}})
+	if err == nil {
+		t.Error("expected error for empty csv")
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	if NameKey("Smith, Jöhn") != NameKey("john  SMITH") {
+		t.Error("expected accents, case, punctuation and order to be ignored")
+	}
+	if NameKey("John Smith") == NameKey("Jane Smith") {
+		t.Error("expected different names to differ")
+	}
+}
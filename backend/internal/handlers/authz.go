@@ -43,6 +43,16 @@ func (h *Handler) canManageOrgMembers(ctx context.Context, userID, orgID uuid.UU
 	return role == models.RoleOwner || role == models.RoleAdmin, nil
 }
 
+// canEditOrganizationContacts checks if the user can change an organization's
+// guest directory (members who are not viewers).
+func (h *Handler) canEditOrganizationContacts(ctx context.Context, userID, orgID uuid.UUID) (bool, error) {
+	role, err := h.getUserOrgRole(ctx, userID, orgID)
+	if err != nil {
+		return false, err
+	}
+	return role == models.RoleOwner || role == models.RoleAdmin || role == models.RoleMember, nil
+}
+
 // canViewFloorPlan checks if the user can view a floor plan.
 // Users can view if they are the creator OR if the plan is shared to an org they're a member of.
 func (h *Handler) canViewFloorPlan(ctx context.Context, userID, floorPlanID uuid.UUID) (bool, error) {
@@ -120,3 +130,89 @@ func (h *Handler) canEditFloorPlan(ctx context.Context, userID, floorPlanID uuid
 func (h *Handler) canShareToOrganization(ctx context.Context, userID, orgID uuid.UUID) (bool, error) {
 	return h.canAccessOrganization(ctx, userID, orgID)
 }
+
+// canViewVenue checks if the user can view a venue and its rooms. Venues are
+// shared like floor plans: the creator and members of the venue's organization.
+func (h *Handler) canViewVenue(ctx context.Context, userID, venueID uuid.UUID) (bool, error) {
+	role, err := h.venueRole(ctx, userID, venueID)
+	if err != nil {
+		return false, err
+	}
+	return role != "", nil
+}
+
+// canEditVenue checks if the user can change a venue and its rooms (creator or
+// organization member who is not a viewer).
+func (h *Handler) canEditVenue(ctx context.Context, userID, venueID uuid.UUID) (bool, error) {
+	role, err := h.venueRole(ctx, userID, venueID)
+	if err != nil {
+		return false, err
+	}
+	return role == models.RoleOwner || role == models.RoleAdmin || role == models.RoleMember, nil
+}
+
+// venueRole returns the user's role for a venue: owner for the creator, the
+// organization role for shared venues, or empty string without access.
+func (h *Handler) venueRole(ctx context.Context, userID, venueID uuid.UUID) (string, error) {
+	var creatorID uuid.UUID
+	var orgID *uuid.UUID
+	query := `SELECT user_id, organization_id FROM venues WHERE id = $1`
+	err := h.pool.QueryRow(ctx, query, venueID).Scan(&creatorID, &orgID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if creatorID == userID {
+		return models.RoleOwner, nil
+	}
+	if orgID == nil {
+		return "", nil
+	}
+	return h.getUserOrgRole(ctx, userID, *orgID)
+}
+
+// canViewEvent checks if the user can view an event, its guest list and
+// floor plans. Events are shared like venues.
+func (h *Handler) canViewEvent(ctx context.Context, userID, eventID uuid.UUID) (bool, error) {
+	role, err := h.eventRole(ctx, userID, eventID)
+	if err != nil {
+		return false, err
+	}
+	return role != "", nil
+}
+
+// canEditEvent checks if the user can change an event and its guest list
+// (creator or organization member who is not a viewer).
+func (h *Handler) canEditEvent(ctx context.Context, userID, eventID uuid.UUID) (bool, error) {
+	role, err := h.eventRole(ctx, userID, eventID)
+	if err != nil {
+		return false, err
+	}
+	return role == models.RoleOwner || role == models.RoleAdmin || role == models.RoleMember, nil
+}
+
+// eventRole returns the user's role for an event: owner for the creator, the
+// organization role for shared events, or empty string without access.
+func (h *Handler) eventRole(ctx context.Context, userID, eventID uuid.UUID) (string, error) {
+	var creatorID uuid.UUID
+	var orgID *uuid.UUID
+	query := `SELECT user_id, organization_id FROM events WHERE id = $1`
+	err := h.pool.QueryRow(ctx, query, eventID).Scan(&creatorID, &orgID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if creatorID == userID {
+		return models.RoleOwner, nil
+	}
+	if orgID == nil {
+		return "", nil
+	}
+	return h.getUserOrgRole(ctx, userID, *orgID)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AutoAssign runs the automatic seating algorithm against the stored plan.
+// The proposed seating is returned as a preview unless the request asks to
+// commit it, in which case it is saved as a new version.
+func (h *Handler) AutoAssign(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.AutoAssignRequest](r, w)
+	if !ok {
+		return
+	}
+
+	resp := h.reseat(w, r, userID, fpID, req.Commit, req.Version, req.Seed,
+		func(ctx context.Context, plan *seatingPlan, rng *rand.Rand) (*seating.Result, error) {
+			return seating.AutoAssign(plan.guests, plan.tables, *req.Config, rng), nil
+		})
+	if resp == nil {
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// reseat runs solve against the stored plan and returns the proposed seating,
+// saved as a new version when commit is set. It writes the error response and
+// returns nil when the seating can't be found or saved.
+func (h *Handler) reseat(w http.ResponseWriter, r *http.Request, userID, fpID uuid.UUID, commit bool, version *int, seed *int64,
+	solve func(ctx context.Context, plan *seatingPlan, rng *rand.Rand) (*seating.Result, error)) *models.AutoAssignResponse {
+	job := h.beginPlanJob(w, r, userID, fpID, commit, version)
+	if job == nil {
+		return nil
+	}
+	defer job.tx.Rollback(r.Context())
+
+	plan, err := loadSeatingPlan(r.Context(), job.tx, fpID)
+	if errors.Is(err, errInvalidStoredEntities) {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return nil
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return nil
+	}
+
+	s := time.Now().UnixNano()
+	if seed != nil {
+		s = *seed
+	}
+	result, err := solve(r.Context(), plan, rand.New(rand.NewSource(s)))
+	if err != nil {
+		// The client is gone, so nobody reads this
+		http.Error(w, `{"error":"request canceled"}`, http.StatusServiceUnavailable)
+		return nil
+	}
+
+	resp := &models.AutoAssignResponse{
+		Status:          "preview",
+		Version:         job.version,
+		Success:         result.Success,
+		Message:         result.Message,
+		UnassignedCount: result.UnassignedCount,
+		Displaced:       result.Displaced,
+	}
+	resp.Tables, resp.Guests, err = seating.WriteAssignments(plan.tableItems, plan.guestItems, result)
+	if err != nil {
+		http.Error(w, `{"error":"failed to apply assignment"}`, http.StatusInternalServerError)
+		return nil
+	}
+
+	if !commit {
+		return resp
+	}
+	if resp.Version, err = job.save(r.Context(), fpID, userID, resp.Tables, resp.Guests); err != nil {
+		http.Error(w, `{"error":"failed to save assignment"}`, http.StatusInternalServerError)
+		return nil
+	}
+	resp.Status = "assigned"
+	return resp
+}
+
+// planJob is the transaction of an automatic change to a stored plan, which
+// is either previewed or saved as a new version.
+type planJob struct {
+	tx      pgx.Tx
+	version int
+	roomID  *uuid.UUID
+}
+
+// beginPlanJob checks that the caller may run the job and opens its
+// transaction at the expected version. It writes the error response and
+// returns nil when the job can't run; otherwise the caller must roll back
+// job.tx.
+func (h *Handler) beginPlanJob(w http.ResponseWriter, r *http.Request, userID, fpID uuid.UUID, commit bool, version *int) *planJob {
+	// A preview changes nothing, so viewers may run it too
+	var allowed bool
+	var err error
+	if commit {
+		allowed, err = h.canEditFloorPlan(r.Context(), userID, fpID)
+	} else {
+		allowed, err = h.canViewFloorPlan(r.Context(), userID, fpID)
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return nil
+	}
+	if !allowed {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return nil
+	}
+
+	// Only a commit needs to hold off concurrent saves
+	query := `SELECT version, room_id FROM floor_plans WHERE id = $1`
+	if commit {
+		query += ` FOR UPDATE`
+	}
+	job := &planJob{tx: tx}
+	if err := tx.QueryRow(r.Context(), query, fpID).Scan(&job.version, &job.roomID); err != nil {
+		tx.Rollback(r.Context())
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return nil
+	}
+	if version != nil && *version != job.version {
+		tx.Rollback(r.Context())
+		h.respondVersionConflict(w, r, fpID, job.version)
+		return nil
+	}
+	return job
+}
+
+// save stores the changed tables and guests as a new version, as
+// saveSeating does, and commits the transaction.
+func (j *planJob) save(ctx context.Context, fpID, userID uuid.UUID, tables, guests []json.RawMessage) (int, error) {
+	version, err := saveSeating(ctx, j.tx, fpID, userID, tables, guests)
+	if err != nil {
+		return 0, err
+	}
+	return version, j.tx.Commit(ctx)
+}
+
+// errInvalidStoredEntities means stored entities don't decode into the typed model.
+var errInvalidStoredEntities = errors.New("invalid stored entities")
+
+// seatingPlan is the stored tables and guests of a plan, both as stored
+// documents and decoded.
+type seatingPlan struct {
+	tableItems []json.RawMessage
+	guestItems []json.RawMessage
+	tables     []models.Table
+	guests     []models.Guest
+}
+
+func loadSeatingPlan(ctx context.Context, tx pgx.Tx, fpID uuid.UUID) (*seatingPlan, error) {
+	var plan seatingPlan
+	var err error
+	if plan.tableItems, err = queryEntityData(ctx, tx, "floor_plan_tables", fpID); err != nil {
+		return nil, err
+	}
+	if plan.guestItems, err = queryEntityData(ctx, tx, "floor_plan_guests", fpID); err != nil {
+		return nil, err
+	}
+
+	plan.tables = make([]models.Table, len(plan.tableItems))
+	for i, item := range plan.tableItems {
+		if err := json.Unmarshal(item, &plan.tables[i]); err != nil {
+			return nil, errInvalidStoredEntities
+		}
+	}
+	plan.guests = make([]models.Guest, len(plan.guestItems))
+	for i, item := range plan.guestItems {
+		if err := json.Unmarshal(item, &plan.guests[i]); err != nil {
+			return nil, errInvalidStoredEntities
+		}
+	}
+	return &plan, nil
+}
+
+// saveSeating stores changed tables and guests and commits them as a new
+// version, leaving a nil list as stored. The caller must hold the floor_plans
+// row lock.
+func saveSeating(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, tables, guests []json.RawMessage) (int, error) {
+	unchanged := events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}}
+	changes := events.Changes{Tables: unchanged, Guests: unchanged, Labels: unchanged}
+	var err error
+	if tables != nil {
+		if changes.Tables, err = upsertEntities(ctx, tx, "floor_plan_tables", fpID, userID, tables); err != nil {
+			return 0, err
+		}
+	}
+	if guests != nil {
+		if changes.Guests, err = upsertEntities(ctx, tx, "floor_plan_guests", fpID, userID, guests); err != nil {
+			return 0, err
+		}
+	}
+	return commitVersion(ctx, tx, fpID, userID, changes, nil)
+}
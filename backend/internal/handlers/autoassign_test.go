@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// reseatEndpoint is a handler that proposes a seating of the stored plan,
+// with body fields that make it seat every guest.
+type reseatEndpoint struct {
+	name    string
+	path    string
+	body    string
+	handler func(h *Handler) http.HandlerFunc
+}
+
+func reseatEndpoints(apart string) []reseatEndpoint {
+	return []reseatEndpoint{
+		{"auto-assign", "/auto-assign", `"seed":1`, func(h *Handler) http.HandlerFunc { return h.AutoAssign }},
+		{"optimize", "/optimize", `"seed":1,"constraints":[` + apart + `]`, func(h *Handler) http.HandlerFunc { return h.OptimizeSeating }},
+	}
+}
+
+func TestAutoAssign_ViewerPreview(t *testing.T) {
+	tables, guests, _ := seatingPlanItems()
+	st := &seatingTest{role: models.RoleViewer, tables: tables, guests: guests}
+	h := New(st.db())
+
+	w := httptest.NewRecorder()
+	h.AutoAssign(w, seatingRequest(withUserID(context.Background(), uuid.New()), "/auto-assign", uuid.New(), `{"seed":1}`))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.AutoAssignResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Status != "preview" || resp.Version != 5 || resp.UnassignedCount != 0 || len(resp.Guests) != 3 {
+		t.Errorf("unexpected preview %s", w.Body.String())
+	}
+	if st.committed || len(st.saved) != 0 {
+		t.Error("expected a preview to save nothing")
+	}
+}
+
+func TestAutoAssign_ViewerCannotCommit(t *testing.T) {
+	tables, guests, _ := seatingPlanItems()
+	st := &seatingTest{role: models.RoleViewer, tables: tables, guests: guests}
+	h := New(st.db())
+
+	w := httptest.NewRecorder()
+	h.AutoAssign(w, seatingRequest(withUserID(context.Background(), uuid.New()), "/auto-assign", uuid.New(), `{"commit":true}`))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if st.committed {
+		t.Error("expected nothing committed")
+	}
+}
+
+func TestReseat_VersionConflict(t *testing.T) {
+	tables, guests, apart := seatingPlanItems()
+	for _, ep := range reseatEndpoints(apart) {
+		t.Run(ep.name, func(t *testing.T) {
+			st := &seatingTest{role: models.RoleMember, tables: tables, guests: guests}
+			h := New(st.db())
+
+			body := `{` + ep.body + `,"commit":true,"version":4}`
+			w := httptest.NewRecorder()
+			ep.handler(h)(w, seatingRequest(withUserID(context.Background(), uuid.New()), ep.path, uuid.New(), body))
+
+			if w.Code != http.StatusConflict {
+				t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+			}
+			if st.committed || len(st.saved) != 0 {
+				t.Error("expected a stale commit to save nothing")
+			}
+		})
+	}
+}
+
+func TestReseat_Commit(t *testing.T) {
+	tables, guests, apart := seatingPlanItems()
+	for _, ep := range reseatEndpoints(apart) {
+		t.Run(ep.name, func(t *testing.T) {
+			st := &seatingTest{role: models.RoleMember, tables: tables, guests: guests}
+			h := New(st.db())
+
+			body := `{` + ep.body + `,"commit":true,"version":5}`
+			w := httptest.NewRecorder()
+			ep.handler(h)(w, seatingRequest(withUserID(context.Background(), uuid.New()), ep.path, uuid.New(), body))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp models.AutoAssignResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if resp.Status != "assigned" || resp.Version != 6 {
+				t.Errorf("expected the seating saved as version 6, got %s", w.Body.String())
+			}
+			if !st.committed || len(st.saved) != 3 {
+				t.Errorf("expected all 3 guests saved and committed, got %d saved", len(st.saved))
+			}
+			for _, g := range st.saved {
+				var guest models.Guest
+				json.Unmarshal([]byte(g), &guest)
+				if guest.AssignedTableID == nil || guest.SeatPosition == nil {
+					t.Errorf("expected every guest seated, got %s", g)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/eventsync"
+	"github.com/frallan97/table-planner-backend/internal/export"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/remap"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ExportFloorPlan downloads the plan as a bundle that ImportFloorPlan can
+// read. Past versions are included with revisions=true.
+func (h *Handler) ExportFloorPlan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	withRevisions := false
+	if v := r.URL.Query().Get("revisions"); v != "" {
+		if withRevisions, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, `{"error":"revisions must be true or false"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	bundle := models.FloorPlanBundle{
+		Format:        models.BundleFormat,
+		FormatVersion: models.BundleFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+	}
+	err = h.pool.QueryRow(r.Context(),
+		`SELECT name, version FROM floor_plans WHERE id = $1`, fpID,
+	).Scan(&bundle.Name, &bundle.Version)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+
+	if bundle.Tables, err = h.getEntityData(r.Context(), "floor_plan_tables", fpID); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if bundle.Guests, err = h.getEntityData(r.Context(), "floor_plan_guests", fpID); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if bundle.Labels, err = h.getEntityData(r.Context(), "floor_plan_labels", fpID); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if withRevisions {
+		rows, err := h.pool.Query(r.Context(),
+			`SELECT version, created_at, restored_from, tables, guests, labels
+			 FROM floor_plan_revisions
+			 WHERE floor_plan_id = $1
+			 ORDER BY version`,
+			fpID,
+		)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var rev models.BundleRevision
+			if err := rows.Scan(&rev.Version, &rev.CreatedAt, &rev.RestoredFrom, &rev.Tables, &rev.Guests, &rev.Labels); err != nil {
+				http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+				return
+			}
+			bundle.Revisions = append(bundle.Revisions, rev)
+		}
+		if err := rows.Err(); err != nil {
+			http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	sendFile(w, "attachment", "application/json", export.Filename(bundle.Name, "json"), func(out io.Writer) error {
+		return json.NewEncoder(out).Encode(bundle)
+	})
+}
+
+// ImportFloorPlan creates a personal floor plan from a bundle. Every entity
+// gets a new ID, so a plan can be imported next to the one it was exported
+// from. Seat assignments that don't agree are repaired.
+func (h *Handler) ImportFloorPlan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	bundle, ok := decodeAndValidate[models.FloorPlanBundle](r, w)
+	if !ok {
+		return
+	}
+
+	// Revisions share the mapping so history lines up with the current plan
+	ids := remap.New()
+	tables, guests, labels, err := ids.Entities(bundle.Tables, bundle.Guests, bundle.Labels)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	// The imported plan has no event, so its guests are plain guests
+	if guests, _, err = eventsync.Unlink(guests); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "guests: " + err.Error()})
+		return
+	}
+	if errs := models.ValidateEntities(tables, guests, labels); len(errs) > 0 {
+		respondFieldErrors(w, errs)
+		return
+	}
+	reconciled, err := seating.Reconcile(tables, guests)
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan data"}`, http.StatusBadRequest)
+		return
+	}
+	if len(reconciled.Issues) > 0 {
+		tables, guests = reconciled.Tables, reconciled.Guests
+	}
+
+	revisions := make([]models.BundleRevision, len(bundle.Revisions))
+	for i, rev := range bundle.Revisions {
+		rev.Tables, rev.Guests, rev.Labels, err = ids.Entities(rev.Tables, rev.Guests, rev.Labels)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("revisions[%d].%v", i, err)})
+			return
+		}
+		if rev.Guests, _, err = eventsync.Unlink(rev.Guests); err != nil {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("revisions[%d].guests: %v", i, err)})
+			return
+		}
+		// A restore makes a revision live, so it must pass the same checks
+		if errs := models.ValidateEntities(rev.Tables, rev.Guests, rev.Labels); len(errs) > 0 {
+			for j := range errs {
+				errs[j].Path = fmt.Sprintf("revisions[%d].%s", i, errs[j].Path)
+			}
+			respondFieldErrors(w, errs)
+			return
+		}
+		revisions[i] = rev
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	now := time.Now()
+	fp := models.FloorPlan{ID: uuid.New(), UserID: userID, Name: bundle.Name, CreatedAt: now, UpdatedAt: now}
+
+	// History keeps its version numbers; the imported state is committed on top
+	lastVersion := 0
+	if len(revisions) > 0 {
+		lastVersion = revisions[len(revisions)-1].Version
+	}
+	_, err = tx.Exec(r.Context(),
+		`INSERT INTO floor_plans (id, user_id, name, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		fp.ID, fp.UserID, fp.Name, lastVersion, fp.CreatedAt, fp.UpdatedAt,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	for _, rev := range revisions {
+		createdAt := rev.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		_, err = tx.Exec(r.Context(),
+			`INSERT INTO floor_plan_revisions (floor_plan_id, version, created_by, created_at, restored_from, tables, guests, labels)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			fp.ID, rev.Version, userID, createdAt, rev.RestoredFrom,
+			jsonArray(rev.Tables), jsonArray(rev.Guests), jsonArray(rev.Labels),
+		)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	changes, err := replaceEntities(r.Context(), tx, fp.ID, userID, tables, guests, labels)
+	if err != nil {
+		http.Error(w, `{"error":"failed to import floor plan"}`, http.StatusInternalServerError)
+		return
+	}
+	if fp.Version, err = commitVersion(r.Context(), tx, fp.ID, userID, changes, nil); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.FloorPlanImportResponse{
+		FloorPlan: fp,
+		Revisions: len(revisions),
+		Repairs:   reconciled.Issues,
+	})
+}
+
+// jsonArray encodes items as a JSONB array, empty rather than null.
+func jsonArray(items []json.RawMessage) json.RawMessage {
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	data, _ := json.Marshal(items)
+	return data
+}
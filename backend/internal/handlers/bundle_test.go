@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestExportFloorPlan(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guest := json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Alice"}`)
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				switch {
+				case strings.Contains(sql, "SELECT user_id, organization_id"):
+					*dest[0].(*uuid.UUID) = userID
+				case strings.Contains(sql, "SELECT name, version"):
+					*dest[0].(*string) = "Gala"
+					*dest[1].(*int) = 3
+				}
+				return nil
+			}}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			switch {
+			case strings.Contains(sql, "FROM floor_plan_revisions"):
+				return &mockRows{n: 1, scan: func(i int, dest ...any) error {
+					*dest[0].(*int) = 1
+					*dest[1].(*time.Time) = time.Now()
+					for _, d := range dest[3:] {
+						*d.(*[]json.RawMessage) = []json.RawMessage{}
+					}
+					return nil
+				}}, nil
+			case strings.Contains(sql, "floor_plan_guests"):
+				return dataRows(guest), nil
+			}
+			return &emptyRows{}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/export?revisions=true", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.ExportFloorPlan(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=Gala.json` {
+		t.Errorf("unexpected disposition %q", got)
+	}
+	var bundle models.FloorPlanBundle
+	if err := json.Unmarshal(w.Body.Bytes(), &bundle); err != nil {
+		t.Fatalf("failed to parse bundle: %v", err)
+	}
+	if err := bundle.Validate(); err != nil {
+		t.Errorf("exported bundle does not validate: %v", err)
+	}
+	if bundle.Name != "Gala" || bundle.Version != 3 || len(bundle.Guests) != 1 || len(bundle.Revisions) != 1 {
+		t.Errorf("unexpected bundle: %+v", bundle)
+	}
+}
+
+func TestImportFloorPlan(t *testing.T) {
+	userID := uuid.New()
+	tableID, guestID := uuid.NewString(), uuid.NewString()
+	table := `{"id":"` + tableID + `","name":"Head","tableType":"ROUND","position":{"x":0,"y":0},"rotation":0,
+		"capacity":2,"seats":[{"position":0,"guestId":"` + guestID + `","label":""},{"position":1,"guestId":null,"label":""}],
+		"assignedGuests":["` + guestID + `"]}`
+	guest := `{"id":"` + guestID + `","name":"Alice","dietaryRestrictions":[],"assignedTableId":"` + tableID + `","seatPosition":0,` +
+		`"eventGuestId":"` + uuid.NewString() + `"}`
+	body := `{"format":"table-planner/floor-plan","formatVersion":1,"name":"Gala","version":4,
+		"tables":[` + table + `],"guests":[` + guest + `],"labels":[],
+		"revisions":[{"version":2,"createdAt":"2026-01-01T00:00:00Z","tables":[` + table + `],"guests":[],"labels":[]}]}`
+
+	var planArgs []any
+	var revisions [][]any
+	inserted := map[string]json.RawMessage{}
+	h := New(&mockDB{
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						*dest[0].(*int) = 3
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return &emptyRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					switch {
+					case strings.Contains(sql, "INSERT INTO floor_plans"):
+						planArgs = args
+					case strings.Contains(sql, "INSERT INTO floor_plan_revisions"):
+						// Bundled history, then the imported state
+						revisions = append(revisions, args)
+					case strings.Contains(sql, "INSERT INTO floor_plan_tables"):
+						inserted["table"] = args[2].(json.RawMessage)
+					case strings.Contains(sql, "INSERT INTO floor_plan_guests"):
+						inserted["guest"] = args[2].(json.RawMessage)
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/import", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	w := httptest.NewRecorder()
+
+	h.ImportFloorPlan(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.FloorPlanImportResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Name != "Gala" || resp.UserID != userID || resp.Version != 3 || resp.Revisions != 1 || len(resp.Repairs) != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(planArgs) < 4 || planArgs[3] != 2 {
+		t.Errorf("expected the plan to start at the last bundled version, got %v", planArgs)
+	}
+	if len(revisions) != 2 || revisions[0][1] != 2 || revisions[0][2] != userID || revisions[1][1] != 3 {
+		t.Fatalf("expected revision 2 to be imported before version 3, got %v", revisions)
+	}
+
+	var newTable models.Table
+	var newGuest models.Guest
+	json.Unmarshal(inserted["table"], &newTable)
+	json.Unmarshal(inserted["guest"], &newGuest)
+	if newTable.ID == tableID || newGuest.ID == guestID {
+		t.Fatal("imported entities kept their original IDs")
+	}
+	if newGuest.EventGuestID != nil {
+		t.Errorf("expected the imported guest unlinked from its event, got %s", inserted["guest"])
+	}
+	if *newTable.Seats[0].GuestID != newGuest.ID || *newGuest.AssignedTableID != newTable.ID {
+		t.Errorf("references not remapped: table %+v, guest %+v", newTable, newGuest)
+	}
+	if !strings.Contains(string(revisions[0][5].(json.RawMessage)), newTable.ID) {
+		t.Errorf("revision not remapped like the current plan: %s", revisions[0][5])
+	}
+}
+
+func TestImportFloorPlan_InvalidBundle(t *testing.T) {
+	h := New(&mockDB{})
+	for _, body := range []string{
+		`{"format":"other","formatVersion":1}`,
+		`{"format":"table-planner/floor-plan","formatVersion":99}`,
+		`{"format":"table-planner/floor-plan","formatVersion":1,"tables":[{"id":"nope"}]}`,
+		`{"format":"table-planner/floor-plan","formatVersion":1,"revisions":[{"version":1,"tables":[{"id":"nope"}]}]}`,
+		`{"format":"table-planner/floor-plan","formatVersion":1,"revisions":[{"version":2},{"version":2}]}`,
+		`{"format":"table-planner/floor-plan","formatVersion":1,"revisions":[{"version":3},{"version":2}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/import", strings.NewReader(body))
+		req = req.WithContext(withUserID(req.Context(), uuid.New()))
+		w := httptest.NewRecorder()
+
+		h.ImportFloorPlan(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
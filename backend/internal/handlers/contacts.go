@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const contactColumns = `id, organization_id, name, email, dietary_restrictions, tags, notes, created_by, created_at, updated_at`
+
+// maxContactResults caps a directory search.
+const maxContactResults = 500
+
+func scanContact(row pgx.Row, c *models.Contact) error {
+	return row.Scan(&c.ID, &c.OrganizationID, &c.Name, &c.Email, &c.DietaryRestrictions, &c.Tags, &c.Notes,
+		&c.CreatedBy, &c.CreatedAt, &c.UpdatedAt)
+}
+
+// likePattern matches text containing s, with LIKE wildcards in s escaped.
+func likePattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(s) + "%"
+}
+
+// ListContacts searches an organization's guest directory (members only).
+// The q parameter matches names and emails, tag matches a tag exactly.
+func (h *Handler) ListContacts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canAccess, err := h.canAccessOrganization(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canAccess {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	tag := strings.TrimSpace(r.URL.Query().Get("tag"))
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT `+contactColumns+`
+		 FROM organization_contacts
+		 WHERE organization_id = $1
+		   AND ($2 = '' OR name ILIKE $3 OR email ILIKE $3)
+		   AND ($4 = '' OR $4 = ANY(tags))
+		 ORDER BY name, id
+		 LIMIT $5`,
+		orgID, q, likePattern(q), tag, maxContactResults,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	contacts := []models.Contact{}
+	for rows.Next() {
+		var c models.Contact
+		if err := scanContact(rows, &c); err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		contacts = append(contacts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, contacts)
+}
+
+// CreateContact adds a contact to an organization's guest directory.
+func (h *Handler) CreateContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.ContactRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditOrganizationContacts(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	dietary, _ := json.Marshal(req.DietaryRestrictions)
+	var c models.Contact
+	err = scanContact(h.pool.QueryRow(r.Context(),
+		`INSERT INTO organization_contacts (organization_id, name, email, dietary_restrictions, tags, notes, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING `+contactColumns,
+		orgID, req.Name, req.Email, dietary, req.Tags, req.Notes, userID,
+	), &c)
+	if err != nil {
+		http.Error(w, `{"error":"failed to create contact"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, c)
+}
+
+// UpdateContact replaces a contact. With sync set, the contact's name and
+// dietary restrictions are also copied into the organization's floor plans
+// that have guests pulled from it, each of which gets a new version.
+func (h *Handler) UpdateContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+	contactID, err := uuid.Parse(chi.URLParam(r, "contactId"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid contact ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.ContactRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditOrganizationContacts(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	dietary, _ := json.Marshal(req.DietaryRestrictions)
+	resp := models.ContactResponse{FloorPlans: []models.FloorPlanVersion{}}
+	err = scanContact(tx.QueryRow(r.Context(),
+		`UPDATE organization_contacts
+		 SET name = $1, email = $2, dietary_restrictions = $3, tags = $4, notes = $5, updated_at = NOW()
+		 WHERE id = $6 AND organization_id = $7
+		 RETURNING `+contactColumns,
+		req.Name, req.Email, dietary, req.Tags, req.Notes, contactID, orgID,
+	), &resp.Contact)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"contact not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"failed to update contact"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if req.Sync {
+		// The contact row stays locked until commit, so concurrent syncs of
+		// the same contact are applied in order
+		c := resp.Contact
+		resp.FloorPlans, err = editPlans(r.Context(), tx, userID,
+			func(tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, bool, error) {
+				guests, changed, err := syncContactGuests(guests, c)
+				return tables, guests, changed, err
+			},
+			`SELECT id, name FROM floor_plans
+			 WHERE organization_id = $1
+			   AND id IN (SELECT floor_plan_id FROM floor_plan_guests WHERE data->>'contactId' = $2)
+			 ORDER BY id FOR UPDATE`,
+			orgID, contactID.String(),
+		)
+		if err != nil {
+			http.Error(w, `{"error":"failed to update floor plans"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// DeleteContact removes a contact from the guest directory. Guests pulled
+// from it stay in their floor plans.
+func (h *Handler) DeleteContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+	contactID, err := uuid.Parse(chi.URLParam(r, "contactId"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid contact ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canEdit, err := h.canEditOrganizationContacts(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(),
+		`DELETE FROM organization_contacts WHERE id = $1 AND organization_id = $2`, contactID, orgID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"contact not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddContactsToFloorPlan pulls contacts from the guest directory of the floor
+// plan's organization into the plan as unseated guests, saved as a new
+// version. Contacts the plan already has a guest for are skipped.
+func (h *Handler) AddContactsToFloorPlan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.AddContactsRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	var orgID *uuid.UUID
+	var dbVersion int
+	err = tx.QueryRow(r.Context(),
+		`SELECT organization_id, version FROM floor_plans WHERE id = $1 FOR UPDATE`, fpID,
+	).Scan(&orgID, &dbVersion)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+	if req.Version != nil && *req.Version != dbVersion {
+		h.respondVersionConflict(w, r, fpID, dbVersion)
+		return
+	}
+	if orgID == nil {
+		http.Error(w, `{"error":"floor plan is not shared to an organization"}`, http.StatusBadRequest)
+		return
+	}
+	// The creator of a plan can edit it after leaving its organization
+	canAccess, err := h.canAccessOrganization(r.Context(), userID, *orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canAccess {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	rows, err := tx.Query(r.Context(),
+		`SELECT `+contactColumns+` FROM organization_contacts WHERE organization_id = $1 AND id = ANY($2)`,
+		*orgID, req.ContactIDs,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	contacts := make(map[uuid.UUID]models.Contact, len(req.ContactIDs))
+	for rows.Next() {
+		var c models.Contact
+		if err := scanContact(rows, &c); err != nil {
+			rows.Close()
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		contacts[c.ID] = c
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+	for _, id := range req.ContactIDs {
+		if _, ok := contacts[id]; !ok {
+			http.Error(w, `{"error":"contact `+id.String()+` not found"}`, http.StatusNotFound)
+			return
+		}
+	}
+
+	guests, err := queryEntityData(r.Context(), tx, "floor_plan_guests", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	present := map[string]bool{}
+	for _, item := range guests {
+		var g models.Guest
+		if err := json.Unmarshal(item, &g); err != nil {
+			http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		if g.ContactID != nil {
+			present[*g.ContactID] = true
+		}
+	}
+
+	resp := models.AddContactsResponse{Status: "unchanged", Version: dbVersion, Guests: []json.RawMessage{}}
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	for _, id := range req.ContactIDs {
+		if present[id.String()] {
+			continue
+		}
+		c := contacts[id]
+		contactID := id.String()
+		guest, _ := json.Marshal(models.Guest{
+			ID:                  uuid.NewString(),
+			Name:                c.Name,
+			DietaryRestrictions: c.DietaryRestrictions,
+			CreatedAt:           createdAt,
+			ContactID:           &contactID,
+		})
+		resp.Guests = append(resp.Guests, guest)
+	}
+	if len(resp.Guests) == 0 {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	changes := events.Changes{
+		Tables: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+		Labels: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+	}
+	changes.Guests, err = upsertEntities(r.Context(), tx, "floor_plan_guests", fpID, userID, append(guests, resp.Guests...))
+	if err != nil {
+		http.Error(w, `{"error":"failed to save guests"}`, http.StatusInternalServerError)
+		return
+	}
+	if resp.Version, err = commitVersion(r.Context(), tx, fpID, userID, changes, nil); err != nil {
+		http.Error(w, `{"error":"failed to save guests"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	resp.Status = "added"
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// syncContactGuests copies a contact's name and dietary restrictions onto the
+// guests pulled from it, reporting whether any guest changed. Copies of event
+// guests are left alone, since the event owns those fields.
+func syncContactGuests(items []json.RawMessage, c models.Contact) ([]json.RawMessage, bool, error) {
+	patch, _ := json.Marshal(map[string]any{"name": c.Name, "dietaryRestrictions": c.DietaryRestrictions})
+	out := make([]json.RawMessage, len(items))
+	changed := false
+	for i, item := range items {
+		out[i] = item
+		var g models.Guest
+		if err := json.Unmarshal(item, &g); err != nil {
+			return nil, false, errInvalidStoredEntities
+		}
+		if g.ContactID == nil || *g.ContactID != c.ID.String() || g.EventGuestID != nil {
+			continue
+		}
+		if g.Name == c.Name && slices.Equal(g.DietaryRestrictions, c.DietaryRestrictions) {
+			continue
+		}
+		patched, err := mergePatch(item, patch)
+		if err != nil {
+			return nil, false, errInvalidStoredEntities
+		}
+		out[i] = patched
+		changed = true
+	}
+	return out, changed, nil
+}
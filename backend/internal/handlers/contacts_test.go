@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestSyncContactGuests(t *testing.T) {
+	c := models.Contact{ID: uuid.New(), Name: "Alice Smith", DietaryRestrictions: []models.DietaryRestriction{models.DietaryVegan}}
+	items := []json.RawMessage{
+		json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Alice","dietaryRestrictions":[],"contactId":"` + c.ID.String() + `","assignedTableId":"t1","seatPosition":2}`),
+		json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Bob","dietaryRestrictions":[]}`),
+		// The event owns this copy's name and dietary restrictions
+		json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Alice","dietaryRestrictions":[],"contactId":"` + c.ID.String() + `","eventGuestId":"` + uuid.NewString() + `"}`),
+	}
+
+	out, changed, err := syncContactGuests(items, c)
+	if err != nil || !changed {
+		t.Fatalf("expected a change, got changed=%v err=%v", changed, err)
+	}
+	var g models.Guest
+	json.Unmarshal(out[0], &g)
+	if g.Name != "Alice Smith" || len(g.DietaryRestrictions) != 1 || g.SeatPosition == nil || *g.SeatPosition != 2 {
+		t.Errorf("expected the contact's details with the seat kept, got %s", out[0])
+	}
+	if string(out[1]) != string(items[1]) || string(out[2]) != string(items[2]) {
+		t.Errorf("expected other guests and event guests to be left alone, got %s %s", out[1], out[2])
+	}
+
+	if _, changed, _ := syncContactGuests(out, c); changed {
+		t.Error("expected no change once in sync")
+	}
+}
+
+func TestAddContactsToFloorPlan_SkipsPresentContacts(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	orgID := uuid.New()
+	present := models.Contact{ID: uuid.New(), Name: "Alice"}
+	added := models.Contact{ID: uuid.New(), Name: "Bob", DietaryRestrictions: []models.DietaryRestriction{models.DietaryVegetarian}}
+
+	var saved []string
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				if strings.Contains(sql, "organization_members") {
+					*dest[0].(*string) = models.RoleMember
+					return nil
+				}
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						if strings.Contains(sql, "UPDATE floor_plans") {
+							*dest[0].(*int) = 4
+							return nil
+						}
+						*dest[0].(**uuid.UUID) = &orgID
+						*dest[1].(*int) = 3
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					switch {
+					case strings.Contains(sql, "FROM organization_contacts"):
+						contacts := []models.Contact{present, added}
+						return &mockRows{n: len(contacts), scan: func(i int, dest ...any) error {
+							*dest[0].(*uuid.UUID) = contacts[i].ID
+							*dest[2].(*string) = contacts[i].Name
+							*dest[4].(*[]models.DietaryRestriction) = contacts[i].DietaryRestrictions
+							return nil
+						}}, nil
+					case strings.Contains(sql, "SELECT data FROM floor_plan_guests"):
+						return dataRows(
+							json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Alice","dietaryRestrictions":[],"contactId":"` + present.ID.String() + `"}`),
+						), nil
+					}
+					return &mockRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					if strings.Contains(sql, "INSERT INTO floor_plan_guests") {
+						saved = append(saved, string(args[2].(json.RawMessage)))
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"contactIds":["` + present.ID.String() + `","` + added.ID.String() + `"],"version":3}`
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/contacts", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.AddContactsToFloorPlan(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.AddContactsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Status != "added" || resp.Version != 4 || len(resp.Guests) != 1 {
+		t.Fatalf("expected one guest added in version 4, got %s", w.Body.String())
+	}
+	var g models.Guest
+	json.Unmarshal(resp.Guests[0], &g)
+	if g.Name != "Bob" || g.ContactID == nil || *g.ContactID != added.ID.String() || g.AssignedTableID != nil {
+		t.Errorf("expected an unseated guest linked to the contact, got %s", resp.Guests[0])
+	}
+	if len(saved) != 2 {
+		t.Errorf("expected the existing and the added guest to be saved, got %v", saved)
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/eventsync"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/remap"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DuplicateFloorPlan copies a floor plan and all of its entities into a new
+// plan owned by the caller. The copy starts a fresh history.
+func (h *Handler) DuplicateFloorPlan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.DuplicateFloorPlanRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	if req.OrganizationID != nil {
+		canShare, err := h.canShareToOrganization(r.Context(), userID, *req.OrganizationID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !canShare {
+			http.Error(w, `{"error":"not a member of this organization"}`, http.StatusForbidden)
+			return
+		}
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// Hold off saves to the source until its entities have been read
+	var sourceName string
+	var roomID, eventID *uuid.UUID
+	err = tx.QueryRow(r.Context(),
+		`SELECT name, room_id, event_id FROM floor_plans WHERE id = $1 FOR SHARE`, fpID,
+	).Scan(&sourceName, &roomID, &eventID)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+
+	tables, err := queryEntityData(r.Context(), tx, "floor_plan_tables", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	guests, err := queryEntityData(r.Context(), tx, "floor_plan_guests", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	labels, err := queryEntityData(r.Context(), tx, "floor_plan_labels", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if req.ExcludeGuests {
+		guests = []json.RawMessage{}
+	}
+	if req.ExcludeGuests || req.ClearAssignments {
+		if tables, guests, err = seating.ClearAssignments(tables, guests); err != nil {
+			http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	tables, guests, labels, err = remap.New().Entities(tables, guests, labels)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	// The copy joins the source's event only if the user may add plans to it
+	if eventID != nil {
+		canEdit, err := h.canEditEvent(r.Context(), userID, *eventID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !canEdit {
+			eventID = nil
+			if guests, _, err = eventsync.Unlink(guests); err != nil {
+				http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+				return
+			}
+		}
+	}
+
+	// The copy keeps the source's room only if its owner can see the venue
+	if roomID != nil {
+		var venueID uuid.UUID
+		err := h.pool.QueryRow(r.Context(), `SELECT venue_id FROM rooms WHERE id = $1`, *roomID).Scan(&venueID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		canView := false
+		if err == nil {
+			if canView, err = h.canViewVenue(r.Context(), userID, venueID); err != nil {
+				http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+		if !canView {
+			roomID = nil
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = sourceName + " (copy)"
+		if len(name) > models.MaxFloorPlanNameLength {
+			name = sourceName
+		}
+	}
+	now := time.Now()
+	fp := models.FloorPlan{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Name:           name,
+		OrganizationID: req.OrganizationID,
+		RoomID:         roomID,
+		EventID:        eventID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	// The copy's first version is committed below
+	_, err = tx.Exec(r.Context(),
+		`INSERT INTO floor_plans (id, user_id, name, organization_id, room_id, event_id, version, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8)`,
+		fp.ID, fp.UserID, fp.Name, fp.OrganizationID, fp.RoomID, fp.EventID, fp.CreatedAt, fp.UpdatedAt,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	changes, err := replaceEntities(r.Context(), tx, fp.ID, userID, tables, guests, labels)
+	if err != nil {
+		http.Error(w, `{"error":"failed to duplicate floor plan"}`, http.StatusInternalServerError)
+		return
+	}
+	if fp.Version, err = commitVersion(r.Context(), tx, fp.ID, userID, changes, nil); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, fp)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDuplicateFloorPlan(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	tableID, guestID := uuid.NewString(), uuid.NewString()
+	table := json.RawMessage(`{"id":"` + tableID + `","seats":[{"position":0,"guestId":"` + guestID + `"}],"assignedGuests":["` + guestID + `"]}`)
+	guest := json.RawMessage(`{"id":"` + guestID + `","name":"Alice","assignedTableId":"` + tableID + `","seatPosition":0}`)
+
+	var planArgs []any
+	inserted := map[string][]json.RawMessage{}
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						switch d := dest[0].(type) {
+						case *string:
+							*d = "Gala"
+						case *int:
+							*d = 1
+						}
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					switch {
+					case strings.Contains(sql, "SELECT data FROM floor_plan_tables"):
+						return dataRows(table), nil
+					case strings.Contains(sql, "SELECT data FROM floor_plan_guests"):
+						return dataRows(guest), nil
+					}
+					return &emptyRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					switch {
+					case strings.Contains(sql, "INSERT INTO floor_plans"):
+						planArgs = args
+					case strings.Contains(sql, "INSERT INTO floor_plan_tables"):
+						inserted["tables"] = append(inserted["tables"], args[2].(json.RawMessage))
+					case strings.Contains(sql, "INSERT INTO floor_plan_guests"):
+						inserted["guests"] = append(inserted["guests"], args[2].(json.RawMessage))
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/duplicate", strings.NewReader(`{"clearAssignments":true}`))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.DuplicateFloorPlan(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var fp models.FloorPlan
+	json.Unmarshal(w.Body.Bytes(), &fp)
+	if fp.ID == fpID || fp.Name != "Gala (copy)" || fp.Version != 1 || fp.OrganizationID != nil {
+		t.Errorf("unexpected copy: %+v", fp)
+	}
+	if len(planArgs) == 0 || planArgs[0] != fp.ID {
+		t.Errorf("copy not inserted: %v", planArgs)
+	}
+	if len(inserted["tables"]) != 1 || len(inserted["guests"]) != 1 {
+		t.Fatalf("expected one table and one guest copied, got %v", inserted)
+	}
+	var newTable models.Table
+	var newGuest models.Guest
+	json.Unmarshal(inserted["tables"][0], &newTable)
+	json.Unmarshal(inserted["guests"][0], &newGuest)
+	if newTable.ID == tableID || newGuest.ID == guestID {
+		t.Error("copied entities kept their original IDs")
+	}
+	if newTable.Seats[0].GuestID != nil || newGuest.AssignedTableID != nil {
+		t.Errorf("assignments not cleared: %s %s", inserted["tables"][0], inserted["guests"][0])
+	}
+}
+
+func TestDuplicateFloorPlan_OtherOrganization(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			if strings.Contains(sql, "organization_members") {
+				return &mockRow{err: pgx.ErrNoRows}
+			}
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+	})
+
+	body := `{"organizationId":"` + uuid.NewString() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/duplicate", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.DuplicateFloorPlan(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDuplicateFloorPlan_Room(t *testing.T) {
+	tests := []struct {
+		name     string
+		ownVenue bool
+	}{
+		{"visible venue", true},
+		{"other user's venue", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, fpID, roomID := uuid.New(), uuid.New(), uuid.New()
+			venueOwner := uuid.New()
+			if tt.ownVenue {
+				venueOwner = userID
+			}
+
+			var planArgs []any
+			h := New(&mockDB{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						switch {
+						case strings.Contains(sql, "FROM rooms"):
+							*dest[0].(*uuid.UUID) = uuid.New()
+						case strings.Contains(sql, "FROM venues"):
+							*dest[0].(*uuid.UUID) = venueOwner
+						default:
+							*dest[0].(*uuid.UUID) = userID
+						}
+						return nil
+					}}
+				},
+				beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+					return &mockTx{
+						queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+							return &mockRow{scanFunc: func(dest ...any) error {
+								switch d := dest[0].(type) {
+								case *string:
+									*d = "Gala"
+									*dest[1].(**uuid.UUID) = &roomID
+								case *int:
+									*d = 1
+								}
+								return nil
+							}}
+						},
+						queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+							return &emptyRows{}, nil
+						},
+						execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+							if strings.Contains(sql, "INSERT INTO floor_plans") {
+								planArgs = args
+							}
+							return pgconn.NewCommandTag("INSERT 0 1"), nil
+						},
+					}, nil
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/duplicate", strings.NewReader(`{}`))
+			req = req.WithContext(withUserID(req.Context(), userID))
+			req = withChiParam(req, "id", fpID.String())
+			w := httptest.NewRecorder()
+
+			h.DuplicateFloorPlan(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+			}
+			var fp models.FloorPlan
+			json.Unmarshal(w.Body.Bytes(), &fp)
+			kept := fp.RoomID != nil && *fp.RoomID == roomID
+			if kept != tt.ownVenue {
+				t.Errorf("expected room kept %v, got %v", tt.ownVenue, fp.RoomID)
+			}
+			if len(planArgs) < 5 || (planArgs[4].(*uuid.UUID) != nil) != tt.ownVenue {
+				t.Errorf("unexpected room inserted: %v", planArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// loadEntityVersions returns the version metadata of every entity in a floor plan.
+func loadEntityVersions(ctx context.Context, q rowsQuerier, fpID uuid.UUID) (models.EntityVersions, error) {
+	var result models.EntityVersions
+	targets := []struct {
+		table string
+		dst   *map[uuid.UUID]models.EntityVersion
+	}{
+		{"floor_plan_tables", &result.Tables},
+		{"floor_plan_guests", &result.Guests},
+		{"floor_plan_labels", &result.Labels},
+	}
+	for _, t := range targets {
+		rows, err := q.Query(ctx,
+			fmt.Sprintf(`SELECT id, version, updated_by, updated_at FROM %s WHERE floor_plan_id = $1`, t.table),
+			fpID,
+		)
+		if err != nil {
+			return result, err
+		}
+		versions := map[uuid.UUID]models.EntityVersion{}
+		for rows.Next() {
+			var id uuid.UUID
+			var v models.EntityVersion
+			if err := rows.Scan(&id, &v.Version, &v.UpdatedBy, &v.UpdatedAt); err != nil {
+				rows.Close()
+				return result, err
+			}
+			versions[id] = v
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return result, err
+		}
+		*t.dst = versions
+	}
+	return result, nil
+}
+
+// versionedSave is the set of writes needed to bring one entity type in line
+// with a per-entity save, computed before anything is written.
+type versionedSave struct {
+	tableName string
+	inserts   map[uuid.UUID]json.RawMessage
+	updates   map[uuid.UUID]json.RawMessage
+	deletes   []uuid.UUID
+	conflicts []uuid.UUID
+}
+
+// planVersionedSave compares incoming entities with the stored rows.
+//
+// expected holds every entity the client knows about and the version it saw.
+// A changed or deleted entity conflicts if its stored version differs from the
+// expected one; entities the client never saw are left alone.
+func planVersionedSave(ctx context.Context, tx pgx.Tx, tableName string, fpID uuid.UUID, items []json.RawMessage, expected map[uuid.UUID]int) (*versionedSave, error) {
+	if !allowedTables[tableName] {
+		return nil, fmt.Errorf("invalid table name: %s", tableName)
+	}
+
+	type storedRow struct {
+		data    json.RawMessage
+		version int
+	}
+	rows, err := tx.Query(ctx,
+		fmt.Sprintf(`SELECT id, data, version FROM %s WHERE floor_plan_id = $1`, tableName),
+		fpID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	stored := map[uuid.UUID]storedRow{}
+	for rows.Next() {
+		var id uuid.UUID
+		var row storedRow
+		if err := rows.Scan(&id, &row.data, &row.version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stored[id] = row
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	plan := &versionedSave{
+		tableName: tableName,
+		inserts:   map[uuid.UUID]json.RawMessage{},
+		updates:   map[uuid.UUID]json.RawMessage{},
+		deletes:   []uuid.UUID{},
+		conflicts: []uuid.UUID{},
+	}
+	incoming := map[uuid.UUID]bool{}
+	for _, item := range items {
+		id := extractID(item)
+		incoming[id] = true
+		cur, exists := stored[id]
+		want, known := expected[id]
+
+		switch {
+		case exists && jsonEqual(cur.data, item):
+			// Unchanged
+		case exists && known && want == cur.version:
+			plan.updates[id] = item
+		case exists:
+			plan.conflicts = append(plan.conflicts, id)
+		case known:
+			// Deleted by someone else since the client loaded it
+			plan.conflicts = append(plan.conflicts, id)
+		default:
+			plan.inserts[id] = item
+		}
+	}
+
+	for id, cur := range stored {
+		if incoming[id] {
+			continue
+		}
+		want, known := expected[id]
+		switch {
+		case !known:
+			// Created by someone else; the client never saw it
+		case want == cur.version:
+			plan.deletes = append(plan.deletes, id)
+		default:
+			plan.conflicts = append(plan.conflicts, id)
+		}
+	}
+
+	return plan, nil
+}
+
+// apply performs the planned writes, bumping the version of updated rows.
+func (p *versionedSave) apply(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID) (events.EntityChanges, error) {
+	changes := events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}}
+
+	if len(p.deletes) > 0 {
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf(`DELETE FROM %s WHERE floor_plan_id = $1 AND id = ANY($2)`, p.tableName),
+			fpID, p.deletes,
+		)
+		if err != nil {
+			return changes, err
+		}
+		changes.Deleted = append(changes.Deleted, p.deletes...)
+	}
+
+	for id, data := range p.inserts {
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s (id, floor_plan_id, data, updated_by) VALUES ($1, $2, $3, $4)`, p.tableName),
+			id, fpID, data, userID,
+		)
+		if err != nil {
+			return changes, err
+		}
+		changes.Upserted = append(changes.Upserted, id)
+	}
+
+	for id, data := range p.updates {
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf(`UPDATE %s SET data = $1, version = version + 1, updated_by = $2, updated_at = NOW()
+				WHERE id = $3 AND floor_plan_id = $4`, p.tableName),
+			data, userID, id, fpID,
+		)
+		if err != nil {
+			return changes, err
+		}
+		changes.Upserted = append(changes.Upserted, id)
+	}
+
+	return changes, nil
+}
+
+// saveVersionedEntities applies a per-entity save of all entity types. If any
+// entity conflicts nothing is written and the conflicts are returned.
+func saveVersionedEntities(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, req *models.BulkSaveRequest) (events.Changes, models.MergeConflicts, error) {
+	var changes events.Changes
+	conflicts := models.MergeConflicts{Tables: []uuid.UUID{}, Guests: []uuid.UUID{}, Labels: []uuid.UUID{}}
+	expected := req.EntityVersions
+
+	kinds := []struct {
+		table    string
+		items    []json.RawMessage
+		expected map[uuid.UUID]int
+		clashes  *[]uuid.UUID
+		changes  *events.EntityChanges
+	}{
+		{"floor_plan_tables", req.Tables, expected.Tables, &conflicts.Tables, &changes.Tables},
+		{"floor_plan_guests", req.Guests, expected.Guests, &conflicts.Guests, &changes.Guests},
+		{"floor_plan_labels", req.Labels, expected.Labels, &conflicts.Labels, &changes.Labels},
+	}
+
+	plans := make([]*versionedSave, len(kinds))
+	for i, k := range kinds {
+		plan, err := planVersionedSave(ctx, tx, k.table, fpID, k.items, k.expected)
+		if err != nil {
+			return changes, conflicts, err
+		}
+		plans[i] = plan
+		*k.clashes = plan.conflicts
+	}
+	if !conflicts.Empty() {
+		return changes, conflicts, nil
+	}
+
+	for i, k := range kinds {
+		c, err := plans[i].apply(ctx, tx, fpID, userID)
+		if err != nil {
+			return changes, conflicts, err
+		}
+		*k.changes = c
+	}
+	return changes, conflicts, nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestPlanVersionedSave(t *testing.T) {
+	kept, edited, stale, removed, foreign, gone, added := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	tx := &mockTx{
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			ids := []uuid.UUID{kept, edited, stale, removed, foreign}
+			items := []json.RawMessage{entity(kept, "K"), entity(edited, "E"), entity(stale, "S2"), entity(removed, "R"), entity(foreign, "F")}
+			versions := []int{1, 2, 2, 1, 1}
+			return &mockRows{n: len(ids), scan: func(i int, dest ...any) error {
+				*dest[0].(*uuid.UUID) = ids[i]
+				*dest[1].(*json.RawMessage) = items[i]
+				*dest[2].(*int) = versions[i]
+				return nil
+			}}, nil
+		},
+	}
+
+	items := []json.RawMessage{
+		entity(kept, "K"),
+		entity(edited, "E2"),
+		entity(stale, "S3"),
+		entity(gone, "G"),
+		entity(added, "A"),
+	}
+	expected := map[uuid.UUID]int{kept: 1, edited: 2, stale: 1, removed: 1, gone: 1}
+
+	plan, err := planVersionedSave(context.Background(), tx, "floor_plan_guests", uuid.New(), items, expected)
+	if err != nil {
+		t.Fatalf("planVersionedSave() error = %v", err)
+	}
+
+	if len(plan.updates) != 1 || plan.updates[edited] == nil {
+		t.Errorf("expected only %s updated, got %v", edited, plan.updates)
+	}
+	if len(plan.inserts) != 1 || plan.inserts[added] == nil {
+		t.Errorf("expected only %s inserted, got %v", added, plan.inserts)
+	}
+	if len(plan.deletes) != 1 || plan.deletes[0] != removed {
+		t.Errorf("expected only %s deleted, got %v", removed, plan.deletes)
+	}
+
+	conflicts := map[uuid.UUID]bool{}
+	for _, id := range plan.conflicts {
+		conflicts[id] = true
+	}
+	if len(conflicts) != 2 || !conflicts[stale] || !conflicts[gone] {
+		t.Errorf("expected conflicts on stale and deleted entities, got %v", plan.conflicts)
+	}
+}
@@ -0,0 +1,830 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/eventsync"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const eventGuestColumns = `id, event_id, name, dietary_restrictions, guest_of, created_at, updated_at`
+
+func scanEventGuest(row pgx.Row, g *models.EventGuest) error {
+	return row.Scan(&g.ID, &g.EventID, &g.Name, &g.DietaryRestrictions, &g.GuestOf, &g.CreatedAt, &g.UpdatedAt)
+}
+
+// loadEventGuests returns an event's guest list.
+func loadEventGuests(ctx context.Context, q rowsQuerier, eventID uuid.UUID) ([]models.EventGuest, error) {
+	rows, err := q.Query(ctx,
+		`SELECT `+eventGuestColumns+` FROM event_guests WHERE event_id = $1 ORDER BY created_at, id`, eventID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guests := []models.EventGuest{}
+	for rows.Next() {
+		var g models.EventGuest
+		if err := scanEventGuest(rows, &g); err != nil {
+			return nil, err
+		}
+		guests = append(guests, g)
+	}
+	return guests, rows.Err()
+}
+
+// ListEvents returns personal events and events of the user's organizations.
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT id, user_id, organization_id, name, created_at, updated_at
+		 FROM events
+		 WHERE user_id = $1
+		    OR organization_id IN (
+			    SELECT organization_id FROM organization_members WHERE user_id = $1
+		    )
+		 ORDER BY updated_at DESC`,
+		userID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Event{}
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.ID, &e.UserID, &e.OrganizationID, &e.Name, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		list = append(list, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, list)
+}
+
+// CreateEvent creates an event, personal or shared with an organization the
+// user is a member of.
+func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.CreateEventRequest](r, w)
+	if !ok {
+		return
+	}
+
+	if req.OrganizationID != nil {
+		canShare, err := h.canShareToOrganization(r.Context(), userID, *req.OrganizationID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !canShare {
+			http.Error(w, `{"error":"not a member of this organization"}`, http.StatusForbidden)
+			return
+		}
+	}
+
+	e := models.Event{UserID: userID, OrganizationID: req.OrganizationID, Name: req.Name}
+	err := h.pool.QueryRow(r.Context(),
+		`INSERT INTO events (user_id, organization_id, name) VALUES ($1, $2, $3)
+		 RETURNING id, created_at, updated_at`,
+		e.UserID, e.OrganizationID, e.Name,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		http.Error(w, `{"error":"failed to create event"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, e)
+}
+
+// GetEvent returns an event with its guest list and the floor plans in it
+// the user can view.
+func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid event ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewEvent(r.Context(), userID, eventID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	var e models.EventFull
+	err = h.pool.QueryRow(r.Context(),
+		`SELECT id, user_id, organization_id, name, created_at, updated_at FROM events WHERE id = $1`, eventID,
+	).Scan(&e.ID, &e.UserID, &e.OrganizationID, &e.Name, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		http.Error(w, `{"error":"event not found"}`, http.StatusNotFound)
+		return
+	}
+
+	if e.Guests, err = loadEventGuests(r.Context(), h.pool, eventID); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT id, name, version FROM floor_plans
+		 WHERE event_id = $1
+		   AND (user_id = $2 OR organization_id IN (
+			   SELECT organization_id FROM organization_members WHERE user_id = $2
+		   ))
+		 ORDER BY name`,
+		eventID, userID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	e.FloorPlans = []models.FloorPlanVersion{}
+	for rows.Next() {
+		var fp models.FloorPlanVersion
+		if err := rows.Scan(&fp.ID, &fp.Name, &fp.Version); err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		e.FloorPlans = append(e.FloorPlans, fp)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, e)
+}
+
+// UpdateEvent renames an event.
+func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid event ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.UpdateEventRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditEvent(r.Context(), userID, eventID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(),
+		`UPDATE events SET name = $1, updated_at = NOW() WHERE id = $2`, req.Name, eventID,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"event not found"}`, http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// DeleteEvent deletes an event and its guest list (creator or organization
+// owner/admin). Its floor plans keep their guests as plain guests.
+func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid event ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.eventRole(r.Context(), userID, eventID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if role != models.RoleOwner && role != models.RoleAdmin {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if !lockEvent(w, r, tx, eventID) {
+		return
+	}
+	_, err = syncEventPlans(r.Context(), tx, eventID, userID, func(tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, bool, error) {
+		guests, changed, err := eventsync.Unlink(guests)
+		return tables, guests, changed, err
+	})
+	if err != nil {
+		http.Error(w, `{"error":"failed to update floor plans"}`, http.StatusInternalServerError)
+		return
+	}
+	if _, err := tx.Exec(r.Context(), `DELETE FROM events WHERE id = $1`, eventID); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateEventGuest adds a guest to an event and to every floor plan in it.
+func (h *Handler) CreateEventGuest(w http.ResponseWriter, r *http.Request) {
+	h.saveEventGuest(w, r, false)
+}
+
+// UpdateEventGuest replaces an event guest's name, dietary restrictions and
+// guestOf, and updates the guest's copy in every floor plan of the event.
+func (h *Handler) UpdateEventGuest(w http.ResponseWriter, r *http.Request) {
+	h.saveEventGuest(w, r, true)
+}
+
+func (h *Handler) saveEventGuest(w http.ResponseWriter, r *http.Request, update bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid event ID"}`, http.StatusBadRequest)
+		return
+	}
+	var guestID uuid.UUID
+	if update {
+		if guestID, err = uuid.Parse(chi.URLParam(r, "guestId")); err != nil {
+			http.Error(w, `{"error":"invalid guest ID"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	req, ok := decodeAndValidate[models.EventGuestRequest](r, w)
+	if !ok {
+		return
+	}
+	if update && req.GuestOf != nil && *req.GuestOf == guestID {
+		http.Error(w, `{"error":"guestOf must not reference the guest itself"}`, http.StatusBadRequest)
+		return
+	}
+
+	canEdit, err := h.canEditEvent(r.Context(), userID, eventID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if !lockEvent(w, r, tx, eventID) {
+		return
+	}
+
+	if req.GuestOf != nil {
+		var exists bool
+		err := tx.QueryRow(r.Context(),
+			`SELECT EXISTS(SELECT 1 FROM event_guests WHERE id = $1 AND event_id = $2)`, *req.GuestOf, eventID,
+		).Scan(&exists)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, `{"error":"guestOf must be a guest of this event"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	dietary, _ := json.Marshal(req.DietaryRestrictions)
+	var g models.EventGuest
+	if update {
+		err = scanEventGuest(tx.QueryRow(r.Context(),
+			`UPDATE event_guests SET name = $1, dietary_restrictions = $2, guest_of = $3, updated_at = NOW()
+			 WHERE id = $4 AND event_id = $5
+			 RETURNING `+eventGuestColumns,
+			req.Name, dietary, req.GuestOf, guestID, eventID,
+		), &g)
+	} else {
+		err = scanEventGuest(tx.QueryRow(r.Context(),
+			`INSERT INTO event_guests (event_id, name, dietary_restrictions, guest_of) VALUES ($1, $2, $3, $4)
+			 RETURNING `+eventGuestColumns,
+			eventID, req.Name, dietary, req.GuestOf,
+		), &g)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"guest not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"failed to save guest"}`, http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	plans, err := syncEventPlans(r.Context(), tx, eventID, userID, func(tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, bool, error) {
+		guests, changed, err := eventsync.Sync(guests, []models.EventGuest{g}, !update, now)
+		return tables, guests, changed, err
+	})
+	if err != nil {
+		http.Error(w, `{"error":"failed to update floor plans"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if !update {
+		status = http.StatusCreated
+	}
+	respondJSON(w, status, models.EventGuestResponse{Guest: &g, FloorPlans: plans})
+}
+
+// DeleteEventGuest removes a guest from an event and from every floor plan in
+// it, freeing the guest's seats.
+func (h *Handler) DeleteEventGuest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid event ID"}`, http.StatusBadRequest)
+		return
+	}
+	guestID, err := uuid.Parse(chi.URLParam(r, "guestId"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid guest ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canEdit, err := h.canEditEvent(r.Context(), userID, eventID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if !lockEvent(w, r, tx, eventID) {
+		return
+	}
+	tag, err := tx.Exec(r.Context(), `DELETE FROM event_guests WHERE id = $1 AND event_id = $2`, guestID, eventID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"guest not found"}`, http.StatusNotFound)
+		return
+	}
+
+	plans, err := syncEventPlans(r.Context(), tx, eventID, userID, func(tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, bool, error) {
+		return eventsync.Remove(tables, guests, []uuid.UUID{guestID})
+	})
+	if err != nil {
+		http.Error(w, `{"error":"failed to update floor plans"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.EventGuestResponse{FloorPlans: plans})
+}
+
+// SetFloorPlanEvent adds a floor plan to an event, copying in the event's
+// guests and updating copies the plan already has, or takes it out of its
+// event. Guests of a previous event stay in the plan as plain guests.
+func (h *Handler) SetFloorPlanEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.SetFloorPlanEventRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	if req.EventID != nil {
+		canEdit, err := h.canEditEvent(r.Context(), userID, *req.EventID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !canEdit {
+			http.Error(w, `{"error":"event not found"}`, http.StatusNotFound)
+			return
+		}
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// Events are locked before their plans, as when guests change
+	var guests []models.EventGuest
+	if req.EventID != nil {
+		if !lockEvent(w, r, tx, *req.EventID) {
+			return
+		}
+		if guests, err = loadEventGuests(r.Context(), tx, *req.EventID); err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var current *uuid.UUID
+	var version int
+	err = tx.QueryRow(r.Context(),
+		`SELECT event_id, version FROM floor_plans WHERE id = $1 FOR UPDATE`, fpID,
+	).Scan(&current, &version)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+	if _, err := tx.Exec(r.Context(),
+		`UPDATE floor_plans SET event_id = $1, updated_at = NOW() WHERE id = $2`, req.EventID, fpID,
+	); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	moved := current != nil && (req.EventID == nil || *current != *req.EventID)
+	now := time.Now()
+	newVersion, changed, err := applyPlanGuestEdit(r.Context(), tx, fpID, userID, func(tables, items []json.RawMessage) ([]json.RawMessage, []json.RawMessage, bool, error) {
+		unlinked := false
+		if moved {
+			var err error
+			if items, unlinked, err = eventsync.Unlink(items); err != nil {
+				return nil, nil, false, err
+			}
+		}
+		items, synced, err := eventsync.Sync(items, guests, true, now)
+		return tables, items, unlinked || synced, err
+	})
+	if errors.Is(err, errInvalidStoredEntities) {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"failed to update guests"}`, http.StatusInternalServerError)
+		return
+	}
+	if changed {
+		version = newVersion
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"status": "updated", "version": version})
+}
+
+// lockEvent locks an event's row so its guest list and floor plans are
+// changed one request at a time, responding 404 if it doesn't exist.
+func lockEvent(w http.ResponseWriter, r *http.Request, tx pgx.Tx, eventID uuid.UUID) bool {
+	var id uuid.UUID
+	err := tx.QueryRow(r.Context(), `SELECT id FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"event not found"}`, http.StatusNotFound)
+		return false
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// planGuestEdit changes the stored tables and guests of a floor plan,
+// reporting whether anything changed.
+type planGuestEdit func(tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, bool, error)
+
+// syncEventPlans applies edit to every floor plan in the event, returning the
+// plans that got a new version. The caller must hold the event row lock.
+func syncEventPlans(ctx context.Context, tx pgx.Tx, eventID, userID uuid.UUID, edit planGuestEdit) ([]models.FloorPlanVersion, error) {
+	return editPlans(ctx, tx, userID, edit,
+		`SELECT id, name FROM floor_plans WHERE event_id = $1 ORDER BY id FOR UPDATE`, eventID,
+	)
+}
+
+// editPlans locks the floor plans selected by query, which returns their IDs
+// and names ordered by ID, and applies edit to each of them. It returns the
+// plans that got a new version.
+func editPlans(ctx context.Context, tx pgx.Tx, userID uuid.UUID, edit planGuestEdit, query string, args ...any) ([]models.FloorPlanVersion, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var plans []models.FloorPlanVersion
+	for rows.Next() {
+		var fp models.FloorPlanVersion
+		if err := rows.Scan(&fp.ID, &fp.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		plans = append(plans, fp)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	updated := []models.FloorPlanVersion{}
+	for _, fp := range plans {
+		version, changed, err := applyPlanGuestEdit(ctx, tx, fp.ID, userID, edit)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			fp.Version = version
+			updated = append(updated, fp)
+		}
+	}
+	return updated, nil
+}
+
+// applyPlanGuestEdit runs edit on a floor plan's tables and guests and saves
+// the result as a new version if anything changed. The caller must hold the
+// floor_plans row lock.
+func applyPlanGuestEdit(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, edit planGuestEdit) (int, bool, error) {
+	tables, err := queryEntityData(ctx, tx, "floor_plan_tables", fpID)
+	if err != nil {
+		return 0, false, err
+	}
+	guests, err := queryEntityData(ctx, tx, "floor_plan_guests", fpID)
+	if err != nil {
+		return 0, false, err
+	}
+	tables, guests, changed, err := edit(tables, guests)
+	if err != nil {
+		return 0, false, errInvalidStoredEntities
+	}
+	if !changed {
+		return 0, false, nil
+	}
+
+	changes := events.Changes{
+		Labels: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+	}
+	if changes.Tables, err = upsertEntities(ctx, tx, "floor_plan_tables", fpID, userID, tables); err != nil {
+		return 0, false, err
+	}
+	if changes.Guests, err = upsertEntities(ctx, tx, "floor_plan_guests", fpID, userID, guests); err != nil {
+		return 0, false, err
+	}
+	version, err := commitVersion(ctx, tx, fpID, userID, changes, nil)
+	return version, true, err
+}
+
+// errEventGuestLink is a floor plan guest linked to an event guest the plan's
+// event doesn't have, or to one another guest of the plan is already a copy of.
+var errEventGuestLink = errors.New("invalid event guest link")
+
+// enforceEventGuests puts the event's name, dietary restrictions and guestOf
+// back on a floor plan's copies of event guests after an edit, since the
+// event owns them and the next sync would undo the edit anyway. It records
+// the guests it rewrote in changes and reports whether there were any. The
+// caller must hold the floor_plans row lock.
+func enforceEventGuests(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, changes *events.EntityChanges) (bool, error) {
+	items, err := queryEntityData(ctx, tx, "floor_plan_guests", fpID)
+	if err != nil {
+		return false, err
+	}
+	var linked []json.RawMessage
+	var copies []models.Guest
+	copyOf := map[string]string{}
+	for _, item := range items {
+		var g models.Guest
+		if err := json.Unmarshal(item, &g); err != nil {
+			return false, errInvalidStoredEntities
+		}
+		if g.EventGuestID == nil {
+			continue
+		}
+		if other, ok := copyOf[*g.EventGuestID]; ok {
+			return false, fmt.Errorf("guests %s and %s are copies of the same event guest: %w", other, g.ID, errEventGuestLink)
+		}
+		copyOf[*g.EventGuestID] = g.ID
+		linked = append(linked, item)
+		copies = append(copies, g)
+	}
+	if len(linked) == 0 {
+		return false, nil
+	}
+
+	var eventID *uuid.UUID
+	if err := tx.QueryRow(ctx, `SELECT event_id FROM floor_plans WHERE id = $1`, fpID).Scan(&eventID); err != nil {
+		return false, err
+	}
+	var eventGuests []models.EventGuest
+	if eventID != nil {
+		if eventGuests, err = loadEventGuests(ctx, tx, *eventID); err != nil {
+			return false, err
+		}
+	}
+	known := make(map[string]bool, len(eventGuests))
+	for _, eg := range eventGuests {
+		known[eg.ID.String()] = true
+	}
+	for _, g := range copies {
+		if !known[*g.EventGuestID] {
+			return false, fmt.Errorf("guest %s is linked to event guest %s, which is not on the event's guest list: %w", g.ID, *g.EventGuestID, errEventGuestLink)
+		}
+	}
+
+	synced, _, err := eventsync.Sync(linked, eventGuests, false, time.Now())
+	if err != nil {
+		return false, errInvalidStoredEntities
+	}
+	enforced := false
+	for i, item := range synced {
+		if jsonEqual(item, linked[i]) {
+			continue
+		}
+		id := extractID(item)
+		_, err := tx.Exec(ctx,
+			`UPDATE floor_plan_guests SET data = $1, version = version + 1, updated_by = $2, updated_at = NOW()
+			 WHERE id = $3 AND floor_plan_id = $4`,
+			item, userID, id, fpID,
+		)
+		if err != nil {
+			return false, err
+		}
+		changes.Upserted = appendNew(changes.Upserted, id)
+		enforced = true
+	}
+	return enforced, nil
+}
+
+// dropStaleEventLinks fits a past state of a plan's guests to the event as it
+// is now. Without an event every link is turned into a plain guest; with one,
+// copies of guests the event no longer has are removed as they were when the
+// event guest was deleted.
+func dropStaleEventLinks(ctx context.Context, tx pgx.Tx, eventID *uuid.UUID, tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, error) {
+	if eventID == nil {
+		unlinked, _, err := eventsync.Unlink(guests)
+		if err != nil {
+			return nil, nil, errInvalidStoredEntities
+		}
+		return tables, unlinked, nil
+	}
+
+	eventGuests, err := loadEventGuests(ctx, tx, *eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	known := make(map[uuid.UUID]bool, len(eventGuests))
+	for _, eg := range eventGuests {
+		known[eg.ID] = true
+	}
+	var gone []uuid.UUID
+	for _, item := range guests {
+		var g models.Guest
+		if err := json.Unmarshal(item, &g); err != nil {
+			return nil, nil, errInvalidStoredEntities
+		}
+		if g.EventGuestID == nil {
+			continue
+		}
+		id, err := uuid.Parse(*g.EventGuestID)
+		if err != nil {
+			return nil, nil, errInvalidStoredEntities
+		}
+		if !known[id] {
+			gone = append(gone, id)
+		}
+	}
+	if len(gone) == 0 {
+		return tables, guests, nil
+	}
+	if tables, guests, _, err = eventsync.Remove(tables, guests, gone); err != nil {
+		return nil, nil, errInvalidStoredEntities
+	}
+	return tables, guests, nil
+}
+
+// respondEventGuestError reports an enforceEventGuests failure.
+func respondEventGuestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errEventGuestLink):
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, errInvalidStoredEntities):
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCreateEventGuest_PropagatesToPlans(t *testing.T) {
+	userID := uuid.New()
+	eventID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	var saved []string
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						switch {
+						case strings.Contains(sql, "INSERT INTO event_guests"):
+							*dest[0].(*uuid.UUID) = guestID
+							*dest[1].(*uuid.UUID) = eventID
+							*dest[2].(*string) = args[1].(string)
+							*dest[3].(*[]models.DietaryRestriction) = []models.DietaryRestriction{models.DietaryVegan}
+							*dest[5].(*time.Time) = time.Now()
+						case strings.Contains(sql, "UPDATE floor_plans"):
+							*dest[0].(*int) = 4
+						default:
+							*dest[0].(*uuid.UUID) = eventID
+						}
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					switch {
+					case strings.Contains(sql, "WHERE event_id"):
+						return &mockRows{n: 1, scan: func(i int, dest ...any) error {
+							*dest[0].(*uuid.UUID) = fpID
+							*dest[1].(*string) = "Reception"
+							return nil
+						}}, nil
+					case strings.Contains(sql, "SELECT data FROM floor_plan_guests"):
+						return dataRows(
+							json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Walk-in","dietaryRestrictions":[]}`),
+						), nil
+					case strings.Contains(sql, "SELECT data"):
+						return dataRows(), nil
+					}
+					return &mockRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					if strings.Contains(sql, "INSERT INTO floor_plan_guests") {
+						saved = append(saved, string(args[2].(json.RawMessage)))
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"name":"Alice","dietaryRestrictions":["VEGAN"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/events/"+eventID.String()+"/guests", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", eventID.String())
+	w := httptest.NewRecorder()
+
+	h.CreateEventGuest(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.EventGuestResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Guest == nil || resp.Guest.ID != guestID {
+		t.Fatalf("expected the new guest in the response, got %s", w.Body.String())
+	}
+	if len(resp.FloorPlans) != 1 || resp.FloorPlans[0].ID != fpID || resp.FloorPlans[0].Version != 4 {
+		t.Fatalf("expected the plan's new version, got %s", w.Body.String())
+	}
+
+	if len(saved) != 2 {
+		t.Fatalf("expected the plan's guest and the new copy to be saved, got %v", saved)
+	}
+	var copied models.Guest
+	json.Unmarshal([]byte(saved[1]), &copied)
+	if copied.Name != "Alice" || copied.EventGuestID == nil || *copied.EventGuestID != guestID.String() {
+		t.Errorf("expected a copy linked to the event guest, got %s", saved[1])
+	}
+	if len(copied.DietaryRestrictions) != 1 || copied.DietaryRestrictions[0] != models.DietaryVegan {
+		t.Errorf("expected the dietary restrictions to be copied, got %s", saved[1])
+	}
+}
+
+func TestSetFloorPlanEvent_EventNotEditable(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	eventID := uuid.New()
+
+	began := false
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				if strings.Contains(sql, "FROM events") {
+					// Someone else's personal event
+					*dest[0].(*uuid.UUID) = uuid.New()
+					return nil
+				}
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			began = true
+			return &mockTx{}, nil
+		},
+	})
+
+	body := `{"eventId":"` + eventID.String() + `"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/event", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.SetFloorPlanEvent(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if began {
+		t.Error("expected nothing to be changed")
+	}
+}
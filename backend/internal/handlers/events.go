@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const eventKeepAliveInterval = 25 * time.Second
+
+// eventAccessCheckInterval is how often a stream checks that its user may
+// still view the plan, so unsharing the plan cuts off its subscribers.
+var eventAccessCheckInterval = time.Minute
+
+// StreamEvents streams save and presence events for a floor plan as Server-Sent Events.
+// The stream ends when the client disconnects or the server shuts down. It
+// needs the Authorization header like any other API route, which EventSource
+// can't send, so browsers read it with fetch (subscribeFloorPlanEvents in the
+// frontend's api.ts). It also ends once the user loses access to the plan.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	if h.events == nil {
+		http.Error(w, `{"error":"event stream unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	// The server's write timeout would otherwise cut the stream off
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	ch, unsubscribe := h.events.Subscribe(fpID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+	accessCheck := time.NewTicker(eventAccessCheckInterval)
+	defer accessCheck.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-accessCheck.C:
+			// A failed check keeps the stream; the next one decides
+			if canView, err := h.canViewFloorPlan(r.Context(), userID, fpID); err == nil && !canView {
+				return
+			}
+			continue
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeSubscriber hands out a pre-filled channel for every subscription.
+type fakeSubscriber struct {
+	ch           chan events.Event
+	subscribedTo uuid.UUID
+	unsubscribed bool
+}
+
+func (f *fakeSubscriber) Subscribe(floorPlanID uuid.UUID) (<-chan events.Event, func()) {
+	f.subscribedTo = floorPlanID
+	return f.ch, func() { f.unsubscribed = true }
+}
+
+func viewerDB(userID uuid.UUID) *mockDB {
+	return &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					if p, ok := dest[0].(*uuid.UUID); ok {
+						*p = userID
+					}
+					return nil
+				},
+			}
+		},
+	}
+}
+
+func TestStreamEvents_Unauthorized(t *testing.T) {
+	h := New(&mockDB{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/abc/events", nil)
+	w := httptest.NewRecorder()
+	h.StreamEvents(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestStreamEvents_Unavailable(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	h := New(viewerDB(userID))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/events", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+	h.StreamEvents(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestStreamEvents_WritesEvents(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	sub := &fakeSubscriber{ch: make(chan events.Event, 2)}
+	sub.ch <- events.Event{Type: events.TypeSaved, FloorPlanID: fpID, Version: 7}
+	close(sub.ch)
+
+	h := New(viewerDB(userID)).WithEvents(sub)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/events", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+	h.StreamEvents(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "event: saved\n") || !strings.Contains(body, `"version":7`) {
+		t.Errorf("expected saved event in stream, got %q", body)
+	}
+	if sub.subscribedTo != fpID {
+		t.Errorf("expected subscription to %s, got %s", fpID, sub.subscribedTo)
+	}
+	if !sub.unsubscribed {
+		t.Error("expected unsubscribe when stream ends")
+	}
+}
+
+func TestLeavePresence_PublishesEvent(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	var notified []string
+	h := New(&mockDB{
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			if strings.Contains(sql, "pg_notify") {
+				notified = append(notified, args[1].(string))
+				return pgconn.NewCommandTag("SELECT 1"), nil
+			}
+			return pgconn.NewCommandTag("DELETE 1"), nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/floor-plans/"+fpID.String()+"/presence", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+	h.LeavePresence(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if len(notified) != 1 || !strings.Contains(notified[0], events.TypePresenceLeft) {
+		t.Errorf("expected one presence.left notification, got %v", notified)
+	}
+}
+
+func TestStreamEvents_EndsWhenAccessRevoked(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	defer func(d time.Duration) { eventAccessCheckInterval = d }(eventAccessCheckInterval)
+	eventAccessCheckInterval = time.Millisecond
+
+	// The plan is visible when the stream opens and gone by the first check
+	checks := 0
+	db := &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			checks++
+			if checks > 1 {
+				return &mockRow{err: pgx.ErrNoRows}
+			}
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+	}
+	sub := &fakeSubscriber{ch: make(chan events.Event)}
+	h := New(db).WithEvents(sub)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/events", nil)
+	ctx, cancel := context.WithTimeout(withUserID(req.Context(), userID), 5*time.Second)
+	defer cancel()
+	req = withChiParam(req.WithContext(ctx), "id", fpID.String())
+	w := httptest.NewRecorder()
+	h.StreamEvents(w, req)
+
+	if ctx.Err() != nil {
+		t.Fatal("expected the stream to end once access was revoked")
+	}
+	if !sub.unsubscribed {
+		t.Error("expected unsubscribe when stream ends")
+	}
+}
+
+func TestExpirePresence_PublishesLeft(t *testing.T) {
+	fpID, userID := uuid.New(), uuid.New()
+
+	var notified []string
+	h := New(&mockDB{
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			if !strings.Contains(sql, "DELETE FROM floor_plan_presence") {
+				t.Errorf("unexpected query: %s", sql)
+			}
+			return &mockRows{rows: [][]any{{fpID, userID, "ann@example.com"}}}, nil
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			notified = append(notified, args[1].(string))
+			return pgconn.NewCommandTag("SELECT 1"), nil
+		},
+	})
+
+	if err := h.expirePresence(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(notified) != 1 || !strings.Contains(notified[0], events.TypePresenceLeft) || !strings.Contains(notified[0], userID.String()) {
+		t.Errorf("expected one presence.left notification for the user, got %v", notified)
+	}
+}
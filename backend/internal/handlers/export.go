@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/frallan97/table-planner-backend/internal/export"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ExportXLSX downloads the plan's seating as an Excel workbook.
+func (h *Handler) ExportXLSX(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	plan, err := h.loadExportPlan(r.Context(), fpID, models.ShareScopeFull)
+	if err != nil {
+		respondExportError(w, err)
+		return
+	}
+
+	sendFile(w, "attachment", export.ContentTypeXLSX, export.Filename(plan.Name, "xlsx"), func(out io.Writer) error {
+		return export.WriteXLSX(out, plan)
+	})
+}
+
+// ExportPDF downloads printable layouts of the plan as a PDF. The layout query
+// parameter is a comma separated list of layouts, rendered in order.
+func (h *Handler) ExportPDF(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parsePDFOptions(r)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	h.sendPDF(w, r, fpID, models.ShareScopeFull, opts)
+}
+
+// ExportPDFByShareToken is ExportPDF for a public share link (no auth required).
+func (h *Handler) ExportPDFByShareToken(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		http.Error(w, `{"error":"missing token"}`, http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parsePDFOptions(r)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	fpID, scope, err := h.sharedFloorPlan(r.Context(), token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired share link"}`, http.StatusNotFound)
+		return
+	}
+
+	h.sendPDF(w, r, fpID, scope, opts)
+}
+
+func (h *Handler) sendPDF(w http.ResponseWriter, r *http.Request, fpID uuid.UUID, scope models.ShareScope, opts export.PDFOptions) {
+	plan, err := h.loadExportPlan(r.Context(), fpID, scope)
+	if err != nil {
+		respondExportError(w, err)
+		return
+	}
+
+	sendFile(w, "attachment", export.ContentTypePDF, export.Filename(plan.Name, "pdf"), func(out io.Writer) error {
+		return export.WritePDF(out, plan, opts)
+	})
+}
+
+// parsePDFOptions reads the layout and pageSize query parameters, defaulting
+// to the floor plan on A4. Each layout may be listed once.
+func parsePDFOptions(r *http.Request) (export.PDFOptions, error) {
+	opts := export.PDFOptions{PageSize: "a4"}
+	if size := r.URL.Query().Get("pageSize"); size != "" {
+		if !export.ValidPageSize(size) {
+			return opts, errors.New("pageSize must be a4 or letter")
+		}
+		opts.PageSize = size
+	}
+
+	layouts := r.URL.Query().Get("layout")
+	if layouts == "" {
+		layouts = export.LayoutFloorPlan
+	}
+	for _, layout := range strings.Split(layouts, ",") {
+		layout = strings.TrimSpace(layout)
+		if !export.ValidLayout(layout) {
+			return opts, errors.New("layout must be floor-plan, escort-cards or place-cards")
+		}
+		if slices.Contains(opts.Layouts, layout) {
+			return opts, fmt.Errorf("layout %s is listed more than once", layout)
+		}
+		opts.Layouts = append(opts.Layouts, layout)
+	}
+	return opts, nil
+}
+
+// imageFormat is a format floor plan images are rendered in.
+type imageFormat struct {
+	ext         string
+	contentType string
+	write       func(io.Writer, *export.Plan, export.ImageOptions) error
+}
+
+var (
+	svgImage = imageFormat{"svg", export.ContentTypeSVG, export.WriteSVG}
+	pngImage = imageFormat{"png", export.ContentTypePNG, export.WritePNG}
+)
+
+// ExportSVG renders the plan as an SVG image.
+func (h *Handler) ExportSVG(w http.ResponseWriter, r *http.Request) {
+	h.exportImage(w, r, svgImage)
+}
+
+// ExportPNG renders the plan as a PNG image.
+func (h *Handler) ExportPNG(w http.ResponseWriter, r *http.Request) {
+	h.exportImage(w, r, pngImage)
+}
+
+// ExportSVGByShareToken is ExportSVG for a public share link (no auth required).
+func (h *Handler) ExportSVGByShareToken(w http.ResponseWriter, r *http.Request) {
+	h.exportImageByShareToken(w, r, svgImage)
+}
+
+// ExportPNGByShareToken is ExportPNG for a public share link (no auth required).
+func (h *Handler) ExportPNGByShareToken(w http.ResponseWriter, r *http.Request) {
+	h.exportImageByShareToken(w, r, pngImage)
+}
+
+func (h *Handler) exportImage(w http.ResponseWriter, r *http.Request, format imageFormat) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseImageOptions(r, export.MaxImageWidth)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	h.sendImage(w, r, fpID, models.ShareScopeFull, format, opts)
+}
+
+func (h *Handler) exportImageByShareToken(w http.ResponseWriter, r *http.Request, format imageFormat) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		http.Error(w, `{"error":"missing token"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Anyone with the link can ask, so keep images smaller
+	opts, err := parseImageOptions(r, export.MaxSharedImageWidth)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	fpID, scope, err := h.sharedFloorPlan(r.Context(), token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired share link"}`, http.StatusNotFound)
+		return
+	}
+
+	h.sendImage(w, r, fpID, scope, format, opts)
+}
+
+func (h *Handler) sendImage(w http.ResponseWriter, r *http.Request, fpID uuid.UUID, scope models.ShareScope, format imageFormat, opts export.ImageOptions) {
+	plan, err := h.loadExportPlan(r.Context(), fpID, scope)
+	if err != nil {
+		respondExportError(w, err)
+		return
+	}
+
+	sendFile(w, "inline", format.contentType, export.Filename(plan.Name, format.ext), func(out io.Writer) error {
+		return format.write(out, plan, opts)
+	})
+}
+
+// parseImageOptions reads the width and guestNames query parameters, with
+// neither side of the image larger than maxSize. Guest names are drawn
+// unless guestNames=false.
+func parseImageOptions(r *http.Request, maxSize int) (export.ImageOptions, error) {
+	opts := export.ImageOptions{Width: min(export.DefaultImageWidth, maxSize), GuestNames: true, MaxSize: maxSize}
+	if v := r.URL.Query().Get("width"); v != "" {
+		width, err := strconv.Atoi(v)
+		if err != nil || width < 1 || width > maxSize {
+			return opts, fmt.Errorf("width must be between 1 and %d", maxSize)
+		}
+		opts.Width = width
+	}
+	if v := r.URL.Query().Get("guestNames"); v != "" {
+		names, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, errors.New("guestNames must be true or false")
+		}
+		opts.GuestNames = names
+	}
+	return opts, nil
+}
+
+// loadExportPlan loads a plan's name, room and decoded entities, as
+// GetFloorPlan returns them, redacted to what scope shows.
+func (h *Handler) loadExportPlan(ctx context.Context, fpID uuid.UUID, scope models.ShareScope) (*export.Plan, error) {
+	var name string
+	var roomID *uuid.UUID
+	err := h.pool.QueryRow(ctx, `SELECT name, room_id FROM floor_plans WHERE id = $1`, fpID).Scan(&name, &roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := h.getEntityData(ctx, "floor_plan_tables", fpID)
+	if err != nil {
+		return nil, err
+	}
+	guests, err := h.getEntityData(ctx, "floor_plan_guests", fpID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := h.getEntityData(ctx, "floor_plan_labels", fpID)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, guests, err = redactShared(scope, tables, guests)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := export.DecodePlan(name, tables, guests, labels)
+	if err != nil {
+		return nil, errInvalidStoredEntities
+	}
+	if roomID != nil {
+		room, err := h.loadRoom(ctx, *roomID)
+		if err != nil {
+			return nil, err
+		}
+		plan.Room = &room.RoomGeometry
+	}
+	return plan, nil
+}
+
+func respondExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+	case errors.Is(err, errInvalidStoredEntities):
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+	}
+}
+
+// sendFile renders a file into memory first, so a failure can still be
+// reported as an error response. disposition is "attachment" for downloads
+// or "inline" for files meant to be displayed or embedded.
+func sendFile(w http.ResponseWriter, disposition, contentType, filename string, write func(io.Writer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		http.Error(w, `{"error":"failed to generate export"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
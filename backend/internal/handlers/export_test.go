@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// exportDB serves a plan owned by owner with the given guests.
+func exportDB(owner uuid.UUID, guests ...json.RawMessage) *mockDB {
+	return &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				switch {
+				case strings.Contains(sql, "SELECT user_id, organization_id"):
+					*dest[0].(*uuid.UUID) = owner
+				case strings.Contains(sql, "SELECT name, room_id FROM floor_plans"):
+					*dest[0].(*string) = "Gala"
+				case strings.Contains(sql, "FROM floor_plan_share_tokens"):
+					if args[0] != "valid-token" {
+						return pgx.ErrNoRows
+					}
+					*dest[0].(*uuid.UUID) = uuid.New()
+					*dest[1].(*models.ShareScope) = models.ShareScopeNames
+				}
+				return nil
+			}}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			if strings.Contains(sql, "floor_plan_guests") {
+				return dataRows(guests...), nil
+			}
+			return &emptyRows{}, nil
+		},
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	h := New(exportDB(userID, json.RawMessage(`{"id":"`+uuid.NewString()+`","name":"Alice","dietaryRestrictions":[]}`)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/export.xlsx", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.ExportXLSX(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/vnd.openxmlformats") {
+		t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=Gala.xlsx` {
+		t.Errorf("unexpected disposition %q", got)
+	}
+	if !strings.HasPrefix(w.Body.String(), "PK") {
+		t.Error("expected a zip archive")
+	}
+}
+
+func TestExportXLSX_Forbidden(t *testing.T) {
+	fpID := uuid.New()
+	h := New(exportDB(uuid.New()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/export.xlsx", nil)
+	req = req.WithContext(withUserID(req.Context(), uuid.New()))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.ExportXLSX(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestExportPDF_ByShareToken(t *testing.T) {
+	h := New(exportDB(uuid.New(), json.RawMessage(`{"id":"`+uuid.NewString()+`","name":"Alice","dietaryRestrictions":[]}`)))
+
+	req := httptest.NewRequest(http.MethodGet, "/public/floor-plans/valid-token/export.pdf?layout=floor-plan,escort-cards&pageSize=letter", nil)
+	req = withChiParam(req, "token", "valid-token")
+	w := httptest.NewRecorder()
+
+	h.ExportPDFByShareToken(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("unexpected content type %q", got)
+	}
+	if !strings.HasPrefix(w.Body.String(), "%PDF-") {
+		t.Error("expected a PDF document")
+	}
+
+	req = withChiParam(httptest.NewRequest(http.MethodGet, "/public/floor-plans/revoked/export.pdf", nil), "token", "revoked")
+	w = httptest.NewRecorder()
+	h.ExportPDFByShareToken(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown token, got %d", w.Code)
+	}
+}
+
+func TestExportPDF_InvalidOptions(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	h := New(exportDB(userID))
+
+	for _, query := range []string{"layout=seating", "layout=floor-plan,", "layout=place-cards,place-cards", "pageSize=a3"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/export.pdf?"+query, nil)
+		req = req.WithContext(withUserID(req.Context(), userID))
+		req = withChiParam(req, "id", fpID.String())
+		w := httptest.NewRecorder()
+
+		h.ExportPDF(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestExportPNG(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	h := New(exportDB(userID))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/export.png?width=300", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.ExportPNG(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `inline; filename=Gala.png` {
+		t.Errorf("unexpected disposition %q", got)
+	}
+	if !strings.HasPrefix(w.Body.String(), "\x89PNG") {
+		t.Error("expected a PNG image")
+	}
+}
+
+func TestExportSVGByShareToken(t *testing.T) {
+	h := New(exportDB(uuid.New()))
+
+	req := httptest.NewRequest(http.MethodGet, "/public/floor-plans/valid-token/export.svg?guestNames=false", nil)
+	req = withChiParam(req, "token", "valid-token")
+	w := httptest.NewRecorder()
+
+	h.ExportSVGByShareToken(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "image/svg+xml" {
+		t.Errorf("unexpected content type %q", got)
+	}
+
+	for _, query := range []string{"width=0", "width=abc", "width=4000", "guestNames=maybe"} {
+		req := httptest.NewRequest(http.MethodGet, "/public/floor-plans/valid-token/export.svg?"+query, nil)
+		req = withChiParam(req, "token", "valid-token")
+		w := httptest.NewRecorder()
+
+		h.ExportSVGByShareToken(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -65,6 +65,11 @@ func (h *Handler) CreateFloorPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.TemplateID != nil {
+		h.createFloorPlanFromTemplate(w, r, userID, req)
+		return
+	}
+
 	if req.Name == "" {
 		req.Name = "Untitled Floor Plan"
 	}
@@ -77,8 +82,13 @@ func (h *Handler) CreateFloorPlan(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt: time.Now(),
 	}
 
+	// Record the empty initial state as the first revision
 	_, err := h.pool.Exec(r.Context(),
-		`INSERT INTO floor_plans (id, user_id, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`,
+		`WITH fp AS (
+			INSERT INTO floor_plans (id, user_id, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, user_id, version
+		 )
+		 INSERT INTO floor_plan_revisions (floor_plan_id, version, created_by) SELECT id, version, user_id FROM fp`,
 		fp.ID, fp.UserID, fp.Name, fp.CreatedAt, fp.UpdatedAt,
 	)
 	if err != nil {
@@ -116,12 +126,12 @@ func (h *Handler) GetFloorPlan(w http.ResponseWriter, r *http.Request) {
 	var fp models.FloorPlan
 	var orgName *string
 	err = h.pool.QueryRow(r.Context(),
-		`SELECT fp.id, fp.user_id, fp.name, fp.version, fp.organization_id, fp.created_at, fp.updated_at, o.name
+		`SELECT fp.id, fp.user_id, fp.name, fp.version, fp.organization_id, fp.created_at, fp.updated_at, o.name, fp.room_id, fp.event_id
 		 FROM floor_plans fp
 		 LEFT JOIN organizations o ON fp.organization_id = o.id
 		 WHERE fp.id = $1`,
 		fpID,
-	).Scan(&fp.ID, &fp.UserID, &fp.Name, &fp.Version, &fp.OrganizationID, &fp.CreatedAt, &fp.UpdatedAt, &orgName)
+	).Scan(&fp.ID, &fp.UserID, &fp.Name, &fp.Version, &fp.OrganizationID, &fp.CreatedAt, &fp.UpdatedAt, &orgName, &fp.RoomID, &fp.EventID)
 	if err != nil {
 		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
 		return
@@ -145,6 +155,12 @@ func (h *Handler) GetFloorPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	entityVersions, err := loadEntityVersions(r.Context(), h.pool, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
 	// Get presence info (other active editors)
 	presence, err := h.getActivePresence(r.Context(), fpID, userID)
 	if err != nil {
@@ -152,13 +168,24 @@ func (h *Handler) GetFloorPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The room comes with the plan, even for users who can't open its venue
+	var room *models.Room
+	if fp.RoomID != nil {
+		if room, err = h.loadRoom(r.Context(), *fp.RoomID); err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	result := models.FloorPlanFull{
 		FloorPlan:        fp,
 		Tables:           tables,
 		Guests:           guests,
 		Labels:           labels,
+		EntityVersions:   entityVersions,
 		Presence:         presence,
 		OrganizationName: orgName,
+		Room:             room,
 	}
 
 	respondJSON(w, http.StatusOK, result)
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/guestimport"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ImportGuests adds guests from a CSV guest list to the stored plan. Likely
+// duplicates of existing guests are reported and skipped by default. Like
+// AutoAssign it returns a preview unless asked to commit.
+func (h *Handler) ImportGuests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.GuestImportRequest](r, w)
+	if !ok {
+		return
+	}
+
+	var allowed bool
+	if req.Commit {
+		allowed, err = h.canEditFloorPlan(r.Context(), userID, fpID)
+	} else {
+		allowed, err = h.canViewFloorPlan(r.Context(), userID, fpID)
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	query := `SELECT version FROM floor_plans WHERE id = $1`
+	if req.Commit {
+		query += ` FOR UPDATE`
+	}
+	var dbVersion int
+	err = tx.QueryRow(r.Context(), query, fpID).Scan(&dbVersion)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+	if req.Version != nil && *req.Version != dbVersion {
+		h.respondVersionConflict(w, r, fpID, dbVersion)
+		return
+	}
+
+	plan, err := loadSeatingPlan(r.Context(), tx, fpID)
+	if errors.Is(err, errInvalidStoredEntities) {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	result, err := guestimport.Parse(req.CSV, guestimport.Options{
+		Columns:           req.Columns,
+		Delimiter:         []rune(req.Delimiter)[0],
+		Existing:          plan.guests,
+		Tables:            plan.tables,
+		IncludeDuplicates: req.IncludeDuplicates,
+		Now:               time.Now(),
+	})
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(plan.guests)+result.Imported > models.MaxPlanEntities {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("import would exceed the maximum of %d guests", models.MaxPlanEntities),
+		})
+		return
+	}
+
+	newGuests := make([]json.RawMessage, len(result.Guests))
+	for i, g := range result.Guests {
+		if newGuests[i], err = json.Marshal(g); err != nil {
+			http.Error(w, `{"error":"failed to import guests"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Reconciling seats the imported guests' table assignments and drops those
+	// that don't fit. It also repairs existing guests with a broken seating,
+	// which the preview lists so committing changes nothing unannounced.
+	reconciled, err := seating.Reconcile(plan.tableItems, append(plan.guestItems, newGuests...))
+	if err != nil {
+		http.Error(w, `{"error":"failed to import guests"}`, http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GuestImportResponse{
+		Status:     "preview",
+		Version:    dbVersion,
+		Imported:   result.Imported,
+		Duplicates: result.Duplicates,
+		Invalid:    result.Invalid,
+		Rows:       result.Rows,
+		Guests:     reconciled.Guests[len(plan.guestItems):],
+		Issues:     reconciled.Issues,
+	}
+	for i, g := range reconciled.Guests[:len(plan.guestItems)] {
+		if !jsonEqual(g, plan.guestItems[i]) {
+			resp.Repaired = append(resp.Repaired, g)
+		}
+	}
+
+	if !req.Commit {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if result.Imported > 0 {
+		if resp.Version, err = saveSeating(r.Context(), tx, fpID, userID, reconciled.Tables, reconciled.Guests); err != nil {
+			http.Error(w, `{"error":"failed to save guests"}`, http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp.Status = "imported"
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestImportGuests_InvalidRequest(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	h := New(&mockDB{})
+
+	for _, body := range []string{`{"csv":""}`, `{"csv":"name\nA","delimiter":"::"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/guests/import", strings.NewReader(body))
+		req = req.WithContext(withUserID(req.Context(), userID))
+		req = withChiParam(req, "id", fpID.String())
+		w := httptest.NewRecorder()
+
+		h.ImportGuests(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestImportGuests_RepairedExisting(t *testing.T) {
+	tables, guests, _ := seatingPlanItems()
+	// The first guest sits on a table that no longer exists
+	guests[0] = json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"GA","dietaryRestrictions":[],"assignedTableId":"` + uuid.NewString() + `","seatPosition":0}`)
+	st := &seatingTest{role: models.RoleViewer, tables: tables, guests: guests}
+	h := New(st.db())
+
+	body := `{"csv":"name\nZed","columns":{"name":"name"}}`
+	w := httptest.NewRecorder()
+	h.ImportGuests(w, seatingRequest(withUserID(context.Background(), uuid.New()), "/guests/import", uuid.New(), body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.GuestImportResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Imported != 1 || len(resp.Guests) != 1 {
+		t.Fatalf("expected one guest imported, got %s", w.Body.String())
+	}
+	if len(resp.Repaired) != 1 {
+		t.Fatalf("expected the existing guest listed as repaired, got %s", w.Body.String())
+	}
+	var repaired models.Guest
+	json.Unmarshal(resp.Repaired[0], &repaired)
+	if repaired.Name != "GA" || repaired.AssignedTableID != nil {
+		t.Errorf("expected GA unseated, got %s", resp.Repaired[0])
+	}
+}
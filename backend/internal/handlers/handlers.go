@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
@@ -20,14 +23,27 @@ type DB interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
+// EventSubscriber delivers floor plan events to a stream.
+// *events.Broker satisfies this interface.
+type EventSubscriber interface {
+	Subscribe(floorPlanID uuid.UUID) (<-chan events.Event, func())
+}
+
 type Handler struct {
-	pool DB
+	pool   DB
+	events EventSubscriber
 }
 
 func New(pool DB) *Handler {
 	return &Handler{pool: pool}
 }
 
+// WithEvents enables the floor plan event stream endpoint.
+func (h *Handler) WithEvents(sub EventSubscriber) *Handler {
+	h.events = sub
+	return h
+}
+
 func decodeJSON(r *http.Request, dst any) error {
 	r.Body = http.MaxBytesReader(nil, r.Body, maxBodySize)
 	dec := json.NewDecoder(r.Body)
@@ -42,6 +58,14 @@ func respondJSON(w http.ResponseWriter, status int, data any) {
 	}
 }
 
+// respondFieldErrors reports entity validation failures with their field paths.
+func respondFieldErrors(w http.ResponseWriter, errs models.FieldErrors) {
+	respondJSON(w, http.StatusBadRequest, map[string]any{
+		"error":  "invalid floor plan data",
+		"fields": errs,
+	})
+}
+
 // Validatable is implemented by request types that can self-validate.
 type Validatable interface {
 	Validate() error
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/frallan97/table-planner-backend/internal/geometry"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ValidateLayout checks the stored table positions for overlapping tables,
+// tables outside the plan's room or on its obstacles, and narrow aisles.
+func (h *Handler) ValidateLayout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.ValidateLayoutRequest](r, w)
+	if !ok {
+		return
+	}
+
+	var roomID *uuid.UUID
+	err = h.pool.QueryRow(r.Context(), `SELECT room_id FROM floor_plans WHERE id = $1`, fpID).Scan(&roomID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	room, err := loadRoomGeometry(r.Context(), h.pool, roomID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	items, err := h.getEntityData(r.Context(), "floor_plan_tables", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	tables, err := decodeTables(items)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	opts := geometry.DefaultOptions(room)
+	if req.MinAisle != nil {
+		opts.MinAisle = *req.MinAisle
+	}
+	issues := geometry.Check(tables, room, opts)
+
+	resp := models.ValidateLayoutResponse{Valid: len(issues) == 0, MinAisle: opts.MinAisle, Issues: issues}
+	if room != nil {
+		resp.Units = room.Units
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// AutoLayout arranges the stored tables in a grid, in banquet rows or in rings
+// around the stage of the plan's room. The positions are returned as a preview
+// unless the request asks to commit them, in which case they are saved as a
+// new version.
+func (h *Handler) AutoLayout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.AutoLayoutRequest](r, w)
+	if !ok {
+		return
+	}
+
+	job := h.beginPlanJob(w, r, userID, fpID, req.Commit, req.Version)
+	if job == nil {
+		return
+	}
+	defer job.tx.Rollback(r.Context())
+
+	items, err := queryEntityData(r.Context(), job.tx, "floor_plan_tables", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	tables, err := decodeTables(items)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	room, err := loadRoomGeometry(r.Context(), job.tx, job.roomID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	opts := geometry.ArrangeOptions{Style: req.Style, Spacing: geometry.DefaultOptions(room).MinAisle}
+	if req.Spacing != nil {
+		opts.Spacing = *req.Spacing
+	}
+	if req.Width != nil {
+		opts.Width, opts.Height = *req.Width, *req.Height
+	}
+	placements, err := geometry.Arrange(tables, room, opts)
+	if errors.Is(err, geometry.ErrNoFit) || errors.Is(err, geometry.ErrTooLarge) {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"failed to arrange tables"}`, http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.AutoLayoutResponse{Status: "preview", Version: job.version, Tables: make([]json.RawMessage, len(items))}
+	for i, p := range placements {
+		// A large room scale can still push positions past what JSON can hold
+		patch, err := json.Marshal(map[string]any{"position": p.Position, "rotation": p.Rotation})
+		if err != nil {
+			http.Error(w, `{"error":"layout positions are out of range"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		if resp.Tables[i], err = mergePatch(items[i], patch); err != nil {
+			http.Error(w, `{"error":"failed to apply layout"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if !req.Commit {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if resp.Version, err = job.save(r.Context(), fpID, userID, resp.Tables, nil); err != nil {
+		http.Error(w, `{"error":"failed to save layout"}`, http.StatusInternalServerError)
+		return
+	}
+
+	resp.Status = "arranged"
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// layoutWarnings checks the layout of saved tables with the default options,
+// returning nil when there is nothing to warn about.
+func layoutWarnings(ctx context.Context, q rowQuerier, roomID *uuid.UUID, items []json.RawMessage) ([]models.LayoutIssue, error) {
+	tables, err := decodeTables(items)
+	if err != nil {
+		return nil, err
+	}
+	room, err := loadRoomGeometry(ctx, q, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if issues := geometry.Check(tables, room, geometry.DefaultOptions(room)); len(issues) > 0 {
+		return issues, nil
+	}
+	return nil, nil
+}
+
+// loadRoomGeometry returns the geometry of a room, or nil without a room.
+func loadRoomGeometry(ctx context.Context, q rowQuerier, roomID *uuid.UUID) (*models.RoomGeometry, error) {
+	if roomID == nil {
+		return nil, nil
+	}
+	var g models.RoomGeometry
+	err := q.QueryRow(ctx,
+		`SELECT units, scale, outline, obstacles FROM rooms WHERE id = $1`, *roomID,
+	).Scan(&g.Units, &g.Scale, &g.Outline, &g.Obstacles)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func decodeTables(items []json.RawMessage) ([]models.Table, error) {
+	tables := make([]models.Table, len(items))
+	for i, item := range items {
+		if err := json.Unmarshal(item, &tables[i]); err != nil {
+			return nil, errInvalidStoredEntities
+		}
+	}
+	return tables, nil
+}
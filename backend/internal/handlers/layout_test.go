@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func layoutTable(x, y float64) json.RawMessage {
+	return json.RawMessage(`{"id":"` + uuid.New().String() + `","name":"T","tableType":"LINE","capacity":6,` +
+		`"seats":[{"position":0},{"position":1},{"position":2},{"position":3},{"position":4},{"position":5}],"position":{"x":` + jsonNumber(x) + `,"y":` + jsonNumber(y) + `}}`)
+}
+
+func jsonNumber(v float64) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestValidateLayout(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	roomID := uuid.New()
+
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			switch {
+			case strings.Contains(sql, "SELECT room_id"):
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(**uuid.UUID) = &roomID
+					return nil
+				}}
+			case strings.Contains(sql, "FROM rooms"):
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*models.RoomUnit) = models.RoomUnitMeters
+					*dest[1].(*float64) = 100
+					*dest[2].(*[]models.Position) = []models.Position{{X: 0, Y: 0}, {X: 1000, Y: 0}, {X: 1000, Y: 1000}, {X: 0, Y: 1000}}
+					*dest[3].(*[]models.Obstacle) = []models.Obstacle{}
+					return nil
+				}}
+			}
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			// 0.8 m apart, and one table outside the room
+			return dataRows(layoutTable(200, 200), layoutTable(200, 408), layoutTable(1200, 200)), nil
+		},
+	})
+
+	for _, tt := range []struct {
+		body   string
+		issues int
+	}{
+		{`{}`, 2},
+		{`{"minAisle":0.5}`, 1},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/validate", strings.NewReader(tt.body))
+		req = req.WithContext(withUserID(req.Context(), userID))
+		req = withChiParam(req, "id", fpID.String())
+		w := httptest.NewRecorder()
+
+		h.ValidateLayout(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.ValidateLayoutResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Valid || resp.Units != models.RoomUnitMeters || len(resp.Issues) != tt.issues {
+			t.Errorf("%s: unexpected response %s", tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestBulkSave_LayoutWarnings(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					if strings.Contains(sql, "FROM rooms") {
+						t.Error("expected no room lookup for a plan without a room")
+					}
+					return &mockRow{scanFunc: func(dest ...any) error {
+						*dest[0].(*int) = 3
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return &mockRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"version":3,"guests":[],"labels":[],"tables":[` +
+		string(layoutTable(200, 200)) + `,` + string(layoutTable(250, 200)) + `]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/save", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.BulkSave(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.BulkSaveResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Warnings) != 1 || resp.Warnings[0].Type != models.LayoutOverlap {
+		t.Errorf("expected an overlap warning, got %s", w.Body.String())
+	}
+}
+
+func TestAutoLayout(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	var saved []string
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						if strings.Contains(sql, "UPDATE floor_plans") {
+							*dest[0].(*int) = 8
+						} else {
+							*dest[0].(*int) = 7
+						}
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					if strings.Contains(sql, "SELECT data") {
+						// Both tables on top of each other, one with a field the backend doesn't model
+						first := layoutTable(0, 0)
+						first = json.RawMessage(strings.Replace(string(first), `"name":"T"`, `"name":"T","color":"red"`, 1))
+						return dataRows(first, layoutTable(0, 0)), nil
+					}
+					return &mockRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					if strings.Contains(sql, "INSERT INTO floor_plan_tables") {
+						saved = append(saved, string(args[2].(json.RawMessage)))
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	for _, commit := range []bool{false, true} {
+		body := `{"style":"grid","spacing":100,"version":7,"commit":` + strconv.FormatBool(commit) + `}`
+		req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/auto-layout", strings.NewReader(body))
+		req = req.WithContext(withUserID(req.Context(), userID))
+		req = withChiParam(req, "id", fpID.String())
+		w := httptest.NewRecorder()
+
+		h.AutoLayout(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.AutoLayoutResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if want := map[bool]string{false: "preview", true: "arranged"}[commit]; resp.Status != want || len(resp.Tables) != 2 {
+			t.Fatalf("unexpected response %s", w.Body.String())
+		}
+		var tables [2]models.Table
+		json.Unmarshal(resp.Tables[0], &tables[0])
+		json.Unmarshal(resp.Tables[1], &tables[1])
+		if tables[0].Position == tables[1].Position {
+			t.Errorf("expected the tables to be moved apart, got %s", w.Body.String())
+		}
+		if !strings.Contains(string(resp.Tables[0]), `"color":"red"`) {
+			t.Errorf("expected unmodeled fields to be kept, got %s", resp.Tables[0])
+		}
+	}
+	if len(saved) != 2 {
+		t.Errorf("expected the committed layout to be saved, got %v", saved)
+	}
+}
+
+func TestAutoLayout_OutOfRange(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	roomID := uuid.New()
+
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						if strings.Contains(sql, "FROM rooms") {
+							// A scale this large overflows the room's bounds
+							*dest[0].(*models.RoomUnit) = models.RoomUnitMeters
+							*dest[1].(*float64) = 1e307
+							*dest[2].(*[]models.Position) = []models.Position{}
+							*dest[3].(*[]models.Obstacle) = []models.Obstacle{}
+							return nil
+						}
+						*dest[0].(*int) = 7
+						*dest[1].(**uuid.UUID) = &roomID
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return dataRows(layoutTable(0, 0), layoutTable(0, 0)), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"style":"grid","width":20,"height":12}`
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/auto-layout", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.AutoLayout(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+}
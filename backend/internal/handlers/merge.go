@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// rowsQuerier is satisfied by both DB and pgx.Tx.
+type rowsQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// mergeResult is the outcome of a three-way merge of a stale save.
+type mergeResult struct {
+	Tables    []json.RawMessage
+	Guests    []json.RawMessage
+	Labels    []json.RawMessage
+	Conflicts models.MergeConflicts
+}
+
+// threeWayMerge merges a save based on baseVersion with the current state of
+// the plan. Entities changed on only one side take that side's value; entities
+// changed differently on both sides are reported as conflicts.
+func threeWayMerge(ctx context.Context, tx pgx.Tx, fpID uuid.UUID, baseVersion int, req *models.BulkSaveRequest) (*mergeResult, error) {
+	base, err := getRevision(ctx, tx, fpID, baseVersion)
+	if err != nil {
+		return nil, fmt.Errorf("load base revision: %w", err)
+	}
+
+	var result mergeResult
+	kinds := []struct {
+		table   string
+		base    []json.RawMessage
+		client  []json.RawMessage
+		merged  *[]json.RawMessage
+		clashes *[]uuid.UUID
+	}{
+		{"floor_plan_tables", base.Tables, req.Tables, &result.Tables, &result.Conflicts.Tables},
+		{"floor_plan_guests", base.Guests, req.Guests, &result.Guests, &result.Conflicts.Guests},
+		{"floor_plan_labels", base.Labels, req.Labels, &result.Labels, &result.Conflicts.Labels},
+	}
+	for _, k := range kinds {
+		current, err := queryEntityData(ctx, tx, k.table, fpID)
+		if err != nil {
+			return nil, fmt.Errorf("load current %s: %w", k.table, err)
+		}
+		*k.merged, *k.clashes = mergeEntities(k.base, k.client, current)
+	}
+
+	return &result, nil
+}
+
+// errInvalidMerge is returned when merging two valid plans gives an invalid one.
+var errInvalidMerge = errors.New("merged entities are invalid")
+
+// settleMerge checks a merged plan the way a save is checked. Either side may
+// have deleted what the other still refers to, so seats, table assignments
+// and guestOf links to deleted entities are repaired; the repairs are returned.
+func settleMerge(tables, guests, labels []json.RawMessage) ([]json.RawMessage, []json.RawMessage, []models.FieldError, error) {
+	if errs := models.ValidateEntities(tables, guests, labels); len(errs) > 0 {
+		return nil, nil, nil, errInvalidMerge
+	}
+
+	ids := make(map[uuid.UUID]bool, len(guests))
+	for _, item := range guests {
+		ids[extractID(item)] = true
+	}
+	repairs := []models.FieldError{}
+	settled := make([]json.RawMessage, len(guests))
+	for i, item := range guests {
+		settled[i] = item
+		var ref struct {
+			GuestOf *string `json:"guestOf"`
+		}
+		if err := json.Unmarshal(item, &ref); err != nil {
+			return nil, nil, nil, errInvalidMerge
+		}
+		if ref.GuestOf == nil {
+			continue
+		}
+		if host, err := uuid.Parse(*ref.GuestOf); err == nil && ids[host] {
+			continue
+		}
+		var doc map[string]any
+		if err := decodeNumbers(item, &doc); err != nil {
+			return nil, nil, nil, errInvalidMerge
+		}
+		doc["guestOf"] = nil
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		settled[i] = data
+		repairs = append(repairs, models.FieldError{
+			Path:    fmt.Sprintf("guests[%d].guestOf", i),
+			Message: fmt.Sprintf("guest %s does not exist; host cleared", *ref.GuestOf),
+		})
+	}
+
+	reconciled, err := seating.Reconcile(tables, settled)
+	if err != nil {
+		return nil, nil, nil, errInvalidMerge
+	}
+	return reconciled.Tables, reconciled.Guests, append(repairs, reconciled.Issues...), nil
+}
+
+// settleStoredMerge runs settleMerge over the plan as stored after a
+// per-entity save and writes back the repairs, adding them to changes.
+func settleStoredMerge(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, changes *events.Changes) ([]models.FieldError, error) {
+	tables, guests, labels, err := queryPlanEntities(ctx, tx, fpID)
+	if err != nil {
+		return nil, err
+	}
+	tables, guests, repairs, err := settleMerge(tables, guests, labels)
+	if err != nil || len(repairs) == 0 {
+		return repairs, err
+	}
+
+	repaired, err := upsertEntities(ctx, tx, "floor_plan_tables", fpID, userID, tables)
+	if err != nil {
+		return nil, err
+	}
+	changes.Tables.Upserted = appendNew(changes.Tables.Upserted, repaired.Upserted...)
+	if repaired, err = upsertEntities(ctx, tx, "floor_plan_guests", fpID, userID, guests); err != nil {
+		return nil, err
+	}
+	changes.Guests.Upserted = appendNew(changes.Guests.Upserted, repaired.Upserted...)
+	return repairs, nil
+}
+
+// mergeEntities performs an entity-level three-way merge keyed by entity ID.
+// Client entities keep their order; entities only known to the server follow.
+func mergeEntities(base, client, current []json.RawMessage) ([]json.RawMessage, []uuid.UUID) {
+	baseByID, baseIDs := indexEntities(base)
+	clientByID, clientIDs := indexEntities(client)
+	currentByID, currentIDs := indexEntities(current)
+
+	merged := []json.RawMessage{}
+	conflicts := []uuid.UUID{}
+
+	resolve := func(id uuid.UUID) {
+		b, inBase := baseByID[id]
+		c, inClient := clientByID[id]
+		s, inCurrent := currentByID[id]
+
+		clientChanged := inBase != inClient || (inClient && !jsonEqual(b, c))
+		serverChanged := inBase != inCurrent || (inCurrent && !jsonEqual(b, s))
+
+		switch {
+		case !clientChanged:
+			if inCurrent {
+				merged = append(merged, s)
+			}
+		case !serverChanged:
+			if inClient {
+				merged = append(merged, c)
+			}
+		case inClient == inCurrent && (!inClient || jsonEqual(c, s)):
+			// Both sides made the same change
+			if inCurrent {
+				merged = append(merged, s)
+			}
+		default:
+			conflicts = append(conflicts, id)
+			if inCurrent {
+				merged = append(merged, s)
+			}
+		}
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, ids := range [][]uuid.UUID{clientIDs, currentIDs, baseIDs} {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				resolve(id)
+			}
+		}
+	}
+
+	return merged, conflicts
+}
+
+// indexEntities maps entities by ID and returns the IDs in their original order.
+func indexEntities(items []json.RawMessage) (map[uuid.UUID]json.RawMessage, []uuid.UUID) {
+	byID := make(map[uuid.UUID]json.RawMessage, len(items))
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		id := extractID(item)
+		if _, dup := byID[id]; !dup {
+			ids = append(ids, id)
+		}
+		byID[id] = item
+	}
+	return byID, ids
+}
+
+// jsonEqual compares two documents ignoring key order and whitespace.
+func jsonEqual(a, b json.RawMessage) bool {
+	ca, errA := canonicalJSON(a)
+	cb, errB := canonicalJSON(b)
+	if errA != nil || errB != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca, cb)
+}
+
+func canonicalJSON(data json.RawMessage) ([]byte, error) {
+	var v any
+	if err := decodeNumbers(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
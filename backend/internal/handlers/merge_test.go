@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func entity(id uuid.UUID, name string) json.RawMessage {
+	return json.RawMessage(`{"id":"` + id.String() + `","name":"` + name + `"}`)
+}
+
+func names(items []json.RawMessage) map[string]bool {
+	out := map[string]bool{}
+	for _, item := range items {
+		var obj struct{ Name string }
+		json.Unmarshal(item, &obj)
+		out[obj.Name] = true
+	}
+	return out
+}
+
+func TestMergeEntities(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name          string
+		base          []json.RawMessage
+		client        []json.RawMessage
+		current       []json.RawMessage
+		wantNames     []string
+		wantConflicts []uuid.UUID
+	}{
+		{
+			name:      "different entities edited",
+			base:      []json.RawMessage{entity(a, "A"), entity(b, "B")},
+			client:    []json.RawMessage{entity(a, "A2"), entity(b, "B")},
+			current:   []json.RawMessage{entity(a, "A"), entity(b, "B3")},
+			wantNames: []string{"A2", "B3"},
+		},
+		{
+			name:      "client adds, server deletes another",
+			base:      []json.RawMessage{entity(a, "A"), entity(b, "B")},
+			client:    []json.RawMessage{entity(a, "A"), entity(b, "B"), entity(c, "C")},
+			current:   []json.RawMessage{entity(a, "A")},
+			wantNames: []string{"A", "C"},
+		},
+		{
+			name:      "same edit on both sides",
+			base:      []json.RawMessage{entity(a, "A")},
+			client:    []json.RawMessage{entity(a, "A2")},
+			current:   []json.RawMessage{json.RawMessage(`{"name": "A2", "id": "` + a.String() + `"}`)},
+			wantNames: []string{"A2"},
+		},
+		{
+			name:          "same entity edited differently",
+			base:          []json.RawMessage{entity(a, "A"), entity(b, "B")},
+			client:        []json.RawMessage{entity(a, "A2"), entity(b, "B2")},
+			current:       []json.RawMessage{entity(a, "A3"), entity(b, "B")},
+			wantNames:     []string{"A3", "B2"},
+			wantConflicts: []uuid.UUID{a},
+		},
+		{
+			name:          "client deletes what server edited",
+			base:          []json.RawMessage{entity(a, "A")},
+			client:        []json.RawMessage{},
+			current:       []json.RawMessage{entity(a, "A3")},
+			wantNames:     []string{"A3"},
+			wantConflicts: []uuid.UUID{a},
+		},
+		{
+			name:      "both delete",
+			base:      []json.RawMessage{entity(a, "A")},
+			client:    []json.RawMessage{},
+			current:   []json.RawMessage{},
+			wantNames: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := mergeEntities(tt.base, tt.client, tt.current)
+
+			got := names(merged)
+			if len(got) != len(tt.wantNames) {
+				t.Errorf("merged = %v, want %v", got, tt.wantNames)
+			}
+			for _, n := range tt.wantNames {
+				if !got[n] {
+					t.Errorf("merged = %v, missing %s", got, n)
+				}
+			}
+			if len(conflicts) != len(tt.wantConflicts) {
+				t.Fatalf("conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+			for i := range conflicts {
+				if conflicts[i] != tt.wantConflicts[i] {
+					t.Errorf("conflicts = %v, want %v", conflicts, tt.wantConflicts)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkSave_StaleVersionMerged(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestA, guestB := uuid.New(), uuid.New()
+
+	var savedGuests []uuid.UUID
+	db := &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					if p, ok := dest[0].(*uuid.UUID); ok {
+						*p = userID
+					}
+					return nil
+				},
+			}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					switch {
+					case strings.Contains(sql, "FROM floor_plan_revisions"):
+						// Base revision 3 had both guests unchanged
+						return &mockRow{
+							scanFunc: func(dest ...any) error {
+								*dest[9].(*[]json.RawMessage) = []json.RawMessage{entity(guestA, "Ann"), entity(guestB, "Bob")}
+								return nil
+							},
+						}
+					case strings.Contains(sql, "RETURNING version"):
+						return &mockRow{
+							scanFunc: func(dest ...any) error {
+								*dest[0].(*int) = 6
+								return nil
+							},
+						}
+					default:
+						return &mockRow{
+							scanFunc: func(dest ...any) error {
+								*dest[0].(*int) = 5
+								return nil
+							},
+						}
+					}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					if strings.Contains(sql, "SELECT data FROM floor_plan_guests") {
+						// Someone else renamed Bob since version 3
+						return dataRows(entity(guestA, "Ann"), entity(guestB, "Robert")), nil
+					}
+					return &emptyRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					if strings.Contains(sql, "INSERT INTO floor_plan_guests") {
+						savedGuests = append(savedGuests, args[0].(uuid.UUID))
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	}
+
+	h := New(db)
+
+	// Client renamed Ann based on version 3
+	body := `{"version":3,"tables":[],"labels":[],"guests":[` +
+		string(entity(guestA, "Anna")) + `,` + string(entity(guestB, "Bob")) + `]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/save", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.BulkSave(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Status  string            `json:"status"`
+		Version int               `json:"version"`
+		Guests  []json.RawMessage `json:"guests"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Status != "merged" || resp.Version != 6 {
+		t.Errorf("expected merged version 6, got %s %d", resp.Status, resp.Version)
+	}
+	got := names(resp.Guests)
+	if !got["Anna"] || !got["Robert"] {
+		t.Errorf("expected both edits in merged guests, got %v", got)
+	}
+	if len(savedGuests) != 2 {
+		t.Errorf("expected 2 guests saved, got %d", len(savedGuests))
+	}
+	if !strings.Contains(w.Body.String(), `"labels":[]`) {
+		t.Errorf("expected the merged, empty labels to be returned, got %s", w.Body.String())
+	}
+}
+
+func TestBulkSave_MergeClearsDeletedHost(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestA, guestB := uuid.New(), uuid.New()
+
+	db := &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				if p, ok := dest[0].(*uuid.UUID); ok {
+					*p = userID
+				}
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						switch {
+						case strings.Contains(sql, "FROM floor_plan_revisions"):
+							*dest[9].(*[]json.RawMessage) = []json.RawMessage{entity(guestA, "Ann"), entity(guestB, "Bob")}
+						case strings.Contains(sql, "RETURNING version"):
+							*dest[0].(*int) = 6
+						default:
+							*dest[0].(*int) = 5
+						}
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					if strings.Contains(sql, "SELECT data FROM floor_plan_guests") {
+						// Someone else deleted Bob since version 3
+						return dataRows(entity(guestA, "Ann")), nil
+					}
+					return &emptyRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	}
+
+	h := New(db)
+
+	// Client made Ann Bob's guest based on version 3
+	body := `{"version":3,"tables":[],"labels":[],"guests":[` +
+		`{"id":"` + guestA.String() + `","name":"Ann","guestOf":"` + guestB.String() + `"},` + string(entity(guestB, "Bob")) + `]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/save", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.BulkSave(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.BulkSaveResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Status != "merged" || len(resp.Guests) != 1 || len(resp.Repairs) != 1 {
+		t.Fatalf("expected Ann merged with one repair, got %s", w.Body.String())
+	}
+	var ann struct {
+		GuestOf *string `json:"guestOf"`
+	}
+	json.Unmarshal(resp.Guests[0], &ann)
+	if ann.GuestOf != nil {
+		t.Errorf("expected the deleted host cleared, got %s", resp.Guests[0])
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// OptimizeSeating searches for a seating of the stored plan that satisfies the
+// requested constraints, keeping locked guests in place. Like AutoAssign it
+// returns a preview unless asked to commit.
+func (h *Handler) OptimizeSeating(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.OptimizeRequest](r, w)
+	if !ok {
+		return
+	}
+
+	var result *seating.OptimizeResult
+	resp := h.reseat(w, r, userID, fpID, req.Commit, req.Version, req.Seed,
+		func(ctx context.Context, plan *seatingPlan, rng *rand.Rand) (*seating.Result, error) {
+			var err error
+			result, err = seating.Optimize(ctx, plan.guests, plan.tables, seating.Options{
+				Constraints: req.Constraints,
+				Locked:      req.Locked,
+				Iterations:  req.Iterations,
+			}, rng)
+			if err != nil {
+				return nil, err
+			}
+			return &result.Result, nil
+		})
+	if resp == nil {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.OptimizeResponse{
+		AutoAssignResponse: *resp,
+		Penalty:            result.Penalty,
+		HardSatisfied:      result.HardSatisfied,
+		Report:             result.Report,
+	})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// seatingTest is a plan at version 5 in an organization where the caller has
+// role. It records the guests a commit saves and whether it was committed.
+type seatingTest struct {
+	role      string
+	tables    []json.RawMessage
+	guests    []json.RawMessage
+	saved     []string
+	committed bool
+}
+
+func (st *seatingTest) db() *mockDB {
+	orgID := uuid.New()
+	return &mockDB{
+		queryRowFunc: func(ctx context.Context, query string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				if strings.Contains(query, "FROM organization_members") {
+					*dest[0].(*string) = st.role
+					return nil
+				}
+				*dest[0].(*uuid.UUID) = uuid.New()
+				*dest[1].(*sql.NullString) = sql.NullString{String: orgID.String(), Valid: true}
+				return nil
+			}}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return dataRows(), nil
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						if strings.Contains(sql, "UPDATE floor_plans") {
+							*dest[0].(*int) = 6
+						} else {
+							*dest[0].(*int) = 5
+						}
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					switch {
+					case strings.Contains(sql, "SELECT data FROM floor_plan_tables"):
+						return dataRows(st.tables...), nil
+					case strings.Contains(sql, "SELECT data FROM floor_plan_guests"):
+						return dataRows(st.guests...), nil
+					}
+					return &mockRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					if strings.Contains(sql, "INSERT INTO floor_plan_guests") {
+						st.saved = append(st.saved, string(args[2].(json.RawMessage)))
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+				commitFunc: func(ctx context.Context) error {
+					st.committed = true
+					return nil
+				},
+			}, nil
+		},
+	}
+}
+
+// seatingPlanItems is two tables of two seats and three unseated guests, the
+// first two of whom must sit apart.
+func seatingPlanItems() (tables, guests []json.RawMessage, apart string) {
+	for range 2 {
+		tables = append(tables, json.RawMessage(`{"id":"`+uuid.NewString()+`","name":"T","tableType":"ROUND","capacity":2,`+
+			`"seats":[{"position":0,"guestId":null,"label":""},{"position":1,"guestId":null,"label":""}],"assignedGuests":[]}`))
+	}
+	ids := []string{uuid.NewString(), uuid.NewString(), uuid.NewString()}
+	for i, id := range ids {
+		guests = append(guests, json.RawMessage(`{"id":"`+id+`","name":"G`+string(rune('A'+i))+`","dietaryRestrictions":[],"assignedTableId":null,"seatPosition":null}`))
+	}
+	apart = `{"type":"apart","guests":{"guestIds":["` + ids[0] + `","` + ids[1] + `"]},"hard":true}`
+	return tables, guests, apart
+}
+
+func seatingRequest(ctx context.Context, path string, fpID uuid.UUID, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+path, strings.NewReader(body))
+	req = req.WithContext(ctx)
+	return withChiParam(req, "id", fpID.String())
+}
+
+func TestOptimizeSeating_ViewerPreview(t *testing.T) {
+	userID := uuid.New()
+	tables, guests, apart := seatingPlanItems()
+	st := &seatingTest{role: models.RoleViewer, tables: tables, guests: guests}
+	h := New(st.db())
+
+	body := `{"constraints":[` + apart + `],"seed":1}`
+	w := httptest.NewRecorder()
+	h.OptimizeSeating(w, seatingRequest(withUserID(context.Background(), userID), "/optimize", uuid.New(), body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.OptimizeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Status != "preview" || resp.Version != 5 || !resp.HardSatisfied || resp.UnassignedCount != 0 || len(resp.Guests) != 3 {
+		t.Errorf("unexpected preview %s", w.Body.String())
+	}
+	if st.committed || len(st.saved) != 0 {
+		t.Error("expected a preview to save nothing")
+	}
+}
+
+func TestOptimizeSeating_ViewerCannotCommit(t *testing.T) {
+	tables, guests, apart := seatingPlanItems()
+	st := &seatingTest{role: models.RoleViewer, tables: tables, guests: guests}
+	h := New(st.db())
+
+	body := `{"constraints":[` + apart + `],"commit":true}`
+	w := httptest.NewRecorder()
+	h.OptimizeSeating(w, seatingRequest(withUserID(context.Background(), uuid.New()), "/optimize", uuid.New(), body))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOptimizeSeating_Canceled(t *testing.T) {
+	tables, guests, apart := seatingPlanItems()
+	st := &seatingTest{role: models.RoleMember, tables: tables, guests: guests}
+	h := New(st.db())
+
+	ctx, cancel := context.WithCancel(withUserID(context.Background(), uuid.New()))
+	cancel()
+	body := `{"constraints":[` + apart + `],"commit":true}`
+	w := httptest.NewRecorder()
+	h.OptimizeSeating(w, seatingRequest(ctx, "/optimize", uuid.New(), body))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if st.committed || len(st.saved) != 0 {
+		t.Error("expected a canceled run to save nothing")
+	}
+}
@@ -206,22 +206,38 @@ func TestDeleteOrganization(t *testing.T) {
 	}
 }
 
-// mockRows implements pgx.Rows for testing
+// mockRows implements pgx.Rows for testing. With scan set it yields n rows,
+// scanning row i with scan(i, dest...); otherwise it yields rows.
 type mockRows struct {
 	rows [][]any
+	n    int
+	scan func(i int, dest ...any) error
 	idx  int
 }
 
+func (m *mockRows) len() int {
+	if m.scan != nil {
+		return m.n
+	}
+	return len(m.rows)
+}
+
 func (m *mockRows) Next() bool {
+	if m.idx >= m.len() {
+		return false
+	}
 	m.idx++
-	return m.idx < len(m.rows)
+	return true
 }
 
 func (m *mockRows) Scan(dest ...any) error {
-	if m.idx >= len(m.rows) {
+	if m.idx == 0 || m.idx > m.len() {
 		return errors.New("no rows")
 	}
-	row := m.rows[m.idx]
+	if m.scan != nil {
+		return m.scan(m.idx-1, dest...)
+	}
+	row := m.rows[m.idx-1]
 	for i, d := range dest {
 		if i < len(row) {
 			// Simple assignment - in real tests you'd handle type conversions
@@ -236,6 +252,14 @@ func (m *mockRows) Scan(dest ...any) error {
 	return nil
 }
 
+// dataRows returns each item as a single data column.
+func dataRows(items ...json.RawMessage) *mockRows {
+	return &mockRows{n: len(items), scan: func(i int, dest ...any) error {
+		*dest[0].(*json.RawMessage) = items[i]
+		return nil
+	}}
+}
+
 func (m *mockRows) Err() error {
 	return nil
 }
@@ -264,6 +288,7 @@ func (m *mockRows) Values() ([]any, error) {
 
 // mockTx implements pgx.Tx for testing
 type mockTx struct {
+	queryFunc    func(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	queryRowFunc func(ctx context.Context, sql string, args ...any) pgx.Row
 	execFunc     func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
 	commitFunc   func(ctx context.Context) error
@@ -296,6 +321,9 @@ func (m *mockTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.Comm
 }
 
 func (m *mockTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if m.queryFunc != nil {
+		return m.queryFunc(ctx, sql, args...)
+	}
 	return nil, errors.New("query not implemented")
 }
 
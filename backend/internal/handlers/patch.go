@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// entityTables maps patch entity kinds to their storage tables.
+var entityTables = map[string]string{
+	models.EntityTable: "floor_plan_tables",
+	models.EntityGuest: "floor_plan_guests",
+	models.EntityLabel: "floor_plan_labels",
+}
+
+// operationError is a patch operation that cannot be applied to the current state.
+type operationError struct {
+	status int
+	msg    string
+	fields models.FieldErrors
+}
+
+func (e *operationError) Error() string { return e.msg }
+
+// PatchFloorPlan applies individual create, update and delete operations to a
+// floor plan's entities, so small edits don't need to resend the whole plan.
+// It uses the same optimistic version check as BulkSave.
+func (h *Handler) PatchFloorPlan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	canEdit, err := h.canEditFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.PatchFloorPlanRequest](r, w)
+	if !ok {
+		return
+	}
+	for i, op := range req.Operations {
+		if op.Op != models.OpCreate {
+			continue
+		}
+		if errs := models.ValidateEntity(op.Entity, op.Data, fmt.Sprintf("operations[%d].data", i)); len(errs) > 0 {
+			respondFieldErrors(w, errs)
+			return
+		}
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// Optimistic concurrency: check version under row lock
+	var dbVersion int
+	err = tx.QueryRow(r.Context(),
+		`SELECT version FROM floor_plans WHERE id = $1 FOR UPDATE`, fpID,
+	).Scan(&dbVersion)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+
+	// Operations that all carry expected entity versions are checked per entity instead
+	if !req.PerEntity() && req.Version != dbVersion {
+		h.respondVersionConflict(w, r, fpID, dbVersion)
+		return
+	}
+
+	changes := events.Changes{
+		Tables: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+		Guests: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+		Labels: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+	}
+	for i, op := range req.Operations {
+		if err := applyOperation(r.Context(), tx, fpID, userID, op, &changes); err != nil {
+			var opErr *operationError
+			if errors.As(err, &opErr) {
+				if len(opErr.fields) > 0 {
+					// Paths from applyOperation are relative to the operation's data
+					for j := range opErr.fields {
+						opErr.fields[j].Path = fmt.Sprintf("operations[%d].%s", i, opErr.fields[j].Path)
+					}
+					respondFieldErrors(w, opErr.fields)
+					return
+				}
+				respondJSON(w, opErr.status, map[string]string{"error": opErr.msg})
+				return
+			}
+			http.Error(w, `{"error":"failed to apply operations"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Seats and guest assignments must agree once every operation is applied,
+	// as they must for BulkSave; repair mode fixes them instead
+	tables, err := queryEntityData(r.Context(), tx, "floor_plan_tables", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	guests, err := queryEntityData(r.Context(), tx, "floor_plan_guests", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	reconciled, err := seating.Reconcile(tables, guests)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	var repairs []models.FieldError
+	if len(reconciled.Issues) > 0 {
+		if !req.Repair {
+			respondJSON(w, http.StatusBadRequest, map[string]any{
+				"error":  "inconsistent seating assignments",
+				"issues": reconciled.Issues,
+			})
+			return
+		}
+		repaired, err := upsertEntities(r.Context(), tx, "floor_plan_tables", fpID, userID, reconciled.Tables)
+		if err != nil {
+			http.Error(w, `{"error":"failed to save tables"}`, http.StatusInternalServerError)
+			return
+		}
+		changes.Tables.Upserted = appendNew(changes.Tables.Upserted, repaired.Upserted...)
+		if repaired, err = upsertEntities(r.Context(), tx, "floor_plan_guests", fpID, userID, reconciled.Guests); err != nil {
+			http.Error(w, `{"error":"failed to save guests"}`, http.StatusInternalServerError)
+			return
+		}
+		changes.Guests.Upserted = appendNew(changes.Guests.Upserted, repaired.Upserted...)
+		repairs = reconciled.Issues
+	}
+
+	// Edits to what the event owns don't stick, as with BulkSave
+	enforced, err := enforceEventGuests(r.Context(), tx, fpID, userID, &changes.Guests)
+	if err != nil {
+		respondEventGuestError(w, err)
+		return
+	}
+
+	newVersion, err := commitVersion(r.Context(), tx, fpID, userID, changes, nil)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.BulkSaveResponse{Status: "saved", Version: newVersion, Repairs: repairs}
+	if enforced {
+		// The client's copy still has the edits to event guests
+		if resp.Guests, err = queryEntityData(r.Context(), tx, "floor_plan_guests", fpID); err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		resp.Guests = orEmpty(resp.Guests)
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// appendNew appends the IDs not already in ids.
+func appendNew(ids []uuid.UUID, more ...uuid.UUID) []uuid.UUID {
+	for _, id := range more {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// applyOperation applies a single operation inside the save transaction and
+// records the affected ID in changes.
+func applyOperation(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, op models.EntityOperation, changes *events.Changes) error {
+	tableName := entityTables[op.Entity]
+	if !allowedTables[tableName] {
+		return fmt.Errorf("invalid entity: %s", op.Entity)
+	}
+
+	var target *events.EntityChanges
+	switch op.Entity {
+	case models.EntityTable:
+		target = &changes.Tables
+	case models.EntityGuest:
+		target = &changes.Guests
+	default:
+		target = &changes.Labels
+	}
+
+	switch op.Op {
+	case models.OpCreate:
+		tag, err := tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s (id, floor_plan_id, data, updated_by) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`, tableName),
+			op.ID, fpID, op.Data, userID,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return &operationError{status: http.StatusConflict, msg: fmt.Sprintf("%s %s already exists", op.Entity, op.ID)}
+		}
+		target.Upserted = append(target.Upserted, op.ID)
+
+	case models.OpUpdate:
+		var current json.RawMessage
+		var version int
+		err := tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT data, version FROM %s WHERE id = $1 AND floor_plan_id = $2`, tableName),
+			op.ID, fpID,
+		).Scan(&current, &version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &operationError{status: http.StatusNotFound, msg: fmt.Sprintf("%s %s not found", op.Entity, op.ID)}
+		}
+		if err != nil {
+			return err
+		}
+		if op.ExpectedVersion != nil && *op.ExpectedVersion != version {
+			return &operationError{status: http.StatusConflict, msg: fmt.Sprintf("%s %s was modified concurrently", op.Entity, op.ID)}
+		}
+
+		merged, err := mergePatch(current, op.Data)
+		if err != nil {
+			return &operationError{status: http.StatusBadRequest, msg: fmt.Sprintf("%s %s: invalid patch", op.Entity, op.ID)}
+		}
+		if errs := models.ValidateEntity(op.Entity, merged, "data"); len(errs) > 0 {
+			return &operationError{status: http.StatusBadRequest, msg: errs.Error(), fields: errs}
+		}
+
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`UPDATE %s SET data = $1, version = version + 1, updated_by = $2, updated_at = NOW()
+				WHERE id = $3 AND floor_plan_id = $4`, tableName),
+			merged, userID, op.ID, fpID,
+		)
+		if err != nil {
+			return err
+		}
+		target.Upserted = append(target.Upserted, op.ID)
+
+	case models.OpDelete:
+		if op.ExpectedVersion != nil {
+			var version int
+			err := tx.QueryRow(ctx,
+				fmt.Sprintf(`SELECT version FROM %s WHERE id = $1 AND floor_plan_id = $2`, tableName),
+				op.ID, fpID,
+			).Scan(&version)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if *op.ExpectedVersion != version {
+				return &operationError{status: http.StatusConflict, msg: fmt.Sprintf("%s %s was modified concurrently", op.Entity, op.ID)}
+			}
+		}
+
+		// Deleting an entity that is already gone is a no-op
+		tag, err := tx.Exec(ctx,
+			fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND floor_plan_id = $2`, tableName),
+			op.ID, fpID,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() > 0 {
+			target.Deleted = append(target.Deleted, op.ID)
+		}
+
+	default:
+		return fmt.Errorf("invalid op: %s", op.Op)
+	}
+
+	return nil
+}
+
+// nullableFields are entity fields where null is a value: a guest with a null
+// assignedTableId is unassigned, which clients tell apart from a missing key.
+var nullableFields = map[string]bool{"assignedTableId": true, "seatPosition": true, "guestOf": true}
+
+// mergePatch applies an RFC 7396 JSON merge patch to a document, except that
+// null sets the top-level nullableFields to null instead of removing them.
+func mergePatch(doc, patch json.RawMessage) (json.RawMessage, error) {
+	var target, p any
+	if err := decodeNumbers(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := decodeNumbers(patch, &p); err != nil {
+		return nil, err
+	}
+	if patchObj, ok := p.(map[string]any); ok {
+		if targetObj, ok := target.(map[string]any); ok {
+			for k, v := range patchObj {
+				if v == nil && nullableFields[k] {
+					targetObj[k] = nil
+					delete(patchObj, k)
+				}
+			}
+		}
+	}
+	return json.Marshal(applyMergePatch(target, p))
+}
+
+func applyMergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+		} else {
+			targetObj[k] = applyMergePatch(targetObj[k], v)
+		}
+	}
+	return targetObj
+}
+
+// decodeNumbers unmarshals JSON keeping numbers as json.Number so they round-trip exactly.
+func decodeNumbers(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// patchTestDB returns a DB where the caller owns the plan and the plan is at
+// version 3. tx is used for the save transaction.
+func patchTestDB(userID uuid.UUID, tx *mockTx) *mockDB {
+	return &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					if p, ok := dest[0].(*uuid.UUID); ok {
+						*p = userID
+					}
+					return nil
+				},
+			}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return &emptyRows{}, nil
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return tx, nil
+		},
+	}
+}
+
+func patchRequest(userID, fpID uuid.UUID, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, "/api/floor-plans/"+fpID.String(), strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	return withChiParam(req, "id", fpID.String())
+}
+
+func TestPatchFloorPlan_VersionConflict(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	tx := &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*int) = 5
+					return nil
+				},
+			}
+		},
+	}
+	h := New(patchTestDB(userID, tx))
+
+	body := `{"version":3,"operations":[{"op":"delete","entity":"guest","id":"` + guestID.String() + `"}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPatchFloorPlan_CreateExisting(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	tableID := uuid.New()
+
+	tx := &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*int) = 3
+					return nil
+				},
+			}
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			// ON CONFLICT DO NOTHING — row already exists
+			return pgconn.NewCommandTag("INSERT 0 0"), nil
+		},
+	}
+	h := New(patchTestDB(userID, tx))
+
+	body := `{"version":3,"operations":[{"op":"create","entity":"table","id":"` + tableID.String() +
+		`","data":{"id":"` + tableID.String() + `","name":"T1","tableType":"ROUND","capacity":1,` +
+		`"seats":[{"position":0,"guestId":null,"label":"Seat 1"}],"assignedGuests":[]}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "already exists") {
+		t.Errorf("expected already exists error, got %s", w.Body.String())
+	}
+}
+
+func TestPatchFloorPlan_UpdateMergesDocument(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	var written string
+	tx := &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			switch {
+			case strings.Contains(sql, "SELECT data, version FROM floor_plan_guests"):
+				return &mockRow{
+					scanFunc: func(dest ...any) error {
+						*dest[0].(*json.RawMessage) = json.RawMessage(
+							`{"id":"` + guestID.String() + `","name":"Ann","dietaryRestrictions":["VEGAN"],"seatPosition":2}`)
+						*dest[1].(*int) = 1
+						return nil
+					},
+				}
+			case strings.Contains(sql, "RETURNING version"):
+				return &mockRow{
+					scanFunc: func(dest ...any) error {
+						*dest[0].(*int) = 4
+						return nil
+					},
+				}
+			default:
+				return &mockRow{
+					scanFunc: func(dest ...any) error {
+						*dest[0].(*int) = 3
+						return nil
+					},
+				}
+			}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			if strings.Contains(sql, "SELECT data FROM floor_plan_guests") {
+				return dataRows(json.RawMessage(written)), nil
+			}
+			return dataRows(), nil
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			if strings.HasPrefix(sql, "UPDATE floor_plan_guests") {
+				written = string(args[0].(json.RawMessage))
+			}
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+	h := New(patchTestDB(userID, tx))
+
+	body := `{"version":3,"operations":[{"op":"update","entity":"guest","id":"` + guestID.String() +
+		`","data":{"name":"Anna","seatPosition":null}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(written), &doc); err != nil {
+		t.Fatalf("invalid document written: %v", err)
+	}
+	if doc["name"] != "Anna" {
+		t.Errorf("expected name Anna, got %v", doc["name"])
+	}
+	if v, ok := doc["seatPosition"]; !ok || v != nil {
+		t.Errorf("expected seatPosition to be null, got %v", doc["seatPosition"])
+	}
+	if diet, ok := doc["dietaryRestrictions"].([]any); !ok || len(diet) != 1 {
+		t.Errorf("expected dietaryRestrictions to be kept, got %v", doc["dietaryRestrictions"])
+	}
+}
+
+func TestPatchFloorPlan_UnassignGuest(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	var written string
+	tx := &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				switch {
+				case strings.Contains(sql, "SELECT data, version FROM floor_plan_guests"):
+					*dest[0].(*json.RawMessage) = json.RawMessage(`{"id":"` + guestID.String() +
+						`","name":"Ann","dietaryRestrictions":[],"assignedTableId":"` + uuid.NewString() + `","seatPosition":0}`)
+					*dest[1].(*int) = 1
+				case strings.Contains(sql, "RETURNING version"):
+					*dest[0].(*int) = 4
+				default:
+					*dest[0].(*int) = 3
+				}
+				return nil
+			}}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			if strings.Contains(sql, "SELECT data FROM floor_plan_guests") {
+				return dataRows(json.RawMessage(written)), nil
+			}
+			return dataRows(), nil
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			if strings.HasPrefix(sql, "UPDATE floor_plan_guests") {
+				written = string(args[0].(json.RawMessage))
+			}
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+	h := New(patchTestDB(userID, tx))
+
+	body := `{"version":3,"operations":[{"op":"update","entity":"guest","id":"` + guestID.String() +
+		`","data":{"assignedTableId":null,"seatPosition":null}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(written, `"assignedTableId":null`) || !strings.Contains(written, `"seatPosition":null`) {
+		t.Errorf("expected the guest stored as unassigned, got %s", written)
+	}
+}
+
+func TestPatchFloorPlan_EntityVersionConflict(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	var updated bool
+	tx := &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			if strings.Contains(sql, "SELECT data, version FROM floor_plan_guests") {
+				return &mockRow{
+					scanFunc: func(dest ...any) error {
+						*dest[0].(*json.RawMessage) = json.RawMessage(`{"id":"` + guestID.String() + `","name":"Ann"}`)
+						*dest[1].(*int) = 3
+						return nil
+					},
+				}
+			}
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*int) = 9
+					return nil
+				},
+			}
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			if strings.HasPrefix(sql, "UPDATE floor_plan_guests") {
+				updated = true
+			}
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+	h := New(patchTestDB(userID, tx))
+
+	// The plan version is stale but the per-entity check decides the outcome
+	body := `{"version":2,"operations":[{"op":"update","entity":"guest","id":"` + guestID.String() +
+		`","expectedVersion":2,"data":{"name":"Anna"}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "modified concurrently") {
+		t.Errorf("expected concurrent modification error, got %s", w.Body.String())
+	}
+	if updated {
+		t.Error("expected no update to be written")
+	}
+}
+
+func TestPatchFloorPlan_UpdateInvalidDocument(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	tx := &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			if strings.Contains(sql, "SELECT data, version FROM floor_plan_guests") {
+				return &mockRow{
+					scanFunc: func(dest ...any) error {
+						*dest[0].(*json.RawMessage) = json.RawMessage(`{"id":"` + guestID.String() + `","name":"Ann"}`)
+						*dest[1].(*int) = 1
+						return nil
+					},
+				}
+			}
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*int) = 3
+					return nil
+				},
+			}
+		},
+	}
+	h := New(patchTestDB(userID, tx))
+
+	body := `{"version":3,"operations":[{"op":"update","entity":"guest","id":"` + guestID.String() +
+		`","data":{"dietaryRestrictions":["CARNIVORE"]}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"operations[0].data.dietaryRestrictions[0]"`) {
+		t.Errorf("expected field path in response, got %s", w.Body.String())
+	}
+}
+
+// danglingGuestTx stores a guest assigned to a table that doesn't exist and
+// records which guests are written.
+func danglingGuestTx(guestID uuid.UUID, written *[]string) *mockTx {
+	guest := json.RawMessage(`{"id":"` + guestID.String() + `","name":"Ann","dietaryRestrictions":[],` +
+		`"assignedTableId":"` + uuid.NewString() + `","seatPosition":0}`)
+	return &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*int) = 3
+					return nil
+				},
+			}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			if strings.Contains(sql, "SELECT data FROM floor_plan_guests") {
+				return dataRows(guest), nil
+			}
+			return dataRows(), nil
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			if strings.HasPrefix(sql, "INSERT INTO floor_plan_guests") {
+				*written = append(*written, string(args[2].(json.RawMessage)))
+			}
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+}
+
+func TestPatchFloorPlan_InconsistentSeating(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	var written []string
+	h := New(patchTestDB(userID, danglingGuestTx(guestID, &written)))
+
+	body := `{"version":3,"operations":[{"op":"create","entity":"guest","id":"` + guestID.String() +
+		`","data":{"id":"` + guestID.String() + `","name":"Ann","dietaryRestrictions":[]}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "inconsistent seating assignments") {
+		t.Errorf("expected the seating issues, got %s", w.Body.String())
+	}
+}
+
+func TestPatchFloorPlan_Repair(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	var written []string
+	h := New(patchTestDB(userID, danglingGuestTx(guestID, &written)))
+
+	body := `{"version":3,"repair":true,"operations":[{"op":"create","entity":"guest","id":"` + guestID.String() +
+		`","data":{"id":"` + guestID.String() + `","name":"Ann","dietaryRestrictions":[]}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"repairs"`) {
+		t.Errorf("expected the repairs to be reported, got %s", w.Body.String())
+	}
+	if n := len(written); n == 0 || strings.Contains(written[n-1], "assignedTableId\":\"") {
+		t.Errorf("expected the guest saved without its table, got %v", written)
+	}
+}
+
+// eventGuestTx stores a guest that copies an event guest, renamed from Ann
+// to Renamed, in a plan whose event has the event guests in known. It
+// records the guest documents rewritten in place.
+func eventGuestTx(guestID, eventGuestID uuid.UUID, known []uuid.UUID, rewritten *[]string) *mockTx {
+	eventID := uuid.New()
+	guest := json.RawMessage(`{"id":"` + guestID.String() + `","name":"Renamed","dietaryRestrictions":[],` +
+		`"eventGuestId":"` + eventGuestID.String() + `"}`)
+	return &mockTx{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					if strings.Contains(sql, "SELECT event_id") {
+						*dest[0].(**uuid.UUID) = &eventID
+						return nil
+					}
+					*dest[0].(*int) = 3
+					return nil
+				},
+			}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			switch {
+			case strings.Contains(sql, "SELECT data FROM floor_plan_guests"):
+				return dataRows(guest), nil
+			case strings.Contains(sql, "FROM event_guests"):
+				return &mockRows{n: len(known), scan: func(i int, dest ...any) error {
+					*dest[0].(*uuid.UUID) = known[i]
+					*dest[1].(*uuid.UUID) = eventID
+					*dest[2].(*string) = "Ann"
+					*dest[3].(*[]models.DietaryRestriction) = []models.DietaryRestriction{}
+					return nil
+				}}, nil
+			}
+			return dataRows(), nil
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			if strings.HasPrefix(sql, "UPDATE floor_plan_guests") {
+				*rewritten = append(*rewritten, string(args[0].(json.RawMessage)))
+			}
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+}
+
+func TestPatchFloorPlan_EventGuestFields(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+	eventGuestID := uuid.New()
+
+	var rewritten []string
+	h := New(patchTestDB(userID, eventGuestTx(guestID, eventGuestID, []uuid.UUID{eventGuestID}, &rewritten)))
+
+	body := `{"version":3,"operations":[{"op":"create","entity":"guest","id":"` + guestID.String() +
+		`","data":{"id":"` + guestID.String() + `","name":"Renamed","dietaryRestrictions":[],"eventGuestId":"` + eventGuestID.String() + `"}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(rewritten) != 1 || !strings.Contains(rewritten[0], `"name":"Ann"`) {
+		t.Errorf("expected the event's name put back, got %v", rewritten)
+	}
+	var resp models.BulkSaveResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Guests) != 1 {
+		t.Errorf("expected the guests returned, got %s", w.Body.String())
+	}
+}
+
+func TestPatchFloorPlan_UnknownEventGuest(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+	eventGuestID := uuid.New()
+
+	var rewritten []string
+	h := New(patchTestDB(userID, eventGuestTx(guestID, eventGuestID, []uuid.UUID{uuid.New()}, &rewritten)))
+
+	body := `{"version":3,"operations":[{"op":"create","entity":"guest","id":"` + guestID.String() +
+		`","data":{"id":"` + guestID.String() + `","name":"Renamed","dietaryRestrictions":[],"eventGuestId":"` + eventGuestID.String() + `"}}]}`
+	w := httptest.NewRecorder()
+	h.PatchFloorPlan(w, patchRequest(userID, fpID, body))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "not on the event's guest list") {
+		t.Errorf("expected the unknown link reported, got %s", w.Body.String())
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"replace field", `{"a":1,"b":2}`, `{"a":3}`, `{"a":3,"b":2}`},
+		{"remove field", `{"a":1,"b":2}`, `{"b":null}`, `{"a":1}`},
+		{"nullable fields kept", `{"assignedTableId":"t1","guestOf":"g1","seatPosition":0}`,
+			`{"assignedTableId":null,"guestOf":null,"seatPosition":null}`, `{"assignedTableId":null,"guestOf":null,"seatPosition":null}`},
+		{"nullable only at top level", `{"position":{"seatPosition":1}}`, `{"position":{"seatPosition":null}}`, `{"position":{}}`},
+		{"nested object", `{"position":{"x":1,"y":2}}`, `{"position":{"x":5}}`, `{"position":{"x":5,"y":2}}`},
+		{"arrays are replaced", `{"seats":[1,2,3]}`, `{"seats":[4]}`, `{"seats":[4]}`},
+		{"large numbers kept", `{"n":12345678901234567890}`, `{}`, `{"n":12345678901234567890}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergePatch(json.RawMessage(tt.doc), json.RawMessage(tt.patch))
+			if err != nil {
+				t.Fatalf("mergePatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("mergePatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"time"
 
+	"github.com/frallan97/table-planner-backend/internal/events"
 	"github.com/frallan97/table-planner-backend/internal/middleware"
 	"github.com/frallan97/table-planner-backend/internal/models"
 	"github.com/go-chi/chi/v5"
@@ -40,19 +43,37 @@ func (h *Handler) SendPresenceHeartbeat(w http.ResponseWriter, r *http.Request)
 		email = claims.Email
 	}
 
-	// Upsert presence
-	_, err = h.pool.Exec(r.Context(),
-		`INSERT INTO floor_plan_presence (floor_plan_id, user_id, user_email, last_seen_at)
+	// Upsert presence; joined is true unless the user was already active
+	var joined bool
+	err = h.pool.QueryRow(r.Context(),
+		`WITH prev AS (
+			SELECT last_seen_at FROM floor_plan_presence
+			WHERE floor_plan_id = $1 AND user_id = $2
+		 )
+		 INSERT INTO floor_plan_presence (floor_plan_id, user_id, user_email, last_seen_at)
 		 VALUES ($1, $2, $3, NOW())
 		 ON CONFLICT (floor_plan_id, user_id)
-		 DO UPDATE SET user_email = EXCLUDED.user_email, last_seen_at = NOW()`,
+		 DO UPDATE SET user_email = EXCLUDED.user_email, last_seen_at = NOW()
+		 RETURNING NOT EXISTS (SELECT 1 FROM prev WHERE last_seen_at > NOW() - INTERVAL '2 minutes')`,
 		fpID, userID, email,
-	)
+	).Scan(&joined)
 	if err != nil {
 		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
 		return
 	}
 
+	if joined {
+		err = events.Publish(r.Context(), h.pool, events.Event{
+			Type:        events.TypePresenceJoined,
+			FloorPlanID: fpID,
+			UserID:      userID,
+			UserEmail:   email,
+		})
+		if err != nil {
+			log.Printf("Failed to publish presence event: %v", err)
+		}
+	}
+
 	others, err := h.getActivePresence(r.Context(), fpID, userID)
 	if err != nil {
 		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
@@ -62,6 +83,99 @@ func (h *Handler) SendPresenceHeartbeat(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, others)
 }
 
+// LeavePresence removes the caller's presence row so other editors see them leave immediately.
+func (h *Handler) LeavePresence(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(),
+		`DELETE FROM floor_plan_presence WHERE floor_plan_id = $1 AND user_id = $2`,
+		fpID, userID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if tag.RowsAffected() > 0 {
+		err = events.Publish(r.Context(), h.pool, events.Event{
+			Type:        events.TypePresenceLeft,
+			FloorPlanID: fpID,
+			UserID:      userID,
+		})
+		if err != nil {
+			log.Printf("Failed to publish presence event: %v", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// presenceSweepInterval is how often presence rows that stopped sending
+// heartbeats are removed.
+const presenceSweepInterval = time.Minute
+
+// SweepPresence expires stale presence rows until ctx is done, so editors who
+// close the tab without leaving are seen to leave. Every replica may run it;
+// each row is deleted, and its event published, only once.
+func (h *Handler) SweepPresence(ctx context.Context) {
+	ticker := time.NewTicker(presenceSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.expirePresence(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to expire presence: %v", err)
+			}
+		}
+	}
+}
+
+// expirePresence deletes presence rows not seen within the last 2 minutes and
+// publishes presence.left for each.
+func (h *Handler) expirePresence(ctx context.Context) error {
+	rows, err := h.pool.Query(ctx,
+		`DELETE FROM floor_plan_presence
+		 WHERE last_seen_at <= NOW() - INTERVAL '2 minutes'
+		 RETURNING floor_plan_id, user_id, user_email`,
+	)
+	if err != nil {
+		return err
+	}
+	var left []events.Event
+	for rows.Next() {
+		ev := events.Event{Type: events.TypePresenceLeft}
+		if err := rows.Scan(&ev.FloorPlanID, &ev.UserID, &ev.UserEmail); err != nil {
+			rows.Close()
+			return err
+		}
+		left = append(left, ev)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, ev := range left {
+		if err := events.Publish(ctx, h.pool, ev); err != nil {
+			log.Printf("Failed to publish presence event: %v", err)
+		}
+	}
+	return nil
+}
+
 // GetPresence returns the list of other active editors (read-only, no upsert).
 func (h *Handler) GetPresence(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// rowQuerier is satisfied by both DB and pgx.Tx.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// ListRevisions returns the version history of a floor plan, newest first.
+func (h *Handler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT floor_plan_id, version, created_by, created_at, restored_from,
+		        jsonb_array_length(tables), jsonb_array_length(guests), jsonb_array_length(labels)
+		 FROM floor_plan_revisions
+		 WHERE floor_plan_id = $1
+		 ORDER BY version DESC`,
+		fpID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	revisions := []models.FloorPlanRevision{}
+	for rows.Next() {
+		var rev models.FloorPlanRevision
+		err := rows.Scan(&rev.FloorPlanID, &rev.Version, &rev.CreatedBy, &rev.CreatedAt, &rev.RestoredFrom,
+			&rev.TableCount, &rev.GuestCount, &rev.LabelCount)
+		if err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, revisions)
+}
+
+// GetRevision returns a past version of a floor plan including its entities.
+func (h *Handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		http.Error(w, `{"error":"invalid version"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	rev, err := getRevision(r.Context(), h.pool, fpID, version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"revision not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rev)
+}
+
+// RestoreRevision replaces the current entities with those of a past version,
+// committing the result as a new version.
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		http.Error(w, `{"error":"invalid version"}`, http.StatusBadRequest)
+		return
+	}
+
+	canEdit, err := h.canEditFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// Lock the floor plan row so concurrent saves serialize behind the restore
+	var dbVersion int
+	var eventID *uuid.UUID
+	err = tx.QueryRow(r.Context(),
+		`SELECT version, event_id FROM floor_plans WHERE id = $1 FOR UPDATE`, fpID,
+	).Scan(&dbVersion, &eventID)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+
+	rev, err := getRevision(r.Context(), tx, fpID, version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"revision not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Revisions can come from an imported bundle, so check them like a save
+	if errs := models.ValidateEntities(rev.Tables, rev.Guests, rev.Labels); len(errs) > 0 {
+		http.Error(w, `{"error":"revision contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	// The event may have changed since; it keeps what it owns
+	tables, guests, err := dropStaleEventLinks(r.Context(), tx, eventID, rev.Tables, rev.Guests)
+	if errors.Is(err, errInvalidStoredEntities) {
+		http.Error(w, `{"error":"revision contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	changes, err := replaceEntities(r.Context(), tx, fpID, userID, tables, guests, rev.Labels)
+	if err != nil {
+		http.Error(w, `{"error":"failed to restore revision"}`, http.StatusInternalServerError)
+		return
+	}
+	if _, err := enforceEventGuests(r.Context(), tx, fpID, userID, &changes.Guests); err != nil {
+		respondEventGuestError(w, err)
+		return
+	}
+
+	newVersion, err := commitVersion(r.Context(), tx, fpID, userID, changes, &version)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.BulkSaveResponse{Status: "restored", Version: newVersion})
+}
+
+// getRevision loads a single revision snapshot.
+func getRevision(ctx context.Context, q rowQuerier, fpID uuid.UUID, version int) (*models.FloorPlanRevisionFull, error) {
+	var rev models.FloorPlanRevisionFull
+	err := q.QueryRow(ctx,
+		`SELECT floor_plan_id, version, created_by, created_at, restored_from,
+		        jsonb_array_length(tables), jsonb_array_length(guests), jsonb_array_length(labels),
+		        tables, guests, labels
+		 FROM floor_plan_revisions
+		 WHERE floor_plan_id = $1 AND version = $2`,
+		fpID, version,
+	).Scan(&rev.FloorPlanID, &rev.Version, &rev.CreatedBy, &rev.CreatedAt, &rev.RestoredFrom,
+		&rev.TableCount, &rev.GuestCount, &rev.LabelCount,
+		&rev.Tables, &rev.Guests, &rev.Labels)
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func withChiParams(r *http.Request, kv ...string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(kv); i += 2 {
+		rctx.URLParams.Add(kv[i], kv[i+1])
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestGetRevision_InvalidVersion(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	h := New(&mockDB{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/revisions/abc", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParams(req, "id", fpID.String(), "version", "abc")
+	w := httptest.NewRecorder()
+
+	h.GetRevision(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetRevision_NotFound(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			if strings.Contains(sql, "floor_plan_revisions") {
+				return &mockRow{err: pgx.ErrNoRows}
+			}
+			// canViewFloorPlan — return creator = userID
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = userID
+					return nil
+				},
+			}
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/floor-plans/"+fpID.String()+"/revisions/3", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParams(req, "id", fpID.String(), "version", "3")
+	w := httptest.NewRecorder()
+
+	h.GetRevision(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRestoreRevision_Forbidden(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			// canEditFloorPlan — personal plan owned by someone else
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = uuid.New()
+					return nil
+				},
+			}
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/revisions/2/restore", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParams(req, "id", fpID.String(), "version", "2")
+	w := httptest.NewRecorder()
+
+	h.RestoreRevision(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestRestoreRevision_Success(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	tableID := uuid.New()
+
+	var upserted []uuid.UUID
+	var revisionArgs []any
+
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = userID
+					return nil
+				},
+			}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					switch {
+					case strings.Contains(sql, "FROM floor_plan_revisions"):
+						return &mockRow{
+							scanFunc: func(dest ...any) error {
+								*dest[1].(*int) = 2
+								*dest[8].(*[]json.RawMessage) = []json.RawMessage{
+									json.RawMessage(`{"id":"` + tableID.String() + `","name":"Head","tableType":"ROUND","capacity":1,"seats":[{"position":0,"guestId":null}],"assignedGuests":[]}`),
+								}
+								return nil
+							},
+						}
+					case strings.Contains(sql, "RETURNING version"):
+						return &mockRow{
+							scanFunc: func(dest ...any) error {
+								*dest[0].(*int) = 6
+								return nil
+							},
+						}
+					default:
+						// SELECT version ... FOR UPDATE
+						return &mockRow{
+							scanFunc: func(dest ...any) error {
+								*dest[0].(*int) = 5
+								return nil
+							},
+						}
+					}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return &emptyRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					switch {
+					case strings.Contains(sql, "INSERT INTO floor_plan_tables"):
+						upserted = append(upserted, args[0].(uuid.UUID))
+						return pgconn.NewCommandTag("INSERT 0 1"), nil
+					case strings.Contains(sql, "INSERT INTO floor_plan_revisions"):
+						revisionArgs = args
+					}
+					return pgconn.NewCommandTag(""), nil
+				},
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/revisions/2/restore", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParams(req, "id", fpID.String(), "version", "2")
+	w := httptest.NewRecorder()
+
+	h.RestoreRevision(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp["status"] != "restored" || int(resp["version"].(float64)) != 6 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	if len(upserted) != 1 || upserted[0] != tableID {
+		t.Errorf("expected table %s to be restored, got %v", tableID, upserted)
+	}
+	if len(revisionArgs) < 4 || revisionArgs[1] != 6 || *revisionArgs[3].(*int) != 2 {
+		t.Errorf("expected revision 6 restored from 2, got %v", revisionArgs)
+	}
+}
+
+func TestRestoreRevision_InvalidEntities(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	committed := false
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						if strings.Contains(sql, "FROM floor_plan_revisions") {
+							// Planted by a crafted bundle
+							*dest[8].(*[]json.RawMessage) = []json.RawMessage{json.RawMessage(`{"id":"nope"}`)}
+							return nil
+						}
+						*dest[0].(*int) = 5
+						return nil
+					}}
+				},
+				commitFunc: func(ctx context.Context) error {
+					committed = true
+					return nil
+				},
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/revisions/2/restore", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParams(req, "id", fpID.String(), "version", "2")
+	w := httptest.NewRecorder()
+
+	h.RestoreRevision(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	if committed {
+		t.Error("expected nothing committed")
+	}
+}
+
+func TestRestoreRevision_PlanLeftEvent(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guest := json.RawMessage(`{"id":"` + uuid.NewString() + `","name":"Ann","dietaryRestrictions":[],"assignedTableId":null,"seatPosition":null,` +
+		`"eventGuestId":"` + uuid.NewString() + `"}`)
+
+	var restored json.RawMessage
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						if strings.Contains(sql, "FROM floor_plan_revisions") {
+							// Saved while the plan was part of an event
+							*dest[9].(*[]json.RawMessage) = []json.RawMessage{guest}
+							return nil
+						}
+						// The plan has no event now
+						*dest[0].(*int) = 5
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return &emptyRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					if strings.Contains(sql, "INSERT INTO floor_plan_guests") {
+						restored = args[2].(json.RawMessage)
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans/"+fpID.String()+"/revisions/2/restore", nil)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParams(req, "id", fpID.String(), "version", "2")
+	w := httptest.NewRecorder()
+
+	h.RestoreRevision(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if restored == nil || strings.Contains(string(restored), "eventGuestId") {
+		t.Errorf("expected the guest restored without its event link, got %s", restored)
+	}
+}
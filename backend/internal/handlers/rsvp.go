@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/events"
+	"github.com/frallan97/table-planner-backend/internal/eventsync"
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// rsvpTokenTTL is how long a personal RSVP link stays valid.
+const rsvpTokenTTL = 90 * 24 * time.Hour
+
+// CreateRSVPTokens creates personal RSVP links for guests of a floor plan.
+// A guest's previous link stops working.
+func (h *Handler) CreateRSVPTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.CreateRSVPTokensRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	items, err := queryEntityData(r.Context(), tx, "floor_plan_guests", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	names, err := guestNames(items)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	for _, id := range req.GuestIDs {
+		if _, ok := names[id.String()]; !ok {
+			http.Error(w, `{"error":"guest `+id.String()+` not found"}`, http.StatusNotFound)
+			return
+		}
+	}
+
+	_, err = tx.Exec(r.Context(),
+		`UPDATE guest_rsvp_tokens SET is_active = false
+		 WHERE floor_plan_id = $1 AND guest_id = ANY($2) AND is_active = true`,
+		fpID, req.GuestIDs,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	expiresAt := time.Now().Add(rsvpTokenTTL)
+	tokens := make([]models.RSVPToken, 0, len(req.GuestIDs))
+	for _, id := range req.GuestIDs {
+		token, err := newToken()
+		if err != nil {
+			http.Error(w, `{"error":"failed to generate token"}`, http.StatusInternalServerError)
+			return
+		}
+		_, err = tx.Exec(r.Context(),
+			`INSERT INTO guest_rsvp_tokens (floor_plan_id, guest_id, token, created_by, expires_at)
+			 VALUES ($1, $2, $3, $4, $5)`,
+			fpID, id, token, userID, expiresAt,
+		)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		tokens = append(tokens, models.RSVPToken{GuestID: id, GuestName: names[id.String()], Token: token, ExpiresAt: expiresAt})
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// RevokeRSVPToken deactivates a guest's RSVP link.
+func (h *Handler) RevokeRSVPToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+	guestID, err := uuid.Parse(chi.URLParam(r, "guestId"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid guest ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canEdit, err := h.canEditFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	_, err = h.pool.Exec(r.Context(),
+		`UPDATE guest_rsvp_tokens SET is_active = false
+		 WHERE floor_plan_id = $1 AND guest_id = $2 AND is_active = true`,
+		fpID, guestID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// GetRSVPSummary returns the RSVP state of every guest of a floor plan with
+// counts of the answers.
+func (h *Handler) GetRSVPSummary(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	items, err := h.getEntityData(r.Context(), "floor_plan_guests", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT guest_id, token, expires_at, responded_at FROM guest_rsvp_tokens
+		 WHERE floor_plan_id = $1 AND is_active = true
+		   AND (expires_at IS NULL OR expires_at > NOW())`,
+		fpID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	links := map[string]models.RSVPGuest{}
+	for rows.Next() {
+		var guestID uuid.UUID
+		var link models.RSVPGuest
+		if err := rows.Scan(&guestID, &link.Token, &link.ExpiresAt, &link.RespondedAt); err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		links[guestID.String()] = link
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	summary, err := rsvpSummary(items, links)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, summary)
+}
+
+// rsvpSummary combines guest documents with their active RSVP links, keyed
+// by guest ID.
+func rsvpSummary(items []json.RawMessage, links map[string]models.RSVPGuest) (models.RSVPSummary, error) {
+	summary := models.RSVPSummary{Total: len(items), Guests: make([]models.RSVPGuest, 0, len(items))}
+	for _, item := range items {
+		var g models.Guest
+		if err := json.Unmarshal(item, &g); err != nil {
+			return summary, errInvalidStoredEntities
+		}
+		entry := links[g.ID]
+		entry.GuestID, entry.Name, entry.Status = g.ID, g.Name, g.RSVPStatus
+		switch {
+		case g.RSVPStatus == models.RSVPAttending:
+			summary.Attending++
+		case g.RSVPStatus == models.RSVPDeclined:
+			summary.Declined++
+		case entry.Token != nil:
+			summary.Pending++
+		default:
+			summary.NotInvited++
+		}
+		summary.Guests = append(summary.Guests, entry)
+	}
+	return summary, nil
+}
+
+// rsvpLink is an active, non-expired RSVP token.
+type rsvpLink struct {
+	floorPlanID uuid.UUID
+	guestID     uuid.UUID
+	createdBy   uuid.UUID
+	expiresAt   *time.Time
+	respondedAt *time.Time
+}
+
+func lookupRSVPToken(ctx context.Context, q rowQuerier, token string) (rsvpLink, error) {
+	var l rsvpLink
+	err := q.QueryRow(ctx,
+		`SELECT floor_plan_id, guest_id, created_by, expires_at, responded_at FROM guest_rsvp_tokens
+		 WHERE token = $1 AND is_active = true
+		   AND (expires_at IS NULL OR expires_at > NOW())`,
+		token,
+	).Scan(&l.floorPlanID, &l.guestID, &l.createdBy, &l.expiresAt, &l.respondedAt)
+	return l, err
+}
+
+// GetRSVP shows a guest their invitation and current answer (no auth required).
+func (h *Handler) GetRSVP(w http.ResponseWriter, r *http.Request) {
+	link, err := lookupRSVPToken(r.Context(), h.pool, chi.URLParam(r, "token"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+		return
+	}
+
+	inv := models.RSVPInvitation{RespondedAt: link.respondedAt, ExpiresAt: link.expiresAt}
+	var data json.RawMessage
+	err = h.pool.QueryRow(r.Context(),
+		`SELECT fp.name, g.data FROM floor_plans fp
+		 JOIN floor_plan_guests g ON g.floor_plan_id = fp.id
+		 WHERE fp.id = $1 AND g.id = $2`,
+		link.floorPlanID, link.guestID,
+	).Scan(&inv.FloorPlanName, &data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	var g models.Guest
+	if err := json.Unmarshal(data, &g); err != nil {
+		http.Error(w, `{"error":"invalid guest"}`, http.StatusInternalServerError)
+		return
+	}
+	inv.GuestName, inv.Status, inv.DietaryRestrictions = g.Name, g.RSVPStatus, g.DietaryRestrictions
+	if inv.DietaryRestrictions == nil {
+		inv.DietaryRestrictions = []models.DietaryRestriction{}
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// SubmitRSVP records a guest's answer and dietary restrictions in their
+// floor plan as a new version (no auth required). The change is attributed
+// to whoever created the link. Dietary restrictions of a copy of an event
+// guest are written to the event guest and synced to the event's plans from
+// there. An answer that changes nothing saves no version.
+func (h *Handler) SubmitRSVP(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAndValidate[models.RSVPRequest](r, w)
+	if !ok {
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	token := chi.URLParam(r, "token")
+	link, err := lookupRSVPToken(r.Context(), tx, token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+		return
+	}
+
+	g, eventID, err := loadRSVPGuest(r.Context(), tx, link)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The guest was removed from the plan after the link was sent
+		http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	dietary := req.DietaryRestrictions
+	if dietary != nil && eventID != nil && g.EventGuestID != nil {
+		// The event owns the guest's dietary restrictions. Lock it before the
+		// plan, in the order event guest edits do.
+		if !slices.Equal(*dietary, g.DietaryRestrictions) {
+			if status, err := updateRSVPEventGuest(r.Context(), tx, *eventID, *g.EventGuestID, link.createdBy, *dietary); err != nil {
+				if status == http.StatusNotFound {
+					http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+					return
+				}
+				http.Error(w, `{"error":"failed to record RSVP"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+		dietary = nil
+	}
+
+	// Lock the plan as any other save does
+	var dbVersion int
+	err = tx.QueryRow(r.Context(),
+		`SELECT version FROM floor_plans WHERE id = $1 FOR UPDATE`, link.floorPlanID,
+	).Scan(&dbVersion)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+		return
+	}
+
+	// Reread the guest now the plan is locked
+	g, _, err = loadRSVPGuest(r.Context(), tx, link)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	fields := map[string]any{}
+	if g.RSVPStatus != req.Status {
+		fields["rsvpStatus"] = req.Status
+	}
+	if dietary != nil && !slices.Equal(*dietary, g.DietaryRestrictions) {
+		fields["dietaryRestrictions"] = *dietary
+	}
+	if len(fields) > 0 {
+		patch, _ := json.Marshal(fields)
+		changes := events.Changes{
+			Tables: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+			Guests: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+			Labels: events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}},
+		}
+		op := models.EntityOperation{Op: models.OpUpdate, Entity: models.EntityGuest, ID: link.guestID, Data: patch}
+		if err := applyOperation(r.Context(), tx, link.floorPlanID, link.createdBy, op, &changes); err != nil {
+			var opErr *operationError
+			if errors.As(err, &opErr) && opErr.status == http.StatusNotFound {
+				http.Error(w, `{"error":"invalid or expired RSVP link"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error":"failed to record RSVP"}`, http.StatusInternalServerError)
+			return
+		}
+		if _, err := commitVersion(r.Context(), tx, link.floorPlanID, link.createdBy, changes, nil); err != nil {
+			http.Error(w, `{"error":"failed to record RSVP"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	_, err = tx.Exec(r.Context(),
+		`UPDATE guest_rsvp_tokens SET responded_at = NOW() WHERE token = $1`, token,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"status": "recorded", "rsvpStatus": req.Status})
+}
+
+// loadRSVPGuest returns the guest an RSVP link is for and the event of their
+// floor plan, if it has one.
+func loadRSVPGuest(ctx context.Context, q rowQuerier, link rsvpLink) (models.Guest, *uuid.UUID, error) {
+	var g models.Guest
+	var data json.RawMessage
+	var eventID *uuid.UUID
+	err := q.QueryRow(ctx,
+		`SELECT g.data, fp.event_id FROM floor_plan_guests g
+		 JOIN floor_plans fp ON fp.id = g.floor_plan_id
+		 WHERE g.floor_plan_id = $1 AND g.id = $2`,
+		link.floorPlanID, link.guestID,
+	).Scan(&data, &eventID)
+	if err != nil {
+		return g, nil, err
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return g, nil, errInvalidStoredEntities
+	}
+	return g, eventID, nil
+}
+
+// updateRSVPEventGuest sets an event guest's dietary restrictions and syncs
+// them to every plan of the event. It returns 404 when the event or guest is
+// gone.
+func updateRSVPEventGuest(ctx context.Context, tx pgx.Tx, eventID uuid.UUID, eventGuestID string, userID uuid.UUID, dietary []models.DietaryRestriction) (int, error) {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT id FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	data, _ := json.Marshal(dietary)
+	var g models.EventGuest
+	err = scanEventGuest(tx.QueryRow(ctx,
+		`UPDATE event_guests SET dietary_restrictions = $1, updated_at = NOW()
+		 WHERE id = $2 AND event_id = $3
+		 RETURNING `+eventGuestColumns,
+		data, eventGuestID, eventID,
+	), &g)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	_, err = syncEventPlans(ctx, tx, eventID, userID, func(tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, bool, error) {
+		guests, changed, err := eventsync.Sync(guests, []models.EventGuest{g}, false, time.Now())
+		return tables, guests, changed, err
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// guestNames maps the IDs of guest documents to their names.
+func guestNames(items []json.RawMessage) (map[string]string, error) {
+	names := make(map[string]string, len(items))
+	for _, item := range items {
+		var g models.Guest
+		if err := json.Unmarshal(item, &g); err != nil {
+			return nil, errInvalidStoredEntities
+		}
+		names[g.ID] = g.Name
+	}
+	return names, nil
+}
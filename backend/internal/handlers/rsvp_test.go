@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRSVPSummary(t *testing.T) {
+	ids := []string{uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()}
+	items := []json.RawMessage{
+		json.RawMessage(`{"id":"` + ids[0] + `","name":"Ann","rsvpStatus":"ATTENDING"}`),
+		json.RawMessage(`{"id":"` + ids[1] + `","name":"Bo","rsvpStatus":"DECLINED"}`),
+		json.RawMessage(`{"id":"` + ids[2] + `","name":"Cy"}`),
+		json.RawMessage(`{"id":"` + ids[3] + `","name":"Di"}`),
+	}
+	token := "tok"
+	links := map[string]models.RSVPGuest{ids[0]: {Token: &token}, ids[2]: {Token: &token}}
+
+	summary, err := rsvpSummary(items, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Total != 4 || summary.Attending != 1 || summary.Declined != 1 || summary.Pending != 1 || summary.NotInvited != 1 {
+		t.Errorf("unexpected counts %+v", summary)
+	}
+	if g := summary.Guests[2]; g.GuestID != ids[2] || g.Name != "Cy" || g.Token == nil {
+		t.Errorf("expected the pending guest with their link, got %+v", g)
+	}
+}
+
+func TestSubmitRSVP(t *testing.T) {
+	fpID := uuid.New()
+	guestID := uuid.New()
+	plannerID := uuid.New()
+
+	var saved json.RawMessage
+	var updatedBy any
+	responded := false
+	h := New(&mockDB{
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						switch {
+						case strings.Contains(sql, "FROM guest_rsvp_tokens"):
+							*dest[0].(*uuid.UUID) = fpID
+							*dest[1].(*uuid.UUID) = guestID
+							*dest[2].(*uuid.UUID) = plannerID
+						case strings.Contains(sql, "FROM floor_plan_guests"):
+							*dest[0].(*json.RawMessage) = json.RawMessage(`{"id":"` + guestID.String() + `","name":"Ann","dietaryRestrictions":[],"assignedTableId":null,"seatPosition":null}`)
+						case strings.Contains(sql, "UPDATE floor_plans"):
+							*dest[0].(*int) = 6
+						default:
+							*dest[0].(*int) = 5
+						}
+						return nil
+					}}
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					switch {
+					case strings.Contains(sql, "UPDATE floor_plan_guests"):
+						saved, updatedBy = args[0].(json.RawMessage), args[1]
+					case strings.Contains(sql, "SET responded_at"):
+						responded = true
+					}
+					return pgconn.NewCommandTag("UPDATE 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"status":"ATTENDING","dietaryRestrictions":["VEGETARIAN"]}`
+	req := httptest.NewRequest(http.MethodPost, "/public/rsvp/tok", strings.NewReader(body))
+	req = withChiParam(req, "token", "tok")
+	w := httptest.NewRecorder()
+
+	h.SubmitRSVP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var g models.Guest
+	json.Unmarshal(saved, &g)
+	if g.RSVPStatus != models.RSVPAttending || len(g.DietaryRestrictions) != 1 || g.DietaryRestrictions[0] != models.DietaryVegetarian {
+		t.Errorf("expected the answer on the guest, got %s", saved)
+	}
+	if updatedBy != plannerID {
+		t.Errorf("expected the change attributed to the link's creator, got %v", updatedBy)
+	}
+	if !responded {
+		t.Error("expected the link to be marked as answered")
+	}
+}
+
+func TestSubmitRSVP_InvalidToken(t *testing.T) {
+	h := New(&mockDB{
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{err: pgx.ErrNoRows}
+				},
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/public/rsvp/nope", strings.NewReader(`{"status":"DECLINED"}`))
+	req = withChiParam(req, "token", "nope")
+	w := httptest.NewRecorder()
+
+	h.SubmitRSVP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSubmitRSVP_Unchanged(t *testing.T) {
+	fpID := uuid.New()
+	guestID := uuid.New()
+
+	wrote := false
+	h := New(&mockDB{
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						switch {
+						case strings.Contains(sql, "FROM guest_rsvp_tokens"):
+							*dest[0].(*uuid.UUID) = fpID
+							*dest[1].(*uuid.UUID) = guestID
+						case strings.Contains(sql, "FROM floor_plan_guests"):
+							*dest[0].(*json.RawMessage) = json.RawMessage(`{"id":"` + guestID.String() + `","name":"Ann","dietaryRestrictions":["VEGAN"],"rsvpStatus":"ATTENDING"}`)
+						case strings.Contains(sql, "UPDATE floor_plans"):
+							wrote = true
+						default:
+							*dest[0].(*int) = 5
+						}
+						return nil
+					}}
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					if strings.Contains(sql, "UPDATE floor_plan_guests") {
+						wrote = true
+					}
+					return pgconn.NewCommandTag("UPDATE 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"status":"ATTENDING","dietaryRestrictions":["VEGAN"]}`
+	req := httptest.NewRequest(http.MethodPost, "/public/rsvp/tok", strings.NewReader(body))
+	req = withChiParam(req, "token", "tok")
+	w := httptest.NewRecorder()
+
+	h.SubmitRSVP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if wrote {
+		t.Error("expected an unchanged answer to save no version")
+	}
+}
+
+func TestSubmitRSVP_EventGuestDietary(t *testing.T) {
+	fpID := uuid.New()
+	guestID := uuid.New()
+	eventID := uuid.New()
+	eventGuestID := uuid.New()
+	doc := `{"id":"` + guestID.String() + `","name":"Ann","dietaryRestrictions":[],"eventGuestId":"` + eventGuestID.String() + `"}`
+
+	var eventDietary string
+	var synced, answered []string
+	h := New(&mockDB{
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						switch {
+						case strings.Contains(sql, "FROM guest_rsvp_tokens"):
+							*dest[0].(*uuid.UUID) = fpID
+							*dest[1].(*uuid.UUID) = guestID
+						case strings.Contains(sql, "JOIN floor_plans"):
+							*dest[0].(*json.RawMessage) = json.RawMessage(doc)
+							*dest[1].(**uuid.UUID) = &eventID
+						case strings.Contains(sql, "FROM floor_plan_guests"):
+							*dest[0].(*json.RawMessage) = json.RawMessage(doc)
+						case strings.Contains(sql, "FROM events"):
+							*dest[0].(*uuid.UUID) = eventID
+						case strings.Contains(sql, "UPDATE event_guests"):
+							eventDietary = string(args[0].([]byte))
+							*dest[0].(*uuid.UUID) = eventGuestID
+							*dest[1].(*uuid.UUID) = eventID
+							*dest[2].(*string) = "Ann"
+							json.Unmarshal(args[0].([]byte), dest[3])
+						case strings.Contains(sql, "UPDATE floor_plans"):
+							*dest[0].(*int) = 6
+						default:
+							*dest[0].(*int) = 5
+						}
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					switch {
+					case strings.Contains(sql, "WHERE event_id"):
+						return &mockRows{n: 1, scan: func(i int, dest ...any) error {
+							*dest[0].(*uuid.UUID) = fpID
+							*dest[1].(*string) = "Reception"
+							return nil
+						}}, nil
+					case strings.Contains(sql, "SELECT data FROM floor_plan_guests"):
+						return dataRows(json.RawMessage(doc)), nil
+					case strings.Contains(sql, "SELECT data"):
+						return dataRows(), nil
+					}
+					return &mockRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					switch {
+					case strings.Contains(sql, "INSERT INTO floor_plan_guests"):
+						synced = append(synced, string(args[2].(json.RawMessage)))
+					case strings.Contains(sql, "UPDATE floor_plan_guests"):
+						answered = append(answered, string(args[0].(json.RawMessage)))
+					}
+					return pgconn.NewCommandTag("UPDATE 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"status":"ATTENDING","dietaryRestrictions":["VEGAN"]}`
+	req := httptest.NewRequest(http.MethodPost, "/public/rsvp/tok", strings.NewReader(body))
+	req = withChiParam(req, "token", "tok")
+	w := httptest.NewRecorder()
+
+	h.SubmitRSVP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if eventDietary != `["VEGAN"]` {
+		t.Errorf("expected the dietary restrictions written to the event guest, got %q", eventDietary)
+	}
+	if len(synced) != 1 || !strings.Contains(synced[0], `"VEGAN"`) {
+		t.Errorf("expected the event guest synced to the plan, got %v", synced)
+	}
+	if len(answered) != 1 || !strings.Contains(answered[0], `"rsvpStatus":"ATTENDING"`) || strings.Contains(answered[0], `"VEGAN"`) {
+		t.Errorf("expected only the answer written to the plan's guest, got %v", answered)
+	}
+}
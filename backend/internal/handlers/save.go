@@ -3,11 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/frallan97/table-planner-backend/internal/events"
 	"github.com/frallan97/table-planner-backend/internal/middleware"
 	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/seating"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -48,6 +51,29 @@ func (h *Handler) BulkSave(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	if errs := models.ValidateEntities(req.Tables, req.Guests, req.Labels); len(errs) > 0 {
+		respondFieldErrors(w, errs)
+		return
+	}
+
+	// Seats and guest assignments must agree; repair mode fixes them instead
+	reconciled, err := seating.Reconcile(req.Tables, req.Guests)
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan data"}`, http.StatusBadRequest)
+		return
+	}
+	var repairs []models.FieldError
+	if len(reconciled.Issues) > 0 {
+		if !req.Repair {
+			respondJSON(w, http.StatusBadRequest, map[string]any{
+				"error":  "inconsistent seating assignments",
+				"issues": reconciled.Issues,
+			})
+			return
+		}
+		req.Tables, req.Guests = reconciled.Tables, reconciled.Guests
+		repairs = reconciled.Issues
+	}
 
 	tx, err := h.pool.Begin(r.Context())
 	if err != nil {
@@ -58,65 +84,243 @@ func (h *Handler) BulkSave(w http.ResponseWriter, r *http.Request) {
 
 	// Optimistic concurrency: check version under row lock
 	var dbVersion int
+	var roomID *uuid.UUID
 	err = tx.QueryRow(r.Context(),
-		`SELECT version FROM floor_plans WHERE id = $1 FOR UPDATE`, fpID,
-	).Scan(&dbVersion)
+		`SELECT version, room_id FROM floor_plans WHERE id = $1 FOR UPDATE`, fpID,
+	).Scan(&dbVersion, &roomID)
 	if err != nil {
 		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
 		return
 	}
 
-	if req.Version != dbVersion {
-		// Version conflict — return current data so the client can reconcile
-		tables, _ := h.getEntityData(r.Context(), "floor_plan_tables", fpID)
-		guests, _ := h.getEntityData(r.Context(), "floor_plan_guests", fpID)
-		labels, _ := h.getEntityData(r.Context(), "floor_plan_labels", fpID)
-		respondJSON(w, http.StatusConflict, map[string]any{
-			"error":   "version conflict",
-			"version": dbVersion,
-			"tables":  tables,
-			"guests":  guests,
-			"labels":  labels,
-		})
+	status := "saved"
+	var changes events.Changes
+	if req.EntityVersions != nil {
+		// Per-entity concurrency: only entities changed on both sides conflict
+		var conflicts models.MergeConflicts
+		changes, conflicts, err = saveVersionedEntities(r.Context(), tx, fpID, userID, req)
+		if err != nil {
+			http.Error(w, `{"error":"failed to save"}`, http.StatusInternalServerError)
+			return
+		}
+		if !conflicts.Empty() {
+			h.respondEntityConflict(w, r, tx, fpID, dbVersion, conflicts)
+			return
+		}
+		if req.Version != dbVersion {
+			status = "merged"
+			fixed, err := settleStoredMerge(r.Context(), tx, fpID, userID, &changes)
+			if errors.Is(err, errInvalidMerge) {
+				h.respondVersionConflict(w, r, fpID, dbVersion)
+				return
+			}
+			if err != nil {
+				http.Error(w, `{"error":"failed to save"}`, http.StatusInternalServerError)
+				return
+			}
+			repairs = append(repairs, fixed...)
+		}
+	} else {
+		if req.Version != dbVersion {
+			// Stale base: try to merge the client's edits into the current state.
+			// Without the base revision we can't tell who changed what.
+			merge, err := threeWayMerge(r.Context(), tx, fpID, req.Version, req)
+			if err != nil {
+				h.respondVersionConflict(w, r, fpID, dbVersion)
+				return
+			}
+			if !merge.Conflicts.Empty() {
+				respondJSON(w, http.StatusConflict, map[string]any{
+					"error":     "version conflict",
+					"version":   dbVersion,
+					"conflicts": merge.Conflicts,
+					"tables":    merge.Tables,
+					"guests":    merge.Guests,
+					"labels":    merge.Labels,
+				})
+				return
+			}
+			var fixed []models.FieldError
+			if req.Tables, req.Guests, fixed, err = settleMerge(merge.Tables, merge.Guests, merge.Labels); err != nil {
+				h.respondVersionConflict(w, r, fpID, dbVersion)
+				return
+			}
+			req.Labels = merge.Labels
+			repairs = append(repairs, fixed...)
+			status = "merged"
+		}
+
+		// Upsert each entity type
+		if changes.Tables, err = upsertEntities(r.Context(), tx, "floor_plan_tables", fpID, userID, req.Tables); err != nil {
+			http.Error(w, `{"error":"failed to save tables"}`, http.StatusInternalServerError)
+			return
+		}
+		if changes.Guests, err = upsertEntities(r.Context(), tx, "floor_plan_guests", fpID, userID, req.Guests); err != nil {
+			http.Error(w, `{"error":"failed to save guests"}`, http.StatusInternalServerError)
+			return
+		}
+		if changes.Labels, err = upsertEntities(r.Context(), tx, "floor_plan_labels", fpID, userID, req.Labels); err != nil {
+			http.Error(w, `{"error":"failed to save labels"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Edits to what the event owns don't stick
+	enforced, err := enforceEventGuests(r.Context(), tx, fpID, userID, &changes.Guests)
+	if err != nil {
+		respondEventGuestError(w, err)
 		return
 	}
 
-	// Upsert each entity type
-	if err := upsertEntities(r.Context(), tx, "floor_plan_tables", fpID, req.Tables); err != nil {
-		http.Error(w, `{"error":"failed to save tables"}`, http.StatusInternalServerError)
+	newVersion, err := commitVersion(r.Context(), tx, fpID, userID, changes, nil)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
 		return
 	}
-	if err := upsertEntities(r.Context(), tx, "floor_plan_guests", fpID, req.Guests); err != nil {
-		http.Error(w, `{"error":"failed to save guests"}`, http.StatusInternalServerError)
+
+	resp := models.BulkSaveResponse{Status: status, Version: newVersion, Repairs: repairs}
+	if req.EntityVersions != nil {
+		versions, err := loadEntityVersions(r.Context(), tx, fpID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		resp.EntityVersions = &versions
+	}
+	if status == "merged" {
+		// The client's copy is missing the other side's changes
+		if req.EntityVersions != nil {
+			if resp.Tables, resp.Guests, resp.Labels, err = queryPlanEntities(r.Context(), tx, fpID); err != nil {
+				http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+				return
+			}
+		} else {
+			resp.Tables, resp.Guests, resp.Labels = orEmpty(req.Tables), orEmpty(req.Guests), orEmpty(req.Labels)
+		}
+	} else if len(repairs) > 0 {
+		// The client's copy is missing the repairs
+		resp.Tables, resp.Guests, resp.Labels = orEmpty(req.Tables), orEmpty(req.Guests), orEmpty(req.Labels)
+	}
+	if enforced {
+		// The client's copy still has the edits to event guests
+		if resp.Tables, resp.Guests, resp.Labels, err = queryPlanEntities(r.Context(), tx, fpID); err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Layout problems don't block saving; the planner may still be moving tables
+	saved := req.Tables
+	if resp.Tables != nil {
+		saved = resp.Tables
+	}
+	if resp.Warnings, err = layoutWarnings(r.Context(), tx, roomID, saved); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
 		return
 	}
-	if err := upsertEntities(r.Context(), tx, "floor_plan_labels", fpID, req.Labels); err != nil {
-		http.Error(w, `{"error":"failed to save labels"}`, http.StatusInternalServerError)
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
 		return
 	}
 
-	// Update timestamp and increment version
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// respondVersionConflict returns 409 with the current data so the client can reconcile.
+func (h *Handler) respondVersionConflict(w http.ResponseWriter, r *http.Request, fpID uuid.UUID, dbVersion int) {
+	tables, _ := h.getEntityData(r.Context(), "floor_plan_tables", fpID)
+	guests, _ := h.getEntityData(r.Context(), "floor_plan_guests", fpID)
+	labels, _ := h.getEntityData(r.Context(), "floor_plan_labels", fpID)
+	respondJSON(w, http.StatusConflict, map[string]any{
+		"error":   "version conflict",
+		"version": dbVersion,
+		"tables":  tables,
+		"guests":  guests,
+		"labels":  labels,
+	})
+}
+
+// respondEntityConflict returns 409 listing the entities that were changed
+// concurrently, along with the current data and entity versions.
+func (h *Handler) respondEntityConflict(w http.ResponseWriter, r *http.Request, tx pgx.Tx, fpID uuid.UUID, dbVersion int, conflicts models.MergeConflicts) {
+	tables, _ := queryEntityData(r.Context(), tx, "floor_plan_tables", fpID)
+	guests, _ := queryEntityData(r.Context(), tx, "floor_plan_guests", fpID)
+	labels, _ := queryEntityData(r.Context(), tx, "floor_plan_labels", fpID)
+	versions, _ := loadEntityVersions(r.Context(), tx, fpID)
+	respondJSON(w, http.StatusConflict, map[string]any{
+		"error":          "version conflict",
+		"version":        dbVersion,
+		"conflicts":      conflicts,
+		"tables":         tables,
+		"guests":         guests,
+		"labels":         labels,
+		"entityVersions": versions,
+	})
+}
+
+// commitVersion increments the floor plan version, records the resulting entity
+// state as a revision and queues a saved event for subscribers. The caller must
+// hold the floor_plans row lock and commit the transaction.
+func commitVersion(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, changes events.Changes, restoredFrom *int) (int, error) {
 	var newVersion int
-	err = tx.QueryRow(r.Context(),
+	err := tx.QueryRow(ctx,
 		`UPDATE floor_plans SET updated_at = NOW(), version = version + 1 WHERE id = $1 RETURNING version`, fpID,
 	).Scan(&newVersion)
 	if err != nil {
-		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
-		return
+		return 0, err
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
-		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
-		return
+	_, err = tx.Exec(ctx,
+		`INSERT INTO floor_plan_revisions (floor_plan_id, version, created_by, restored_from, tables, guests, labels)
+		 SELECT $1, $2, $3, $4,
+		        COALESCE((SELECT jsonb_agg(data) FROM floor_plan_tables WHERE floor_plan_id = $1), '[]'),
+		        COALESCE((SELECT jsonb_agg(data) FROM floor_plan_guests WHERE floor_plan_id = $1), '[]'),
+		        COALESCE((SELECT jsonb_agg(data) FROM floor_plan_labels WHERE floor_plan_id = $1), '[]')`,
+		fpID, newVersion, userID, restoredFrom,
+	)
+	if err != nil {
+		return 0, err
 	}
 
-	respondJSON(w, http.StatusOK, models.BulkSaveResponse{Status: "saved", Version: newVersion})
+	// Delivered by Postgres only once the transaction commits
+	err = events.Publish(ctx, tx, events.Event{
+		Type:        events.TypeSaved,
+		FloorPlanID: fpID,
+		UserID:      userID,
+		Version:     newVersion,
+		Changes:     &changes,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newVersion, nil
+}
+
+// replaceEntities replaces all tables, guests and labels of a floor plan.
+func replaceEntities(ctx context.Context, tx pgx.Tx, fpID, userID uuid.UUID, tables, guests, labels []json.RawMessage) (events.Changes, error) {
+	var changes events.Changes
+	var err error
+	if changes.Tables, err = upsertEntities(ctx, tx, "floor_plan_tables", fpID, userID, tables); err != nil {
+		return changes, fmt.Errorf("save tables: %w", err)
+	}
+	if changes.Guests, err = upsertEntities(ctx, tx, "floor_plan_guests", fpID, userID, guests); err != nil {
+		return changes, fmt.Errorf("save guests: %w", err)
+	}
+	if changes.Labels, err = upsertEntities(ctx, tx, "floor_plan_labels", fpID, userID, labels); err != nil {
+		return changes, fmt.Errorf("save labels: %w", err)
+	}
+	return changes, nil
 }
 
-// upsertEntities deletes rows not in the incoming set, then upserts the rest.
-func upsertEntities(ctx context.Context, tx pgx.Tx, tableName string, fpID uuid.UUID, items []json.RawMessage) error {
+// upsertEntities deletes rows not in the incoming set, then upserts the rest,
+// bumping the per-entity version of rows whose data actually changed.
+// It returns the IDs of rows that were deleted or changed.
+func upsertEntities(ctx context.Context, tx pgx.Tx, tableName string, fpID, userID uuid.UUID, items []json.RawMessage) (events.EntityChanges, error) {
+	changes := events.EntityChanges{Upserted: []uuid.UUID{}, Deleted: []uuid.UUID{}}
 	if !allowedTables[tableName] {
-		return fmt.Errorf("invalid table name: %s", tableName)
+		return changes, fmt.Errorf("invalid table name: %s", tableName)
 	}
 
 	// Collect IDs from incoming items
@@ -125,48 +329,59 @@ func upsertEntities(ctx context.Context, tx pgx.Tx, tableName string, fpID uuid.
 		incomingIDs = append(incomingIDs, extractID(item))
 	}
 
-	// Delete rows whose IDs are not in the incoming set
-	if len(incomingIDs) > 0 {
-		_, err := tx.Exec(ctx,
-			fmt.Sprintf(`DELETE FROM %s WHERE floor_plan_id = $1 AND id != ALL($2)`, tableName),
-			fpID, incomingIDs,
-		)
-		if err != nil {
-			return err
-		}
-	} else {
-		// No items: delete everything
-		_, err := tx.Exec(ctx,
-			fmt.Sprintf(`DELETE FROM %s WHERE floor_plan_id = $1`, tableName),
-			fpID,
-		)
-		if err != nil {
-			return err
+	// Delete rows whose IDs are not in the incoming set (everything if there are none)
+	rows, err := tx.Query(ctx,
+		fmt.Sprintf(`DELETE FROM %s WHERE floor_plan_id = $1 AND id != ALL($2) RETURNING id`, tableName),
+		fpID, incomingIDs,
+	)
+	if err != nil {
+		return changes, err
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return changes, err
 		}
+		changes.Deleted = append(changes.Deleted, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return changes, err
 	}
 
-	// Upsert each item
+	// Upsert each item, skipping the write when the data is unchanged
 	for i, item := range items {
 		id := incomingIDs[i]
-		_, err := tx.Exec(ctx,
-			fmt.Sprintf(`INSERT INTO %s (id, floor_plan_id, data) VALUES ($1, $2, $3)
-				ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data`, tableName),
-			id, fpID, item,
+		tag, err := tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %[1]s (id, floor_plan_id, data, updated_by) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, version = %[1]s.version + 1,
+					updated_by = EXCLUDED.updated_by, updated_at = NOW()
+				WHERE %[1]s.data IS DISTINCT FROM EXCLUDED.data`, tableName),
+			id, fpID, item, userID,
 		)
 		if err != nil {
-			return err
+			return changes, err
+		}
+		if tag.RowsAffected() > 0 {
+			changes.Upserted = append(changes.Upserted, id)
 		}
 	}
 
-	return nil
+	return changes, nil
 }
 
 func (h *Handler) getEntityData(ctx context.Context, table string, floorPlanID uuid.UUID) ([]json.RawMessage, error) {
+	return queryEntityData(ctx, h.pool, table, floorPlanID)
+}
+
+// queryEntityData loads entity documents using q, so it can run inside a transaction.
+func queryEntityData(ctx context.Context, q rowsQuerier, table string, floorPlanID uuid.UUID) ([]json.RawMessage, error) {
 	if !allowedTables[table] {
 		return nil, fmt.Errorf("invalid table name: %s", table)
 	}
 
-	rows, err := h.pool.Query(ctx,
+	rows, err := q.Query(ctx,
 		fmt.Sprintf(`SELECT data FROM %s WHERE floor_plan_id = $1`, table),
 		floorPlanID,
 	)
@@ -186,6 +401,28 @@ func (h *Handler) getEntityData(ctx context.Context, table string, floorPlanID u
 	return result, nil
 }
 
+// queryPlanEntities loads a plan's tables, guests and labels.
+func queryPlanEntities(ctx context.Context, q rowsQuerier, floorPlanID uuid.UUID) (tables, guests, labels []json.RawMessage, err error) {
+	if tables, err = queryEntityData(ctx, q, "floor_plan_tables", floorPlanID); err != nil {
+		return nil, nil, nil, err
+	}
+	if guests, err = queryEntityData(ctx, q, "floor_plan_guests", floorPlanID); err != nil {
+		return nil, nil, nil, err
+	}
+	if labels, err = queryEntityData(ctx, q, "floor_plan_labels", floorPlanID); err != nil {
+		return nil, nil, nil, err
+	}
+	return tables, guests, labels, nil
+}
+
+// orEmpty returns items, or an empty list instead of nil so it is sent as [].
+func orEmpty(items []json.RawMessage) []json.RawMessage {
+	if items == nil {
+		return []json.RawMessage{}
+	}
+	return items
+}
+
 func extractID(raw json.RawMessage) uuid.UUID {
 	var obj struct {
 		ID string `json:"id"`
@@ -90,6 +90,87 @@ func TestBulkSave_TooManyItems(t *testing.T) {
 	}
 }
 
+func TestBulkSave_InvalidEntities(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	db := &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					if p, ok := dest[0].(*uuid.UUID); ok {
+						*p = userID
+					}
+					return nil
+				},
+			}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			t.Fatal("expected no transaction for invalid data")
+			return nil, nil
+		},
+	}
+
+	h := New(db)
+
+	body := `{"version":1,"tables":[],"labels":[],"guests":[{"id":"` + uuid.New().String() +
+		`","name":"Ann","dietaryRestrictions":["KETO"]}]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/save", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.BulkSave(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"path":"guests[0].dietaryRestrictions[0]"`) {
+		t.Errorf("expected field path in response, got %s", w.Body.String())
+	}
+}
+
+func TestBulkSave_InconsistentSeating(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+
+	db := &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{
+				scanFunc: func(dest ...any) error {
+					if p, ok := dest[0].(*uuid.UUID); ok {
+						*p = userID
+					}
+					return nil
+				},
+			}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			t.Fatal("expected no transaction for inconsistent seating")
+			return nil, nil
+		},
+	}
+
+	h := New(db)
+
+	// The guest is assigned to a table that is not part of the plan
+	body := `{"version":1,"tables":[],"labels":[],"guests":[{"id":"` + uuid.New().String() +
+		`","name":"Ann","assignedTableId":"` + uuid.New().String() + `"}]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/save", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.BulkSave(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"path":"guests[0].assignedTableId"`) {
+		t.Errorf("expected issue for the guest's table, got %s", w.Body.String())
+	}
+}
+
 func TestBulkSave_VersionConflict(t *testing.T) {
 	userID := uuid.New()
 	fpID := uuid.New()
@@ -199,6 +280,10 @@ func TestBulkSave_VersionMatch(t *testing.T) {
 						},
 					}
 				},
+				// DELETE ... RETURNING id — nothing removed
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return &mockRows{}, nil
+				},
 				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
 					return pgconn.NewCommandTag(""), nil
 				},
@@ -231,3 +316,32 @@ func TestBulkSave_VersionMatch(t *testing.T) {
 		t.Errorf("expected version 4, got %v", resp["version"])
 	}
 }
+
+func TestBulkSave_EventGuestFields(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.New()
+	eventGuestID := uuid.New()
+
+	var rewritten []string
+	h := New(patchTestDB(userID, eventGuestTx(guestID, eventGuestID, []uuid.UUID{eventGuestID}, &rewritten)))
+
+	body := strings.NewReader(`{"version":3,"tables":[],"labels":[],"guests":[{"id":"` + guestID.String() +
+		`","name":"Renamed","dietaryRestrictions":[],"eventGuestId":"` + eventGuestID.String() + `"}]}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/save", body)
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.BulkSave(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(rewritten) != 1 || !strings.Contains(rewritten[0], `"name":"Ann"`) {
+		t.Errorf("expected the event's name put back, got %v", rewritten)
+	}
+	if !strings.Contains(w.Body.String(), `"guests":[`) {
+		t.Errorf("expected the saved entities returned, got %s", w.Body.String())
+	}
+}
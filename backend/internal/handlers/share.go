@@ -1,14 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/frallan97/table-planner-backend/internal/middleware"
 	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/namematch"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -127,6 +133,8 @@ func (h *Handler) UnshareFloorPlan(w http.ResponseWriter, r *http.Request) {
 
 // CreateShareToken generates a public share token for a floor plan.
 // Only the creator can create a share token. Old active tokens are deactivated first.
+// The scope defaults to names, which is also what links from before migration
+// 013 became.
 func (h *Handler) CreateShareToken(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
@@ -154,13 +162,22 @@ func (h *Handler) CreateShareToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate cryptographically random token
-	tokenBytes := make([]byte, 32)
-	if _, err := rand.Read(tokenBytes); err != nil {
+	// The body is optional; without one the link shows names only
+	var req models.CreateShareTokenRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
 		http.Error(w, `{"error":"failed to generate token"}`, http.StatusInternalServerError)
 		return
 	}
-	token := base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(tokenBytes)
 
 	// Deactivate old tokens and insert new one in a transaction
 	tx, err := h.pool.Begin(r.Context())
@@ -181,8 +198,8 @@ func (h *Handler) CreateShareToken(w http.ResponseWriter, r *http.Request) {
 
 	expiresAt := time.Now().Add(30 * 24 * time.Hour)
 	_, err = tx.Exec(r.Context(),
-		`INSERT INTO floor_plan_share_tokens (floor_plan_id, token, created_by, expires_at) VALUES ($1, $2, $3, $4)`,
-		fpID, token, userID, expiresAt,
+		`INSERT INTO floor_plan_share_tokens (floor_plan_id, token, created_by, expires_at, scope) VALUES ($1, $2, $3, $4, $5)`,
+		fpID, token, userID, expiresAt, req.Scope,
 	)
 	if err != nil {
 		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
@@ -194,7 +211,7 @@ func (h *Handler) CreateShareToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]any{"token": token, "expiresAt": expiresAt})
+	respondJSON(w, http.StatusOK, map[string]any{"token": token, "expiresAt": expiresAt, "scope": req.Scope})
 }
 
 // RevokeShareToken deactivates the active share token for a floor plan.
@@ -264,13 +281,14 @@ func (h *Handler) GetShareToken(w http.ResponseWriter, r *http.Request) {
 
 	var token *string
 	var expiresAt *time.Time
+	var scope models.ShareScope
 	err = h.pool.QueryRow(r.Context(),
-		`SELECT token, expires_at FROM floor_plan_share_tokens
+		`SELECT token, expires_at, scope FROM floor_plan_share_tokens
 		 WHERE floor_plan_id = $1 AND is_active = true
 		   AND (expires_at IS NULL OR expires_at > NOW())
 		 LIMIT 1`,
 		fpID,
-	).Scan(&token, &expiresAt)
+	).Scan(&token, &expiresAt, &scope)
 	if err == pgx.ErrNoRows || token == nil {
 		respondJSON(w, http.StatusOK, map[string]any{"token": nil})
 		return
@@ -280,7 +298,84 @@ func (h *Handler) GetShareToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]any{"token": token, "expiresAt": expiresAt})
+	respondJSON(w, http.StatusOK, map[string]any{"token": token, "expiresAt": expiresAt, "scope": scope})
+}
+
+// newToken generates a cryptographically random, URL-safe token.
+func newToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(tokenBytes), nil
+}
+
+// sharedFloorPlan looks up the floor plan an active, non-expired share token
+// points to and how much of it the token shows.
+func (h *Handler) sharedFloorPlan(ctx context.Context, token string) (uuid.UUID, models.ShareScope, error) {
+	var fpID uuid.UUID
+	var scope models.ShareScope
+	err := h.pool.QueryRow(ctx,
+		`SELECT floor_plan_id, scope FROM floor_plan_share_tokens
+		 WHERE token = $1 AND is_active = true
+		   AND (expires_at IS NULL OR expires_at > NOW())`,
+		token,
+	).Scan(&fpID, &scope)
+	return fpID, scope, err
+}
+
+// sharedGuestFields are the guest fields a names scope share link shows.
+var sharedGuestFields = []string{"id", "name", "assignedTableId", "seatPosition", "guestOf", "createdAt"}
+
+// redactShared strips what scope doesn't show from a plan's tables and
+// guests. A layout link drops guests and empties every seat; a names link
+// keeps only guests' names and placement and leaves out dietaryRestrictions.
+func redactShared(scope models.ShareScope, tables, guests []json.RawMessage) ([]json.RawMessage, []json.RawMessage, error) {
+	switch scope {
+	case models.ShareScopeFull:
+		return tables, guests, nil
+	case models.ShareScopeNames:
+		out := make([]json.RawMessage, len(guests))
+		for i, item := range guests {
+			var guest map[string]any
+			if err := decodeNumbers(item, &guest); err != nil {
+				return nil, nil, errInvalidStoredEntities
+			}
+			redacted := map[string]any{}
+			for _, field := range sharedGuestFields {
+				if v, ok := guest[field]; ok {
+					redacted[field] = v
+				}
+			}
+			data, err := json.Marshal(redacted)
+			if err != nil {
+				return nil, nil, err
+			}
+			out[i] = data
+		}
+		return tables, out, nil
+	}
+
+	out := make([]json.RawMessage, len(tables))
+	for i, item := range tables {
+		var table map[string]any
+		if err := decodeNumbers(item, &table); err != nil {
+			return nil, nil, errInvalidStoredEntities
+		}
+		seats, _ := table["seats"].([]any)
+		for _, seat := range seats {
+			if seat, ok := seat.(map[string]any); ok {
+				seat["guestId"] = nil
+			}
+		}
+		table["assignedGuests"] = []string{}
+		data, err := json.Marshal(table)
+		if err != nil {
+			return nil, nil, err
+		}
+		out[i] = data
+	}
+	return out, []json.RawMessage{}, nil
 }
 
 // GetFloorPlanByShareToken returns a floor plan by its public share token (no auth required).
@@ -291,14 +386,7 @@ func (h *Handler) GetFloorPlanByShareToken(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Look up active, non-expired token
-	var fpID uuid.UUID
-	err := h.pool.QueryRow(r.Context(),
-		`SELECT floor_plan_id FROM floor_plan_share_tokens
-		 WHERE token = $1 AND is_active = true
-		   AND (expires_at IS NULL OR expires_at > NOW())`,
-		token,
-	).Scan(&fpID)
+	fpID, scope, err := h.sharedFloorPlan(r.Context(), token)
 	if err != nil {
 		http.Error(w, `{"error":"invalid or expired share link"}`, http.StatusNotFound)
 		return
@@ -308,12 +396,12 @@ func (h *Handler) GetFloorPlanByShareToken(w http.ResponseWriter, r *http.Reques
 	var fp models.FloorPlan
 	var orgName *string
 	err = h.pool.QueryRow(r.Context(),
-		`SELECT fp.id, fp.user_id, fp.name, fp.version, fp.organization_id, fp.created_at, fp.updated_at, o.name
+		`SELECT fp.id, fp.user_id, fp.name, fp.version, fp.organization_id, fp.created_at, fp.updated_at, o.name, fp.room_id
 		 FROM floor_plans fp
 		 LEFT JOIN organizations o ON fp.organization_id = o.id
 		 WHERE fp.id = $1`,
 		fpID,
-	).Scan(&fp.ID, &fp.UserID, &fp.Name, &fp.Version, &fp.OrganizationID, &fp.CreatedAt, &fp.UpdatedAt, &orgName)
+	).Scan(&fp.ID, &fp.UserID, &fp.Name, &fp.Version, &fp.OrganizationID, &fp.CreatedAt, &fp.UpdatedAt, &orgName, &fp.RoomID)
 	if err != nil {
 		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
 		return
@@ -337,13 +425,31 @@ func (h *Handler) GetFloorPlanByShareToken(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	tables, guests, err = redactShared(scope, tables, guests)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	var room *models.RoomGeometry
+	if fp.RoomID != nil {
+		loaded, err := h.loadRoom(r.Context(), *fp.RoomID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		room = &loaded.RoomGeometry
+	}
+
 	result := struct {
-		ID               uuid.UUID           `json:"id"`
-		Name             string              `json:"name"`
-		Tables           []json.RawMessage   `json:"tables"`
-		Guests           []json.RawMessage   `json:"guests"`
-		Labels           []json.RawMessage   `json:"labels"`
-		OrganizationName *string             `json:"organizationName,omitempty"`
+		ID               uuid.UUID            `json:"id"`
+		Name             string               `json:"name"`
+		Tables           []json.RawMessage    `json:"tables"`
+		Guests           []json.RawMessage    `json:"guests"`
+		Labels           []json.RawMessage    `json:"labels"`
+		OrganizationName *string              `json:"organizationName,omitempty"`
+		Room             *models.RoomGeometry `json:"room,omitempty"`
+		Scope            models.ShareScope    `json:"scope"`
 	}{
 		ID:               fp.ID,
 		Name:             fp.Name,
@@ -351,7 +457,120 @@ func (h *Handler) GetFloorPlanByShareToken(w http.ResponseWriter, r *http.Reques
 		Guests:           guests,
 		Labels:           labels,
 		OrganizationName: orgName,
+		Room:             room,
+		Scope:            scope,
 	}
 
 	respondJSON(w, http.StatusOK, result)
 }
+
+// maxLookupNameLength bounds the name a public seat lookup searches for.
+const maxLookupNameLength = 200
+
+var (
+	errNoGuestMatch   = errors.New("no guest found with that name")
+	errAmbiguousGuest = errors.New("several guests match that name, try your full name")
+)
+
+// LookupSeatByShareToken finds a guest of a shared floor plan by name and
+// returns only their table and seat (no auth required). Matching forgives
+// case, accents, word order and small typos; a name that fits several guests
+// equally well is rejected rather than guessed.
+func (h *Handler) LookupSeatByShareToken(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		http.Error(w, `{"error":"missing token"}`, http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		http.Error(w, `{"error":"name is required"}`, http.StatusBadRequest)
+		return
+	}
+	if len(name) > maxLookupNameLength {
+		http.Error(w, `{"error":"name is too long"}`, http.StatusBadRequest)
+		return
+	}
+
+	fpID, scope, err := h.sharedFloorPlan(r.Context(), token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired share link"}`, http.StatusNotFound)
+		return
+	}
+	if scope == models.ShareScopeLayout {
+		http.Error(w, `{"error":"this share link doesn't include guests"}`, http.StatusForbidden)
+		return
+	}
+
+	tables, err := h.getEntityData(r.Context(), "floor_plan_tables", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	guests, err := h.getEntityData(r.Context(), "floor_plan_guests", fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	lookup, err := findSeat(tables, guests, name)
+	switch {
+	case errors.Is(err, errNoGuestMatch):
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusNotFound)
+		return
+	case errors.Is(err, errAmbiguousGuest):
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, lookup)
+}
+
+// findSeat returns the table and seat of the one guest whose name best
+// matches name. The seat is its label, or its 1-based number without one.
+func findSeat(tableItems, guestItems []json.RawMessage, name string) (models.SeatLookup, error) {
+	guests := make([]models.Guest, len(guestItems))
+	names := make([]string, len(guestItems))
+	for i, item := range guestItems {
+		if err := json.Unmarshal(item, &guests[i]); err != nil {
+			return models.SeatLookup{}, errInvalidStoredEntities
+		}
+		names[i] = guests[i].Name
+	}
+	matches := namematch.Best(name, names)
+	if len(matches) == 0 {
+		return models.SeatLookup{}, errNoGuestMatch
+	}
+	if len(matches) > 1 {
+		return models.SeatLookup{}, errAmbiguousGuest
+	}
+
+	g := guests[matches[0]]
+	if g.AssignedTableID == nil {
+		return models.SeatLookup{}, nil
+	}
+	tables, err := decodeTables(tableItems)
+	if err != nil {
+		return models.SeatLookup{}, err
+	}
+	for _, t := range tables {
+		if !strings.EqualFold(t.ID, *g.AssignedTableID) {
+			continue
+		}
+		lookup := models.SeatLookup{Table: &t.Name}
+		if g.SeatPosition != nil {
+			seat := strconv.Itoa(*g.SeatPosition + 1)
+			for _, s := range t.Seats {
+				if s.Position == *g.SeatPosition && s.Label != "" {
+					seat = s.Label
+				}
+			}
+			lookup.Seat = &seat
+		}
+		return lookup, nil
+	}
+	return models.SeatLookup{}, nil
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func seatLookupPlan() (tables, guests []json.RawMessage) {
+	tables = []json.RawMessage{
+		json.RawMessage(`{"id":"t1","name":"Table 1","seats":[{"position":0,"label":""},{"position":1,"label":"A2"}]}`),
+		json.RawMessage(`{"id":"t2","name":"Table 2","seats":[{"position":0},{"position":1}]}`),
+	}
+	guests = []json.RawMessage{
+		json.RawMessage(`{"id":"g1","name":"Åsa Öberg","dietaryRestrictions":["VEGAN"],"assignedTableId":"t1","seatPosition":1}`),
+		json.RawMessage(`{"id":"g2","name":"John Smith","assignedTableId":"T2","seatPosition":0}`),
+		json.RawMessage(`{"id":"g3","name":"Jane Smith","assignedTableId":"t2"}`),
+		json.RawMessage(`{"id":"g4","name":"Walk In"}`),
+	}
+	return tables, guests
+}
+
+func TestFindSeat(t *testing.T) {
+	tables, guests := seatLookupPlan()
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		want    models.SeatLookup
+		wantErr error
+	}{
+		{"asa oberg", models.SeatLookup{Table: str("Table 1"), Seat: str("A2")}, nil},
+		{"smith, jon", models.SeatLookup{Table: str("Table 2"), Seat: str("1")}, nil},
+		{"Jane", models.SeatLookup{Table: str("Table 2")}, nil},
+		{"walk in", models.SeatLookup{}, nil},
+		{"smith", models.SeatLookup{}, errAmbiguousGuest},
+		{"nobody", models.SeatLookup{}, errNoGuestMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findSeat(tables, guests, tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// shareDB serves seatLookupPlan through a share link with the given scope.
+func shareDB(scope models.ShareScope) *mockDB {
+	tables, guests := seatLookupPlan()
+	return &mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				if strings.Contains(sql, "FROM floor_plan_share_tokens") {
+					*dest[0].(*uuid.UUID) = uuid.New()
+					*dest[1].(*models.ShareScope) = scope
+				}
+				return nil
+			}}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			switch {
+			case strings.Contains(sql, "floor_plan_tables"):
+				return dataRows(tables...), nil
+			case strings.Contains(sql, "floor_plan_guests"):
+				return dataRows(guests...), nil
+			}
+			return &emptyRows{}, nil
+		},
+	}
+}
+
+func TestRedactShared(t *testing.T) {
+	tables, guests := seatLookupPlan()
+
+	_, named, err := redactShared(models.ShareScopeNames, tables, guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(named[0]); got != `{"assignedTableId":"t1","id":"g1","name":"Åsa Öberg","seatPosition":1}` {
+		t.Errorf("expected only the name and placement, got %s", got)
+	}
+
+	layoutTables, layoutGuests, err := redactShared(models.ShareScopeLayout, []json.RawMessage{
+		json.RawMessage(`{"id":"t1","capacity":2,"seats":[{"position":0,"guestId":"g1"}],"assignedGuests":["g1"]}`),
+	}, guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layoutGuests) != 0 {
+		t.Errorf("expected no guests, got %d", len(layoutGuests))
+	}
+	if got := string(layoutTables[0]); got != `{"assignedGuests":[],"capacity":2,"id":"t1","seats":[{"guestId":null,"position":0}]}` {
+		t.Errorf("expected empty seats, got %s", got)
+	}
+
+	fullTables, fullGuests, err := redactShared(models.ShareScopeFull, tables, guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fullTables[0]) != string(tables[0]) || string(fullGuests[0]) != string(guests[0]) {
+		t.Error("expected a full link to show the plan unchanged")
+	}
+}
+
+func TestGetFloorPlanByShareToken_NamesScope(t *testing.T) {
+	h := New(shareDB(models.ShareScopeNames))
+
+	req := httptest.NewRequest(http.MethodGet, "/public/floor-plans/tok", nil)
+	req = withChiParam(req, "token", "tok")
+	w := httptest.NewRecorder()
+
+	h.GetFloorPlanByShareToken(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); strings.Contains(body, "dietaryRestrictions") || !strings.Contains(body, `"scope":"names"`) {
+		t.Errorf("expected dietary restrictions redacted, got %s", body)
+	}
+}
+
+func TestLookupSeatByShareToken(t *testing.T) {
+	h := New(shareDB(models.ShareScopeNames))
+
+	req := httptest.NewRequest(http.MethodGet, "/public/floor-plans/tok/lookup?name="+url.QueryEscape("ÅSA öberg"), nil)
+	req = withChiParam(req, "token", "tok")
+	w := httptest.NewRecorder()
+
+	h.LookupSeatByShareToken(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); strings.TrimSpace(body) != `{"table":"Table 1","seat":"A2"}` {
+		t.Errorf("expected only the table and seat, got %s", body)
+	}
+}
+
+func TestLookupSeatByShareToken_LayoutScope(t *testing.T) {
+	h := New(shareDB(models.ShareScopeLayout))
+
+	req := httptest.NewRequest(http.MethodGet, "/public/floor-plans/tok/lookup?name=john", nil)
+	req = withChiParam(req, "token", "tok")
+	w := httptest.NewRecorder()
+
+	h.LookupSeatByShareToken(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
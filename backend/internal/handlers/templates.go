@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/frallan97/table-planner-backend/internal/remap"
+	"github.com/frallan97/table-planner-backend/internal/seating"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ListTemplates returns the floor plan templates of an organization (members only).
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canAccess, err := h.canAccessOrganization(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canAccess {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT id, organization_id, name, description, source_floor_plan_id, created_by, created_at, updated_at,
+		        jsonb_array_length(tables), jsonb_array_length(labels)
+		 FROM floor_plan_templates
+		 WHERE organization_id = $1
+		 ORDER BY name`,
+		orgID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	templates := []models.FloorPlanTemplate{}
+	for rows.Next() {
+		var t models.FloorPlanTemplate
+		err := rows.Scan(&t.ID, &t.OrganizationID, &t.Name, &t.Description, &t.SourceFloorPlanID,
+			&t.CreatedBy, &t.CreatedAt, &t.UpdatedAt, &t.TableCount, &t.LabelCount)
+		if err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		templates = append(templates, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, templates)
+}
+
+// GetTemplate returns a template including its tables and labels (members only).
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid template ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canAccess, err := h.canAccessOrganization(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canAccess {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	var t models.FloorPlanTemplateFull
+	err = h.pool.QueryRow(r.Context(),
+		`SELECT id, organization_id, name, description, source_floor_plan_id, created_by, created_at, updated_at,
+		        jsonb_array_length(tables), jsonb_array_length(labels), tables, labels
+		 FROM floor_plan_templates
+		 WHERE id = $1 AND organization_id = $2`,
+		templateID, orgID,
+	).Scan(&t.ID, &t.OrganizationID, &t.Name, &t.Description, &t.SourceFloorPlanID,
+		&t.CreatedBy, &t.CreatedAt, &t.UpdatedAt, &t.TableCount, &t.LabelCount, &t.Tables, &t.Labels)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"template not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, t)
+}
+
+// PublishTemplate saves the tables and labels of a floor plan as a template
+// of the organization (owner/admin only). Guests and seat assignments are
+// left out.
+func (h *Handler) PublishTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.PublishTemplateRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canManage, err := h.canManageOrgMembers(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canManage {
+		http.Error(w, `{"error":"only owners and admins can publish templates"}`, http.StatusForbidden)
+		return
+	}
+
+	canView, err := h.canViewFloorPlan(r.Context(), userID, req.FloorPlanID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	var planName string
+	err = h.pool.QueryRow(r.Context(), `SELECT name FROM floor_plans WHERE id = $1`, req.FloorPlanID).Scan(&planName)
+	if err != nil {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+	tables, err := h.getEntityData(r.Context(), "floor_plan_tables", req.FloorPlanID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	labels, err := h.getEntityData(r.Context(), "floor_plan_labels", req.FloorPlanID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if tables, _, err = seating.ClearAssignments(tables, nil); err != nil {
+		http.Error(w, `{"error":"floor plan contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	t := models.FloorPlanTemplateFull{
+		FloorPlanTemplate: models.FloorPlanTemplate{
+			OrganizationID:    orgID,
+			Name:              req.Name,
+			Description:       req.Description,
+			SourceFloorPlanID: &req.FloorPlanID,
+			CreatedBy:         userID,
+			TableCount:        len(tables),
+			LabelCount:        len(labels),
+		},
+		Tables: tables,
+		Labels: labels,
+	}
+	if t.Name == "" {
+		t.Name = planName
+	}
+	err = h.pool.QueryRow(r.Context(),
+		`INSERT INTO floor_plan_templates (organization_id, name, description, source_floor_plan_id, tables, labels, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at, updated_at`,
+		orgID, t.Name, t.Description, req.FloorPlanID, jsonArray(tables), jsonArray(labels), userID,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		http.Error(w, `{"error":"failed to publish template"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, t)
+}
+
+// DeleteTemplate removes a template (owner/admin only). Plans created from it
+// are not affected.
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid organization ID"}`, http.StatusBadRequest)
+		return
+	}
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid template ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canManage, err := h.canManageOrgMembers(r.Context(), userID, orgID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canManage {
+		http.Error(w, `{"error":"only owners and admins can delete templates"}`, http.StatusForbidden)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(),
+		`DELETE FROM floor_plan_templates WHERE id = $1 AND organization_id = $2`, templateID, orgID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"template not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createFloorPlanFromTemplate is CreateFloorPlan for a request with a
+// templateId. The caller must be a member of the template's organization.
+func (h *Handler) createFloorPlanFromTemplate(w http.ResponseWriter, r *http.Request, userID uuid.UUID, req *models.CreateFloorPlanRequest) {
+	var t models.FloorPlanTemplateFull
+	err := h.pool.QueryRow(r.Context(),
+		`SELECT organization_id, name, tables, labels FROM floor_plan_templates WHERE id = $1`, *req.TemplateID,
+	).Scan(&t.OrganizationID, &t.Name, &t.Tables, &t.Labels)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"template not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	canAccess, err := h.canAccessOrganization(r.Context(), userID, t.OrganizationID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canAccess {
+		http.Error(w, `{"error":"template not found"}`, http.StatusNotFound)
+		return
+	}
+
+	// Every plan made from the template gets its own entity IDs
+	ids := remap.New()
+	tables, err := ids.Tables(t.Tables)
+	if err != nil {
+		http.Error(w, `{"error":"template contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	labels, err := ids.Labels(t.Labels)
+	if err != nil {
+		http.Error(w, `{"error":"template contains invalid entities"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = t.Name
+	}
+	now := time.Now()
+	fp := models.FloorPlan{ID: uuid.New(), UserID: userID, Name: name, CreatedAt: now, UpdatedAt: now}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// The plan's first version is committed with the template's entities
+	_, err = tx.Exec(r.Context(),
+		`INSERT INTO floor_plans (id, user_id, name, version, created_at, updated_at) VALUES ($1, $2, $3, 0, $4, $5)`,
+		fp.ID, fp.UserID, fp.Name, fp.CreatedAt, fp.UpdatedAt,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	changes, err := replaceEntities(r.Context(), tx, fp.ID, userID, tables, nil, labels)
+	if err != nil {
+		http.Error(w, `{"error":"failed to create floor plan"}`, http.StatusInternalServerError)
+		return
+	}
+	if fp.Version, err = commitVersion(r.Context(), tx, fp.ID, userID, changes, nil); err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, `{"error":"failed to commit"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, fp)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestPublishTemplate_RequiresAdmin(t *testing.T) {
+	userID := uuid.New()
+	orgID := uuid.New()
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*string) = models.RoleMember
+				return nil
+			}}
+		},
+	})
+
+	body := `{"floorPlanId":"` + uuid.NewString() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/organizations/"+orgID.String()+"/templates", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", orgID.String())
+	w := httptest.NewRecorder()
+
+	h.PublishTemplate(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPublishTemplate(t *testing.T) {
+	userID := uuid.New()
+	orgID := uuid.New()
+	fpID := uuid.New()
+	guestID := uuid.NewString()
+	table := json.RawMessage(`{"id":"t1","seats":[{"position":0,"guestId":"` + guestID + `"}],"assignedGuests":["` + guestID + `"]}`)
+	label := json.RawMessage(`{"id":"l1","text":"Stage"}`)
+
+	var insertArgs []any
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			switch {
+			case strings.Contains(sql, "organization_members"):
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*string) = models.RoleAdmin
+					return nil
+				}}
+			case strings.Contains(sql, "INSERT INTO floor_plan_templates"):
+				insertArgs = args
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = uuid.New()
+					return nil
+				}}
+			case strings.Contains(sql, "SELECT name FROM floor_plans"):
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*string) = "Banquet hall"
+					return nil
+				}}
+			}
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			switch {
+			case strings.Contains(sql, "floor_plan_tables"):
+				return dataRows(table), nil
+			case strings.Contains(sql, "floor_plan_labels"):
+				return dataRows(label), nil
+			}
+			return &emptyRows{}, nil
+		},
+	})
+
+	body := `{"floorPlanId":"` + fpID.String() + `","description":"Long tables"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/organizations/"+orgID.String()+"/templates", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", orgID.String())
+	w := httptest.NewRecorder()
+
+	h.PublishTemplate(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var tmpl models.FloorPlanTemplateFull
+	json.Unmarshal(w.Body.Bytes(), &tmpl)
+	if tmpl.Name != "Banquet hall" || tmpl.OrganizationID != orgID || tmpl.TableCount != 1 || tmpl.LabelCount != 1 {
+		t.Errorf("unexpected template: %+v", tmpl.FloorPlanTemplate)
+	}
+	if len(insertArgs) == 0 {
+		t.Fatal("template not inserted")
+	}
+	if stored := string(insertArgs[4].(json.RawMessage)); strings.Contains(stored, guestID) {
+		t.Errorf("template kept seat assignments: %s", stored)
+	}
+}
+
+func TestCreateFloorPlan_FromTemplate(t *testing.T) {
+	userID := uuid.New()
+	orgID := uuid.New()
+	templateID := uuid.New()
+	tables := []json.RawMessage{json.RawMessage(`{"id":"t1","name":"Head","seats":[]}`)}
+	labels := []json.RawMessage{json.RawMessage(`{"id":"l1","text":"Stage"}`)}
+
+	inserted := map[string][]json.RawMessage{}
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			switch {
+			case strings.Contains(sql, "FROM floor_plan_templates"):
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = orgID
+					*dest[1].(*string) = "Banquet hall"
+					*dest[2].(*[]json.RawMessage) = tables
+					*dest[3].(*[]json.RawMessage) = labels
+					return nil
+				}}
+			case strings.Contains(sql, "organization_members"):
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*string) = models.RoleViewer
+					return nil
+				}}
+			}
+			return &mockRow{err: pgx.ErrNoRows}
+		},
+		beginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return &mockTx{
+				queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					return &mockRow{scanFunc: func(dest ...any) error {
+						*dest[0].(*int) = 1
+						return nil
+					}}
+				},
+				queryFunc: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return &emptyRows{}, nil
+				},
+				execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+					switch {
+					case strings.Contains(sql, "INSERT INTO floor_plan_tables"):
+						inserted["tables"] = append(inserted["tables"], args[2].(json.RawMessage))
+					case strings.Contains(sql, "INSERT INTO floor_plan_labels"):
+						inserted["labels"] = append(inserted["labels"], args[2].(json.RawMessage))
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}, nil
+		},
+	})
+
+	body := `{"templateId":"` + templateID.String() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/floor-plans", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	w := httptest.NewRecorder()
+
+	h.CreateFloorPlan(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var fp models.FloorPlan
+	json.Unmarshal(w.Body.Bytes(), &fp)
+	if fp.Name != "Banquet hall" || fp.Version != 1 || fp.OrganizationID != nil {
+		t.Errorf("unexpected floor plan: %+v", fp)
+	}
+	if len(inserted["tables"]) != 1 || len(inserted["labels"]) != 1 {
+		t.Fatalf("expected the template's table and label, got %v", inserted)
+	}
+	var table models.Table
+	json.Unmarshal(inserted["tables"][0], &table)
+	if table.ID == "t1" || table.Name != "Head" {
+		t.Errorf("table not copied with a new ID: %s", inserted["tables"][0])
+	}
+}
@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/frallan97/table-planner-backend/internal/middleware"
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const roomColumns = `id, venue_id, name, units, scale, outline, obstacles, created_at, updated_at`
+
+func scanRoom(row pgx.Row, room *models.Room) error {
+	return row.Scan(&room.ID, &room.VenueID, &room.Name, &room.Units, &room.Scale,
+		&room.Outline, &room.Obstacles, &room.CreatedAt, &room.UpdatedAt)
+}
+
+// loadRoom returns a room by ID.
+func (h *Handler) loadRoom(ctx context.Context, roomID uuid.UUID) (*models.Room, error) {
+	var room models.Room
+	row := h.pool.QueryRow(ctx, `SELECT `+roomColumns+` FROM rooms WHERE id = $1`, roomID)
+	if err := scanRoom(row, &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// ListVenues returns personal venues and venues of the user's organizations.
+func (h *Handler) ListVenues(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT id, user_id, organization_id, name, address, created_at, updated_at
+		 FROM venues
+		 WHERE user_id = $1
+		    OR organization_id IN (
+			    SELECT organization_id FROM organization_members WHERE user_id = $1
+		    )
+		 ORDER BY name`,
+		userID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	venues := []models.Venue{}
+	for rows.Next() {
+		var v models.Venue
+		if err := rows.Scan(&v.ID, &v.UserID, &v.OrganizationID, &v.Name, &v.Address, &v.CreatedAt, &v.UpdatedAt); err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		venues = append(venues, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, venues)
+}
+
+// CreateVenue creates a venue, personal or shared with an organization the
+// user is a member of.
+func (h *Handler) CreateVenue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.CreateVenueRequest](r, w)
+	if !ok {
+		return
+	}
+
+	if req.OrganizationID != nil {
+		canShare, err := h.canShareToOrganization(r.Context(), userID, *req.OrganizationID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !canShare {
+			http.Error(w, `{"error":"not a member of this organization"}`, http.StatusForbidden)
+			return
+		}
+	}
+
+	v := models.Venue{
+		UserID:         userID,
+		OrganizationID: req.OrganizationID,
+		Name:           req.Name,
+		Address:        req.Address,
+	}
+	err := h.pool.QueryRow(r.Context(),
+		`INSERT INTO venues (user_id, organization_id, name, address) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at, updated_at`,
+		v.UserID, v.OrganizationID, v.Name, v.Address,
+	).Scan(&v.ID, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		http.Error(w, `{"error":"failed to create venue"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, v)
+}
+
+// GetVenue returns a venue with its rooms.
+func (h *Handler) GetVenue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	venueID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid venue ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	canView, err := h.canViewVenue(r.Context(), userID, venueID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	var v models.VenueFull
+	err = h.pool.QueryRow(r.Context(),
+		`SELECT id, user_id, organization_id, name, address, created_at, updated_at FROM venues WHERE id = $1`,
+		venueID,
+	).Scan(&v.ID, &v.UserID, &v.OrganizationID, &v.Name, &v.Address, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		http.Error(w, `{"error":"venue not found"}`, http.StatusNotFound)
+		return
+	}
+
+	rows, err := h.pool.Query(r.Context(),
+		`SELECT `+roomColumns+` FROM rooms WHERE venue_id = $1 ORDER BY name`, venueID,
+	)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	v.Rooms = []models.Room{}
+	for rows.Next() {
+		var room models.Room
+		if err := scanRoom(rows, &room); err != nil {
+			http.Error(w, `{"error":"scan error"}`, http.StatusInternalServerError)
+			return
+		}
+		v.Rooms = append(v.Rooms, room)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, `{"error":"rows error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, v)
+}
+
+// UpdateVenue renames a venue or changes its address.
+func (h *Handler) UpdateVenue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	venueID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid venue ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.UpdateVenueRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditVenue(r.Context(), userID, venueID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(),
+		`UPDATE venues SET name = $1, address = $2, updated_at = NOW() WHERE id = $3`,
+		req.Name, req.Address, venueID,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"venue not found"}`, http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// DeleteVenue deletes a venue and its rooms (creator or organization
+// owner/admin). Floor plans in its rooms keep their layout without a room.
+func (h *Handler) DeleteVenue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	venueID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid venue ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.venueRole(r.Context(), userID, venueID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if role != models.RoleOwner && role != models.RoleAdmin {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), `DELETE FROM venues WHERE id = $1`, venueID)
+	if err != nil || tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"venue not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateRoom adds a room to a venue.
+func (h *Handler) CreateRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	venueID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid venue ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.RoomRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditVenue(r.Context(), userID, venueID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	outline, obstacles := roomJSON(&req.RoomGeometry)
+	room := models.Room{VenueID: venueID, Name: req.Name, RoomGeometry: req.RoomGeometry}
+	err = h.pool.QueryRow(r.Context(),
+		`INSERT INTO rooms (venue_id, name, units, scale, outline, obstacles) VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at, updated_at`,
+		venueID, room.Name, room.Units, room.Scale, outline, obstacles,
+	).Scan(&room.ID, &room.CreatedAt, &room.UpdatedAt)
+	if err != nil {
+		http.Error(w, `{"error":"failed to create room"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, room)
+}
+
+// UpdateRoom replaces a room's name and geometry.
+func (h *Handler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	venueID, roomID, ok := roomParams(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := decodeAndValidate[models.RoomRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditVenue(r.Context(), userID, venueID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	outline, obstacles := roomJSON(&req.RoomGeometry)
+	var room models.Room
+	row := h.pool.QueryRow(r.Context(),
+		`UPDATE rooms SET name = $1, units = $2, scale = $3, outline = $4, obstacles = $5, updated_at = NOW()
+		 WHERE id = $6 AND venue_id = $7
+		 RETURNING `+roomColumns,
+		req.Name, req.Units, req.Scale, outline, obstacles, roomID, venueID,
+	)
+	if err := scanRoom(row, &room); errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"room not found"}`, http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, room)
+}
+
+// DeleteRoom deletes a room. Floor plans in it keep their layout without a room.
+func (h *Handler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	venueID, roomID, ok := roomParams(w, r)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditVenue(r.Context(), userID, venueID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	tag, err := h.pool.Exec(r.Context(), `DELETE FROM rooms WHERE id = $1 AND venue_id = $2`, roomID, venueID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"room not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetFloorPlanRoom places a floor plan in a room the user can view, or takes
+// it out of its room.
+func (h *Handler) SetFloorPlanRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	fpID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid floor plan id"}`, http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeAndValidate[models.SetFloorPlanRoomRequest](r, w)
+	if !ok {
+		return
+	}
+
+	canEdit, err := h.canEditFloorPlan(r.Context(), userID, fpID)
+	if err != nil {
+		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canEdit {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	var room *models.Room
+	if req.RoomID != nil {
+		room, err = h.loadRoom(r.Context(), *req.RoomID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, `{"error":"room not found"}`, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		canView, err := h.canViewVenue(r.Context(), userID, room.VenueID)
+		if err != nil {
+			http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+			return
+		}
+		if !canView {
+			http.Error(w, `{"error":"room not found"}`, http.StatusNotFound)
+			return
+		}
+	}
+
+	tag, err := h.pool.Exec(r.Context(),
+		`UPDATE floor_plans SET room_id = $1, updated_at = NOW() WHERE id = $2`,
+		req.RoomID, fpID,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		http.Error(w, `{"error":"floor plan not found"}`, http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"status": "updated", "room": room})
+}
+
+func roomParams(w http.ResponseWriter, r *http.Request) (venueID, roomID uuid.UUID, ok bool) {
+	venueID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid venue ID"}`, http.StatusBadRequest)
+		return venueID, roomID, false
+	}
+	roomID, err = uuid.Parse(chi.URLParam(r, "roomId"))
+	if err != nil {
+		http.Error(w, `{"error":"invalid room ID"}`, http.StatusBadRequest)
+		return venueID, roomID, false
+	}
+	return venueID, roomID, true
+}
+
+// roomJSON encodes the JSONB columns of a room.
+func roomJSON(g *models.RoomGeometry) (outline, obstacles json.RawMessage) {
+	outline, _ = json.Marshal(g.Outline)
+	obstacles, _ = json.Marshal(g.Obstacles)
+	return outline, obstacles
+}
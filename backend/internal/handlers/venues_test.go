@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCreateRoom(t *testing.T) {
+	userID := uuid.New()
+	venueID := uuid.New()
+
+	var insertArgs []any
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			if strings.Contains(sql, "INSERT INTO rooms") {
+				insertArgs = args
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = uuid.New()
+					return nil
+				}}
+			}
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+	})
+
+	body := `{"name":"Ballroom","units":"ft","outline":[{"x":0,"y":0},{"x":3000,"y":0},{"x":3000,"y":2000}],
+		"obstacles":[{"type":"PILLAR","points":[{"x":100,"y":100},{"x":140,"y":100},{"x":140,"y":140}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/venues/"+venueID.String()+"/rooms", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", venueID.String())
+	w := httptest.NewRecorder()
+
+	h.CreateRoom(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var room models.Room
+	json.Unmarshal(w.Body.Bytes(), &room)
+	if room.VenueID != venueID || room.Units != models.RoomUnitFeet || room.Scale != models.DefaultRoomScale || len(room.Outline) != 3 {
+		t.Errorf("unexpected room: %+v", room)
+	}
+	if len(insertArgs) != 6 || !strings.Contains(string(insertArgs[5].(json.RawMessage)), `"PILLAR"`) {
+		t.Errorf("obstacles not stored: %v", insertArgs)
+	}
+}
+
+func TestCreateRoom_InvalidGeometry(t *testing.T) {
+	userID := uuid.New()
+	venueID := uuid.New()
+	h := New(&mockDB{})
+
+	body := `{"name":"Ballroom","outline":[{"x":0,"y":0},{"x":10,"y":0}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/venues/"+venueID.String()+"/rooms", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", venueID.String())
+	w := httptest.NewRecorder()
+
+	h.CreateRoom(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "outline") {
+		t.Errorf("expected the outline to be reported, got %s", w.Body.String())
+	}
+}
+
+func TestSetFloorPlanRoom_OtherUsersRoom(t *testing.T) {
+	userID := uuid.New()
+	fpID := uuid.New()
+	roomID := uuid.New()
+
+	updated := false
+	h := New(&mockDB{
+		queryRowFunc: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			switch {
+			case strings.Contains(sql, "FROM rooms"):
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = roomID
+					*dest[1].(*uuid.UUID) = uuid.New()
+					return nil
+				}}
+			case strings.Contains(sql, "FROM venues"):
+				// Personal venue of someone else
+				return &mockRow{scanFunc: func(dest ...any) error {
+					*dest[0].(*uuid.UUID) = uuid.New()
+					return nil
+				}}
+			}
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*dest[0].(*uuid.UUID) = userID
+				return nil
+			}}
+		},
+		execFunc: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			updated = true
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	})
+
+	body := `{"roomId":"` + roomID.String() + `"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/floor-plans/"+fpID.String()+"/room", strings.NewReader(body))
+	req = req.WithContext(withUserID(req.Context(), userID))
+	req = withChiParam(req, "id", fpID.String())
+	w := httptest.NewRecorder()
+
+	h.SetFloorPlanRoom(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if updated {
+		t.Error("floor plan was placed in a room the user cannot see")
+	}
+}
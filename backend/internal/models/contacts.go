@@ -0,0 +1,134 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxContactTags      = 20
+	maxContactTagLength = 50
+	maxContactNotes     = 2000
+	maxContactsPerPull  = 500
+)
+
+// Contact is an entry in an organization's guest directory. Guests pulled
+// from it into floor plans keep a contactId, so later changes to the contact
+// can be synced into those plans.
+type Contact struct {
+	ID                  uuid.UUID            `json:"id"`
+	OrganizationID      uuid.UUID            `json:"organizationId"`
+	Name                string               `json:"name"`
+	Email               string               `json:"email"`
+	DietaryRestrictions []DietaryRestriction `json:"dietaryRestrictions"`
+	Tags                []string             `json:"tags"`
+	Notes               string               `json:"notes"`
+	CreatedBy           uuid.UUID            `json:"createdBy"`
+	CreatedAt           time.Time            `json:"createdAt"`
+	UpdatedAt           time.Time            `json:"updatedAt"`
+}
+
+// ContactRequest creates a contact or replaces one. Sync, on update, copies
+// the new name and dietary restrictions into the organization's floor plans
+// that have guests pulled from the contact.
+type ContactRequest struct {
+	Name                string               `json:"name"`
+	Email               string               `json:"email"`
+	DietaryRestrictions []DietaryRestriction `json:"dietaryRestrictions"`
+	Tags                []string             `json:"tags"`
+	Notes               string               `json:"notes"`
+	Sync                bool                 `json:"sync"`
+}
+
+func (r *ContactRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > maxEntityNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxEntityNameLength)
+	}
+	if r.Email != "" {
+		if !emailRegex.MatchString(r.Email) {
+			return errors.New("invalid email format")
+		}
+		if len(r.Email) > 255 {
+			return errors.New("email must be at most 255 characters")
+		}
+	}
+	if r.DietaryRestrictions == nil {
+		r.DietaryRestrictions = []DietaryRestriction{}
+	}
+	for _, d := range r.DietaryRestrictions {
+		if !d.Valid() {
+			return errors.New("dietaryRestrictions must be VEGETARIAN, VEGAN, PESCATARIAN, LACTOSE_INTOLERANT, or NONE")
+		}
+	}
+	if len(r.Tags) > maxContactTags {
+		return fmt.Errorf("at most %d tags are allowed", maxContactTags)
+	}
+	// Tags are trimmed and kept once each, in order
+	tags := make([]string, 0, len(r.Tags))
+	seen := map[string]bool{}
+	for _, tag := range r.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return errors.New("tags must not be empty")
+		}
+		if len(tag) > maxContactTagLength {
+			return fmt.Errorf("tags must be at most %d characters", maxContactTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	r.Tags = tags
+	if len(r.Notes) > maxContactNotes {
+		return fmt.Errorf("notes must be at most %d characters", maxContactNotes)
+	}
+	return nil
+}
+
+// ContactResponse is a changed contact with the new versions of the floor
+// plans it was synced into.
+type ContactResponse struct {
+	Contact
+	FloorPlans []FloorPlanVersion `json:"floorPlans"`
+}
+
+// AddContactsRequest pulls contacts from the organization's directory into a
+// floor plan as unseated guests.
+type AddContactsRequest struct {
+	ContactIDs []uuid.UUID `json:"contactIds"`
+	Version    *int        `json:"version,omitempty"`
+}
+
+func (r *AddContactsRequest) Validate() error {
+	if len(r.ContactIDs) == 0 {
+		return errors.New("contactIds is required")
+	}
+	if len(r.ContactIDs) > maxContactsPerPull {
+		return fmt.Errorf("at most %d contacts can be added at once", maxContactsPerPull)
+	}
+	seen := make(map[uuid.UUID]bool, len(r.ContactIDs))
+	for _, id := range r.ContactIDs {
+		if seen[id] {
+			return fmt.Errorf("contact %s is listed more than once", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// AddContactsResponse lists the guests added to the floor plan. Contacts the
+// plan already has a guest for are skipped.
+type AddContactsResponse struct {
+	Status  string            `json:"status"`
+	Version int               `json:"version"`
+	Guests  []json.RawMessage `json:"guests"`
+}
@@ -0,0 +1,375 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TableType mirrors the frontend TableType enum.
+type TableType string
+
+const (
+	TableTypeLine   TableType = "LINE"
+	TableTypeUShape TableType = "U_SHAPE"
+	TableTypeRound  TableType = "ROUND"
+)
+
+func (t TableType) Valid() bool {
+	switch t {
+	case TableTypeLine, TableTypeUShape, TableTypeRound:
+		return true
+	}
+	return false
+}
+
+// DietaryRestriction mirrors the frontend DietaryRestriction enum.
+type DietaryRestriction string
+
+const (
+	DietaryVegetarian        DietaryRestriction = "VEGETARIAN"
+	DietaryVegan             DietaryRestriction = "VEGAN"
+	DietaryPescatarian       DietaryRestriction = "PESCATARIAN"
+	DietaryLactoseIntolerant DietaryRestriction = "LACTOSE_INTOLERANT"
+	DietaryNone              DietaryRestriction = "NONE"
+)
+
+func (d DietaryRestriction) Valid() bool {
+	switch d {
+	case DietaryVegetarian, DietaryVegan, DietaryPescatarian, DietaryLactoseIntolerant, DietaryNone:
+		return true
+	}
+	return false
+}
+
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Seat struct {
+	Position int     `json:"position"`
+	GuestID  *string `json:"guestId"`
+	Label    string  `json:"label"`
+	// Pinned keeps the seated guest in place when seating is reassigned
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// Table is the stored shape of a table. IDs are kept as strings so invalid
+// values can be reported with a field path instead of failing the decode.
+type Table struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	TableType      TableType `json:"tableType"`
+	Position       Position  `json:"position"`
+	Rotation       float64   `json:"rotation"`
+	Seats          []Seat    `json:"seats"`
+	Capacity       int       `json:"capacity"`
+	AssignedGuests []string  `json:"assignedGuests"`
+	SingleSided    bool      `json:"singleSided"`
+	EndSeatLeft    bool      `json:"endSeatLeft"`
+	EndSeatRight   bool      `json:"endSeatRight"`
+	TopSeats       int       `json:"topSeats"`
+	LeftSeats      int       `json:"leftSeats"`
+	RightSeats     int       `json:"rightSeats"`
+}
+
+type Guest struct {
+	ID                  string               `json:"id"`
+	Name                string               `json:"name"`
+	DietaryRestrictions []DietaryRestriction `json:"dietaryRestrictions"`
+	AssignedTableID     *string              `json:"assignedTableId"`
+	SeatPosition        *int                 `json:"seatPosition"`
+	GuestOf             *string              `json:"guestOf"`
+	CreatedAt           string               `json:"createdAt,omitempty"`
+	// Pinned keeps the guest's placement when seating is reassigned
+	Pinned bool `json:"pinned,omitempty"`
+	// EventGuestID links the guest to the event guest it is a copy of
+	EventGuestID *string `json:"eventGuestId,omitempty"`
+	// ContactID links the guest to the organization contact it was pulled from
+	ContactID *string `json:"contactId,omitempty"`
+	// RSVPStatus is the guest's answer to their RSVP link, empty before one
+	RSVPStatus RSVPStatus `json:"rsvpStatus,omitempty"`
+}
+
+type FloorLabel struct {
+	ID       string   `json:"id"`
+	Text     string   `json:"text"`
+	Position Position `json:"position"`
+	Rotation float64  `json:"rotation"`
+	Width    float64  `json:"width"`
+	Height   float64  `json:"height"`
+	FontSize float64  `json:"fontSize"`
+}
+
+const (
+	maxEntityNameLength = 200
+	maxLabelTextLength  = 500
+	maxTableCapacity    = 200
+	maxFieldErrors      = 100
+)
+
+// FieldError is a validation failure of a single field, addressed by a path
+// such as "tables[2].seats[0].guestId".
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// FieldErrors collects field-level validation failures.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	if len(e) == 0 {
+		return "no errors"
+	}
+	msg := e[0].Path + ": " + e[0].Message
+	if len(e) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e)-1)
+	}
+	return msg
+}
+
+func (e *FieldErrors) add(path, format string, args ...any) {
+	*e = append(*e, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// decodeEntity unmarshals an entity, reporting type mismatches at their field path.
+func decodeEntity(data json.RawMessage, path string, v any) FieldErrors {
+	if err := json.Unmarshal(data, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			fieldPath := path
+			if typeErr.Field != "" {
+				fieldPath += "." + typeErr.Field
+			}
+			return FieldErrors{{Path: fieldPath, Message: "must be of type " + typeErr.Type.String()}}
+		}
+		return FieldErrors{{Path: path, Message: "must be a JSON object"}}
+	}
+	return nil
+}
+
+func validateID(errs *FieldErrors, path, id string) {
+	if id == "" {
+		errs.add(path, "is required")
+		return
+	}
+	if parsed, err := uuid.Parse(id); err != nil || parsed == uuid.Nil {
+		errs.add(path, "must be a UUID")
+	}
+}
+
+func validateOptionalID(errs *FieldErrors, path string, id *string) {
+	if id != nil {
+		validateID(errs, path, *id)
+	}
+}
+
+func validateNumber(errs *FieldErrors, path string, n float64) {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		errs.add(path, "must be a finite number")
+	}
+}
+
+// ParseTable decodes and validates a table.
+func ParseTable(data json.RawMessage, path string) (*Table, FieldErrors) {
+	var t Table
+	if errs := decodeEntity(data, path, &t); errs != nil {
+		return nil, errs
+	}
+	return &t, t.Validate(path)
+}
+
+// Validate checks a table's fields and that its seats agree with its capacity
+// and shape.
+func (t *Table) Validate(path string) FieldErrors {
+	var errs FieldErrors
+	validateID(&errs, path+".id", t.ID)
+	if len(t.Name) > maxEntityNameLength {
+		errs.add(path+".name", "must be at most %d characters", maxEntityNameLength)
+	}
+	if !t.TableType.Valid() {
+		errs.add(path+".tableType", "must be one of LINE, U_SHAPE, ROUND")
+	}
+	validateNumber(&errs, path+".rotation", t.Rotation)
+
+	if t.Capacity < 1 || t.Capacity > maxTableCapacity {
+		errs.add(path+".capacity", "must be between 1 and %d", maxTableCapacity)
+	} else if len(t.Seats) != t.Capacity {
+		errs.add(path+".seats", "must contain %d seats to match capacity", t.Capacity)
+	}
+
+	seen := make(map[int]bool, len(t.Seats))
+	for i, seat := range t.Seats {
+		seatPath := fmt.Sprintf("%s.seats[%d]", path, i)
+		if seat.Position < 0 || seat.Position >= len(t.Seats) {
+			errs.add(seatPath+".position", "must be between 0 and %d", len(t.Seats)-1)
+		} else if seen[seat.Position] {
+			errs.add(seatPath+".position", "duplicate seat position %d", seat.Position)
+		}
+		seen[seat.Position] = true
+		validateOptionalID(&errs, seatPath+".guestId", seat.GuestID)
+	}
+
+	if len(t.AssignedGuests) > t.Capacity && t.Capacity > 0 {
+		errs.add(path+".assignedGuests", "must not exceed capacity of %d", t.Capacity)
+	}
+	for i, id := range t.AssignedGuests {
+		validateID(&errs, fmt.Sprintf("%s.assignedGuests[%d]", path, i), id)
+	}
+
+	switch t.TableType {
+	case TableTypeLine:
+		ends := 0
+		if t.EndSeatLeft {
+			ends++
+		}
+		if t.EndSeatRight {
+			ends++
+		}
+		if !t.SingleSided && t.Capacity > ends && (t.Capacity-ends)%2 != 0 {
+			errs.add(path+".capacity", "must seat the same number on both sides of a double-sided table")
+		}
+	case TableTypeUShape:
+		if t.TopSeats < 0 || t.LeftSeats < 0 || t.RightSeats < 0 {
+			errs.add(path, "seats per arm must not be negative")
+		} else if t.TopSeats+t.LeftSeats+t.RightSeats != t.Capacity {
+			errs.add(path+".capacity", "must equal topSeats + leftSeats + rightSeats")
+		}
+	}
+
+	return errs
+}
+
+// ParseGuest decodes and validates a guest.
+func ParseGuest(data json.RawMessage, path string) (*Guest, FieldErrors) {
+	var g Guest
+	if errs := decodeEntity(data, path, &g); errs != nil {
+		return nil, errs
+	}
+	return &g, g.Validate(path)
+}
+
+// Validate checks a guest's fields.
+func (g *Guest) Validate(path string) FieldErrors {
+	var errs FieldErrors
+	validateID(&errs, path+".id", g.ID)
+	if strings.TrimSpace(g.Name) == "" {
+		errs.add(path+".name", "is required")
+	} else if len(g.Name) > maxEntityNameLength {
+		errs.add(path+".name", "must be at most %d characters", maxEntityNameLength)
+	}
+	for i, d := range g.DietaryRestrictions {
+		if !d.Valid() {
+			errs.add(fmt.Sprintf("%s.dietaryRestrictions[%d]", path, i),
+				"must be one of VEGETARIAN, VEGAN, PESCATARIAN, LACTOSE_INTOLERANT, NONE")
+		}
+	}
+	validateOptionalID(&errs, path+".assignedTableId", g.AssignedTableID)
+	if g.SeatPosition != nil {
+		if *g.SeatPosition < 0 {
+			errs.add(path+".seatPosition", "must not be negative")
+		}
+		if g.AssignedTableID == nil {
+			errs.add(path+".seatPosition", "requires assignedTableId")
+		}
+	}
+	validateOptionalID(&errs, path+".guestOf", g.GuestOf)
+	if g.GuestOf != nil && *g.GuestOf == g.ID {
+		errs.add(path+".guestOf", "must not reference the guest itself")
+	}
+	validateOptionalID(&errs, path+".eventGuestId", g.EventGuestID)
+	validateOptionalID(&errs, path+".contactId", g.ContactID)
+	if g.RSVPStatus != "" && !g.RSVPStatus.Valid() {
+		errs.add(path+".rsvpStatus", "must be ATTENDING or DECLINED")
+	}
+	return errs
+}
+
+// ParseFloorLabel decodes and validates a floor label.
+func ParseFloorLabel(data json.RawMessage, path string) (*FloorLabel, FieldErrors) {
+	var l FloorLabel
+	if errs := decodeEntity(data, path, &l); errs != nil {
+		return nil, errs
+	}
+	return &l, l.Validate(path)
+}
+
+// Validate checks a label's fields.
+func (l *FloorLabel) Validate(path string) FieldErrors {
+	var errs FieldErrors
+	validateID(&errs, path+".id", l.ID)
+	if len(l.Text) > maxLabelTextLength {
+		errs.add(path+".text", "must be at most %d characters", maxLabelTextLength)
+	}
+	validateNumber(&errs, path+".rotation", l.Rotation)
+	if l.Width < 0 {
+		errs.add(path+".width", "must not be negative")
+	}
+	if l.Height < 0 {
+		errs.add(path+".height", "must not be negative")
+	}
+	if l.FontSize < 0 {
+		errs.add(path+".fontSize", "must not be negative")
+	}
+	return errs
+}
+
+// ValidateEntity validates a single entity of the given kind (EntityTable,
+// EntityGuest or EntityLabel).
+func ValidateEntity(kind string, data json.RawMessage, path string) FieldErrors {
+	var errs FieldErrors
+	switch kind {
+	case EntityTable:
+		_, errs = ParseTable(data, path)
+	case EntityGuest:
+		_, errs = ParseGuest(data, path)
+	case EntityLabel:
+		_, errs = ParseFloorLabel(data, path)
+	default:
+		errs = FieldErrors{{Path: path, Message: "unknown entity " + kind}}
+	}
+	return errs
+}
+
+// ValidateEntities validates all entities of a floor plan and checks that IDs
+// are unique within each kind. At most maxFieldErrors errors are returned.
+func ValidateEntities(tables, guests, labels []json.RawMessage) FieldErrors {
+	var errs FieldErrors
+	kinds := []struct {
+		kind  string
+		path  string
+		items []json.RawMessage
+	}{
+		{EntityTable, "tables", tables},
+		{EntityGuest, "guests", guests},
+		{EntityLabel, "labels", labels},
+	}
+	for _, k := range kinds {
+		seen := make(map[string]int, len(k.items))
+		for i, item := range k.items {
+			path := fmt.Sprintf("%s[%d]", k.path, i)
+			errs = append(errs, ValidateEntity(k.kind, item, path)...)
+
+			var ref struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(item, &ref) == nil && ref.ID != "" {
+				if first, dup := seen[ref.ID]; dup {
+					errs.add(path+".id", "duplicates %s[%d].id", k.path, first)
+				} else {
+					seen[ref.ID] = i
+				}
+			}
+			if len(errs) >= maxFieldErrors {
+				return errs[:maxFieldErrors]
+			}
+		}
+	}
+	return errs
+}
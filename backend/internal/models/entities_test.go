@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func seatsJSON(n int) string {
+	seats := make([]string, n)
+	for i := range seats {
+		seats[i] = `{"position":` + string(rune('0'+i)) + `,"guestId":null,"label":"Seat"}`
+	}
+	return "[" + strings.Join(seats, ",") + "]"
+}
+
+func TestParseTable(t *testing.T) {
+	id := uuid.New().String()
+	tests := []struct {
+		name      string
+		data      string
+		wantPaths []string
+	}{
+		{"valid round", `{"id":"` + id + `","name":"T1","tableType":"ROUND","capacity":3,"seats":` + seatsJSON(3) + `}`, nil},
+		{"valid double-sided line with end seat",
+			`{"id":"` + id + `","tableType":"LINE","capacity":5,"endSeatLeft":true,"seats":` + seatsJSON(5) + `}`, nil},
+		{"valid u-shape",
+			`{"id":"` + id + `","tableType":"U_SHAPE","capacity":4,"topSeats":2,"leftSeats":1,"rightSeats":1,"seats":` + seatsJSON(4) + `}`, nil},
+		{"missing id", `{"tableType":"ROUND","capacity":1,"seats":` + seatsJSON(1) + `}`, []string{"t.id"}},
+		{"unknown type", `{"id":"` + id + `","tableType":"SQUARE","capacity":1,"seats":` + seatsJSON(1) + `}`, []string{"t.tableType"}},
+		{"negative capacity", `{"id":"` + id + `","tableType":"ROUND","capacity":-1,"seats":[]}`, []string{"t.capacity"}},
+		{"seats do not match capacity", `{"id":"` + id + `","tableType":"ROUND","capacity":4,"seats":` + seatsJSON(3) + `}`, []string{"t.seats"}},
+		{"uneven double-sided line", `{"id":"` + id + `","tableType":"LINE","capacity":3,"seats":` + seatsJSON(3) + `}`, []string{"t.capacity"}},
+		{"u-shape arms do not add up",
+			`{"id":"` + id + `","tableType":"U_SHAPE","capacity":2,"topSeats":2,"leftSeats":1,"seats":` + seatsJSON(2) + `}`, []string{"t.capacity"}},
+		{"bad seat guest id",
+			`{"id":"` + id + `","tableType":"ROUND","capacity":1,"seats":[{"position":0,"guestId":"nope"}]}`, []string{"t.seats[0].guestId"}},
+		{"duplicate seat position",
+			`{"id":"` + id + `","tableType":"ROUND","capacity":2,"seats":[{"position":0},{"position":0}]}`, []string{"t.seats[1].position"}},
+		{"wrong field type", `{"id":"` + id + `","capacity":"four"}`, []string{"t.capacity"}},
+		{"not an object", `[1]`, []string{"t"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := ParseTable(json.RawMessage(tt.data), "t")
+			assertPaths(t, errs, tt.wantPaths)
+		})
+	}
+}
+
+func TestParseGuest(t *testing.T) {
+	id := uuid.New().String()
+	tableID := uuid.New().String()
+	tests := []struct {
+		name      string
+		data      string
+		wantPaths []string
+	}{
+		{"minimal", `{"id":"` + id + `","name":"Ann"}`, nil},
+		{"seated", `{"id":"` + id + `","name":"Ann","dietaryRestrictions":["VEGAN","NONE"],"assignedTableId":"` + tableID + `","seatPosition":2}`, nil},
+		{"blank name", `{"id":"` + id + `","name":"  "}`, []string{"g.name"}},
+		{"unknown diet", `{"id":"` + id + `","name":"Ann","dietaryRestrictions":["VEGAN","KETO"]}`, []string{"g.dietaryRestrictions[1]"}},
+		{"seat without table", `{"id":"` + id + `","name":"Ann","seatPosition":1}`, []string{"g.seatPosition"}},
+		{"guest of self", `{"id":"` + id + `","name":"Ann","guestOf":"` + id + `"}`, []string{"g.guestOf"}},
+		{"rsvp", `{"id":"` + id + `","name":"Ann","rsvpStatus":"DECLINED"}`, nil},
+		{"unknown rsvp", `{"id":"` + id + `","name":"Ann","rsvpStatus":"MAYBE"}`, []string{"g.rsvpStatus"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := ParseGuest(json.RawMessage(tt.data), "g")
+			assertPaths(t, errs, tt.wantPaths)
+		})
+	}
+}
+
+func TestValidateEntities_DuplicateIDs(t *testing.T) {
+	id := uuid.New().String()
+	label := json.RawMessage(`{"id":"` + id + `","text":"Stage"}`)
+
+	errs := ValidateEntities(nil, nil, []json.RawMessage{label, label})
+	assertPaths(t, errs, []string{"labels[1].id"})
+}
+
+func assertPaths(t *testing.T, errs FieldErrors, want []string) {
+	t.Helper()
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v, want paths %v", errs, want)
+	}
+	for i := range want {
+		if errs[i].Path != want[i] {
+			t.Errorf("errors[%d].Path = %q, want %q", i, errs[i].Path, want[i])
+		}
+	}
+}
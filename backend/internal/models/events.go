@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event groups the floor plans of one occasion, such as the rehearsal dinner,
+// ceremony and reception of a wedding, around a shared guest list. Each plan
+// keeps its own copies of the guests it seats, linked by eventGuestId.
+type Event struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"userId"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	Name           string     `json:"name"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// EventFull is an event with its guest list and floor plans.
+type EventFull struct {
+	Event
+	Guests     []EventGuest       `json:"guests"`
+	FloorPlans []FloorPlanVersion `json:"floorPlans"`
+}
+
+// FloorPlanVersion is a floor plan with its current version.
+type FloorPlanVersion struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Version int       `json:"version"`
+}
+
+// EventGuest is a guest on an event's canonical guest list.
+type EventGuest struct {
+	ID                  uuid.UUID            `json:"id"`
+	EventID             uuid.UUID            `json:"eventId"`
+	Name                string               `json:"name"`
+	DietaryRestrictions []DietaryRestriction `json:"dietaryRestrictions"`
+	// GuestOf is the event guest this guest accompanies
+	GuestOf   *uuid.UUID `json:"guestOf"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+type CreateEventRequest struct {
+	Name           string     `json:"name"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+}
+
+func (r *CreateEventRequest) Validate() error {
+	return validateEventName(r.Name)
+}
+
+type UpdateEventRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *UpdateEventRequest) Validate() error {
+	return validateEventName(r.Name)
+}
+
+func validateEventName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("name is required")
+	}
+	if len(name) > maxEntityNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxEntityNameLength)
+	}
+	return nil
+}
+
+// EventGuestRequest adds a guest to an event or replaces one.
+type EventGuestRequest struct {
+	Name                string               `json:"name"`
+	DietaryRestrictions []DietaryRestriction `json:"dietaryRestrictions"`
+	GuestOf             *uuid.UUID           `json:"guestOf"`
+}
+
+func (r *EventGuestRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > maxEntityNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxEntityNameLength)
+	}
+	if r.DietaryRestrictions == nil {
+		r.DietaryRestrictions = []DietaryRestriction{}
+	}
+	for _, d := range r.DietaryRestrictions {
+		if !d.Valid() {
+			return errors.New("dietaryRestrictions must be VEGETARIAN, VEGAN, PESCATARIAN, LACTOSE_INTOLERANT, or NONE")
+		}
+	}
+	return nil
+}
+
+// EventGuestResponse is a changed event guest with the new versions of the
+// floor plans the change was copied to.
+type EventGuestResponse struct {
+	Guest      *EventGuest        `json:"guest,omitempty"`
+	FloorPlans []FloorPlanVersion `json:"floorPlans"`
+}
+
+// SetFloorPlanEventRequest adds a floor plan to an event, or takes it out of
+// its event when EventID is null.
+type SetFloorPlanEventRequest struct {
+	EventID *uuid.UUID `json:"eventId"`
+}
+
+func (r *SetFloorPlanEventRequest) Validate() error {
+	return nil
+}
@@ -26,6 +26,8 @@ type FloorPlan struct {
 	Name           string     `json:"name"`
 	Version        int        `json:"version"`
 	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	RoomID         *uuid.UUID `json:"roomId,omitempty"`
+	EventID        *uuid.UUID `json:"eventId,omitempty"`
 	CreatedAt      time.Time  `json:"createdAt"`
 	UpdatedAt      time.Time  `json:"updatedAt"`
 }
@@ -42,8 +44,31 @@ type FloorPlanFull struct {
 	Tables           []json.RawMessage   `json:"tables"`
 	Guests           []json.RawMessage   `json:"guests"`
 	Labels           []json.RawMessage   `json:"labels"`
+	EntityVersions   EntityVersions      `json:"entityVersions"`
 	Presence         []FloorPlanPresence `json:"presence"`
 	OrganizationName *string             `json:"organizationName,omitempty"`
+	Room             *Room               `json:"room,omitempty"`
+}
+
+// EntityVersion is the concurrency metadata of a single table, guest or label.
+type EntityVersion struct {
+	Version   int        `json:"version"`
+	UpdatedBy *uuid.UUID `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// EntityVersions holds the current version of every entity, keyed by entity ID.
+type EntityVersions struct {
+	Tables map[uuid.UUID]EntityVersion `json:"tables"`
+	Guests map[uuid.UUID]EntityVersion `json:"guests"`
+	Labels map[uuid.UUID]EntityVersion `json:"labels"`
+}
+
+// ExpectedVersions maps each entity the client knows about to the version it last saw.
+type ExpectedVersions struct {
+	Tables map[uuid.UUID]int `json:"tables"`
+	Guests map[uuid.UUID]int `json:"guests"`
+	Labels map[uuid.UUID]int `json:"labels"`
 }
 
 type FloorPlanWithOrg struct {
@@ -52,13 +77,18 @@ type FloorPlanWithOrg struct {
 	IsPersonal       bool    `json:"isPersonal"`
 }
 
+// MaxFloorPlanNameLength is the longest name a floor plan may have.
+const MaxFloorPlanNameLength = 200
+
 type CreateFloorPlanRequest struct {
 	Name string `json:"name"`
+	// TemplateID starts the plan from an organization template's tables and labels
+	TemplateID *uuid.UUID `json:"templateId,omitempty"`
 }
 
 func (r *CreateFloorPlanRequest) Validate() error {
-	if len(r.Name) > 200 {
-		return errors.New("name must be at most 200 characters")
+	if len(r.Name) > MaxFloorPlanNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxFloorPlanNameLength)
 	}
 	return nil
 }
@@ -71,8 +101,8 @@ func (r *UpdateFloorPlanRequest) Validate() error {
 	if r.Name == "" {
 		return errors.New("name is required")
 	}
-	if len(r.Name) > 200 {
-		return errors.New("name must be at most 200 characters")
+	if len(r.Name) > MaxFloorPlanNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxFloorPlanNameLength)
 	}
 	return nil
 }
@@ -82,15 +112,47 @@ type BulkSaveRequest struct {
 	Tables  []json.RawMessage `json:"tables"`
 	Guests  []json.RawMessage `json:"guests"`
 	Labels  []json.RawMessage `json:"labels"`
+	// EntityVersions switches the save to per-entity concurrency: the plan
+	// version is not checked and only entities changed concurrently conflict.
+	EntityVersions *ExpectedVersions `json:"entityVersions,omitempty"`
+	// Repair fixes inconsistent seat assignments instead of rejecting the save
+	Repair bool `json:"repair,omitempty"`
 }
 
 type BulkSaveResponse struct {
 	Status  string `json:"status"`
 	Version int    `json:"version"`
+	// Set when a stale save was merged or repaired, so the client can adopt
+	// the saved state; null when not returned, never null when returned
+	Tables []json.RawMessage `json:"tables"`
+	Guests []json.RawMessage `json:"guests"`
+	Labels []json.RawMessage `json:"labels"`
+	// Set for per-entity saves so the client can continue with fresh versions
+	EntityVersions *EntityVersions `json:"entityVersions,omitempty"`
+	// Changes made to seat assignments when saving in repair mode
+	Repairs []FieldError `json:"repairs,omitempty"`
+	// Placement problems in the saved layout; they don't block the save
+	Warnings []LayoutIssue `json:"warnings,omitempty"`
+}
+
+// MergeConflicts lists entities the client changed that were also changed
+// by another save in the meantime.
+type MergeConflicts struct {
+	Tables []uuid.UUID `json:"tables"`
+	Guests []uuid.UUID `json:"guests"`
+	Labels []uuid.UUID `json:"labels"`
+}
+
+func (c MergeConflicts) Empty() bool {
+	return len(c.Tables) == 0 && len(c.Guests) == 0 && len(c.Labels) == 0
 }
 
 const maxBulkItems = 500
 
+// MaxPlanEntities is the most tables, guests or labels a plan can hold, as a
+// bulk save can't carry more.
+const MaxPlanEntities = maxBulkItems
+
 func (r *BulkSaveRequest) Validate() error {
 	if len(r.Tables) > maxBulkItems {
 		return fmt.Errorf("tables exceeds maximum of %d items", maxBulkItems)
@@ -104,6 +166,470 @@ func (r *BulkSaveRequest) Validate() error {
 	return nil
 }
 
+// Entity kinds addressed by patch operations
+const (
+	EntityTable = "table"
+	EntityGuest = "guest"
+	EntityLabel = "label"
+)
+
+// Patch operation types
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// EntityOperation is a single change in a PatchFloorPlanRequest. For updates,
+// Data is applied to the stored document as a JSON merge patch (RFC 7396).
+type EntityOperation struct {
+	Op     string          `json:"op"`
+	Entity string          `json:"entity"`
+	ID     uuid.UUID       `json:"id"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	// ExpectedVersion, when set, rejects the operation if the entity has changed since
+	ExpectedVersion *int `json:"expectedVersion,omitempty"`
+}
+
+// PatchFloorPlanRequest is checked against the plan version unless every
+// update and delete carries an ExpectedVersion.
+type PatchFloorPlanRequest struct {
+	Version    int               `json:"version"`
+	Operations []EntityOperation `json:"operations"`
+	// Repair fixes inconsistent seat assignments instead of rejecting the patch
+	Repair bool `json:"repair,omitempty"`
+}
+
+// PerEntity reports whether the request can be checked per entity instead of per plan.
+func (r *PatchFloorPlanRequest) PerEntity() bool {
+	for _, op := range r.Operations {
+		if op.Op != OpCreate && op.ExpectedVersion == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *PatchFloorPlanRequest) Validate() error {
+	if len(r.Operations) == 0 {
+		return errors.New("operations is required")
+	}
+	if len(r.Operations) > maxBulkItems {
+		return fmt.Errorf("operations exceeds maximum of %d items", maxBulkItems)
+	}
+	for i, op := range r.Operations {
+		if op.Entity != EntityTable && op.Entity != EntityGuest && op.Entity != EntityLabel {
+			return fmt.Errorf("operations[%d]: entity must be table, guest, or label", i)
+		}
+		if op.ID == uuid.Nil {
+			return fmt.Errorf("operations[%d]: id is required", i)
+		}
+		switch op.Op {
+		case OpDelete:
+		case OpCreate, OpUpdate:
+			var doc map[string]json.RawMessage
+			if err := json.Unmarshal(op.Data, &doc); err != nil || doc == nil {
+				return fmt.Errorf("operations[%d]: data must be a JSON object", i)
+			}
+			if rawID, ok := doc["id"]; ok {
+				var id uuid.UUID
+				if err := json.Unmarshal(rawID, &id); err != nil || id != op.ID {
+					return fmt.Errorf("operations[%d]: data.id must match id", i)
+				}
+			} else if op.Op == OpCreate {
+				return fmt.Errorf("operations[%d]: data.id is required", i)
+			}
+		default:
+			return fmt.Errorf("operations[%d]: op must be create, update, or delete", i)
+		}
+	}
+	return nil
+}
+
+// FloorPlanRevision describes one committed version of a floor plan.
+type FloorPlanRevision struct {
+	FloorPlanID  uuid.UUID `json:"floorPlanId"`
+	Version      int       `json:"version"`
+	CreatedBy    uuid.UUID `json:"createdBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+	RestoredFrom *int      `json:"restoredFrom,omitempty"`
+	TableCount   int       `json:"tableCount"`
+	GuestCount   int       `json:"guestCount"`
+	LabelCount   int       `json:"labelCount"`
+}
+
+// FloorPlanRevisionFull is a revision including its entity snapshot.
+type FloorPlanRevisionFull struct {
+	FloorPlanRevision
+	Tables []json.RawMessage `json:"tables"`
+	Guests []json.RawMessage `json:"guests"`
+	Labels []json.RawMessage `json:"labels"`
+}
+
+// DuplicateFloorPlanRequest configures a copy of a floor plan. Everything is
+// copied unless asked otherwise.
+type DuplicateFloorPlanRequest struct {
+	// Name defaults to the source plan's name followed by " (copy)"
+	Name string `json:"name"`
+	// ExcludeGuests leaves out the guest list, so every seat is empty
+	ExcludeGuests bool `json:"excludeGuests"`
+	// ClearAssignments keeps the guest list but unseats everyone
+	ClearAssignments bool `json:"clearAssignments"`
+	// OrganizationID shares the copy with an organization the caller belongs
+	// to; otherwise the copy is personal
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+}
+
+func (r *DuplicateFloorPlanRequest) Validate() error {
+	if len(r.Name) > MaxFloorPlanNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxFloorPlanNameLength)
+	}
+	if r.OrganizationID != nil && *r.OrganizationID == uuid.Nil {
+		return errors.New("organizationId must be a valid UUID")
+	}
+	return nil
+}
+
+// Floor plan bundle format. FormatVersion is raised when a bundle written by
+// this version can't be read by older ones.
+const (
+	BundleFormat        = "table-planner/floor-plan"
+	BundleFormatVersion = 1
+	maxBundleRevisions  = 1000
+)
+
+// FloorPlanBundle is a self-contained copy of a floor plan for moving it
+// between environments or accounts.
+type FloorPlanBundle struct {
+	Format        string    `json:"format"`
+	FormatVersion int       `json:"formatVersion"`
+	ExportedAt    time.Time `json:"exportedAt"`
+	Name          string    `json:"name"`
+	// Version is the version of the plan the bundle was exported at
+	Version int               `json:"version"`
+	Tables  []json.RawMessage `json:"tables"`
+	Guests  []json.RawMessage `json:"guests"`
+	Labels  []json.RawMessage `json:"labels"`
+	// Revisions is the plan's history, oldest first, when it was exported
+	Revisions []BundleRevision `json:"revisions,omitempty"`
+}
+
+// BundleRevision is one past version of a bundled floor plan.
+type BundleRevision struct {
+	Version      int               `json:"version"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	RestoredFrom *int              `json:"restoredFrom,omitempty"`
+	Tables       []json.RawMessage `json:"tables"`
+	Guests       []json.RawMessage `json:"guests"`
+	Labels       []json.RawMessage `json:"labels"`
+}
+
+func (b *FloorPlanBundle) Validate() error {
+	if b.Format != BundleFormat {
+		return fmt.Errorf("format must be %q", BundleFormat)
+	}
+	if b.FormatVersion < 1 || b.FormatVersion > BundleFormatVersion {
+		return fmt.Errorf("formatVersion %d is not supported", b.FormatVersion)
+	}
+	if b.Name == "" {
+		b.Name = "Untitled Floor Plan"
+	}
+	if len(b.Name) > MaxFloorPlanNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxFloorPlanNameLength)
+	}
+	if err := validateBundleItems("", b.Tables, b.Guests, b.Labels); err != nil {
+		return err
+	}
+	if len(b.Revisions) > maxBundleRevisions {
+		return fmt.Errorf("revisions exceeds maximum of %d items", maxBundleRevisions)
+	}
+	for i, rev := range b.Revisions {
+		if rev.Version < 1 || (i > 0 && rev.Version <= b.Revisions[i-1].Version) {
+			return fmt.Errorf("revisions[%d]: versions must be positive and increasing", i)
+		}
+		if err := validateBundleItems(fmt.Sprintf("revisions[%d].", i), rev.Tables, rev.Guests, rev.Labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateBundleItems(path string, tables, guests, labels []json.RawMessage) error {
+	if len(tables) > maxBulkItems {
+		return fmt.Errorf("%stables exceeds maximum of %d items", path, maxBulkItems)
+	}
+	if len(guests) > maxBulkItems {
+		return fmt.Errorf("%sguests exceeds maximum of %d items", path, maxBulkItems)
+	}
+	if len(labels) > maxBulkItems {
+		return fmt.Errorf("%slabels exceeds maximum of %d items", path, maxBulkItems)
+	}
+	return nil
+}
+
+// FloorPlanImportResponse describes the floor plan created from a bundle.
+type FloorPlanImportResponse struct {
+	FloorPlan
+	// Revisions is the number of past versions brought along
+	Revisions int `json:"revisions"`
+	// Changes made to seat assignments that didn't agree in the bundle
+	Repairs []FieldError `json:"repairs,omitempty"`
+}
+
+// CompanionPlacement controls where auto-assignment seats a guest's companion.
+type CompanionPlacement string
+
+const (
+	CompanionNextTo CompanionPlacement = "next-to"
+	CompanionAcross CompanionPlacement = "across"
+	CompanionNone   CompanionPlacement = "none"
+)
+
+// AutoAssignConfig mirrors AssignmentConfig in the frontend's algorithms.ts.
+type AutoAssignConfig struct {
+	BalanceGuests      bool               `json:"balanceGuests"`
+	Randomize          bool               `json:"randomize"`
+	CompanionPlacement CompanionPlacement `json:"companionPlacement"`
+	// Incremental keeps every current placement and only seats unassigned
+	// guests. Pinned placements are always kept.
+	Incremental bool `json:"incremental,omitempty"`
+}
+
+type AutoAssignRequest struct {
+	// Config defaults to the frontend defaults when omitted
+	Config *AutoAssignConfig `json:"config,omitempty"`
+	// Commit saves the assignment as a new version instead of only returning it
+	Commit bool `json:"commit"`
+	// Version, when set, must match the current version
+	Version *int `json:"version,omitempty"`
+	// Seed makes randomized assignments reproducible
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+func (r *AutoAssignRequest) Validate() error {
+	if r.Config == nil {
+		r.Config = &AutoAssignConfig{BalanceGuests: true, CompanionPlacement: CompanionNextTo}
+	}
+	switch r.Config.CompanionPlacement {
+	case "":
+		r.Config.CompanionPlacement = CompanionNextTo
+	case CompanionNextTo, CompanionAcross, CompanionNone:
+	default:
+		return errors.New("companionPlacement must be next-to, across, or none")
+	}
+	return nil
+}
+
+type AutoAssignResponse struct {
+	Status          string            `json:"status"`
+	Version         int               `json:"version"`
+	Success         bool              `json:"success"`
+	Message         string            `json:"message"`
+	UnassignedCount int               `json:"unassignedCount"`
+	Tables          []json.RawMessage `json:"tables"`
+	Guests          []json.RawMessage `json:"guests"`
+	// Displaced lists the pinned guests that had to be moved
+	Displaced []string `json:"displaced,omitempty"`
+}
+
+// Seating constraint types
+const (
+	ConstraintTogether = "together"
+	ConstraintApart    = "apart"
+	ConstraintSide     = "side"
+)
+
+// Plan sides for side constraints, by table position
+const (
+	SideLeft  = "left"
+	SideRight = "right"
+)
+
+// GuestSelector picks the guests a constraint applies to. Guests matching any
+// of the criteria are selected.
+type GuestSelector struct {
+	GuestIDs []uuid.UUID        `json:"guestIds,omitempty"`
+	Dietary  DietaryRestriction `json:"dietary,omitempty"`
+	// GuestOf selects a host and everyone who is their guest
+	GuestOf *uuid.UUID `json:"guestOf,omitempty"`
+}
+
+// SeatingConstraint is a rule for the optimizer. Hard constraints outweigh
+// every soft one; soft constraints are traded off by weight.
+type SeatingConstraint struct {
+	Type   string        `json:"type"`
+	Guests GuestSelector `json:"guests"`
+	Side   string        `json:"side,omitempty"`
+	Hard   bool          `json:"hard"`
+	Weight float64       `json:"weight,omitempty"`
+}
+
+// MaxConstraintWeight caps the weight of a soft constraint, so that no soft
+// violation costs as much as a hard one.
+const MaxConstraintWeight = 100
+
+const (
+	maxConstraints       = 100
+	defaultOptimizeSteps = 20000
+	maxOptimizeSteps     = 50000
+)
+
+type OptimizeRequest struct {
+	Constraints []SeatingConstraint `json:"constraints"`
+	// Locked guests keep their current table and seat
+	Locked     []uuid.UUID `json:"locked,omitempty"`
+	Iterations int         `json:"iterations,omitempty"`
+	Seed       *int64      `json:"seed,omitempty"`
+	Commit     bool        `json:"commit"`
+	Version    *int        `json:"version,omitempty"`
+}
+
+func (r *OptimizeRequest) Validate() error {
+	if len(r.Constraints) > maxConstraints {
+		return fmt.Errorf("constraints exceeds maximum of %d items", maxConstraints)
+	}
+	if len(r.Locked) > maxBulkItems {
+		return fmt.Errorf("locked exceeds maximum of %d items", maxBulkItems)
+	}
+	for i := range r.Constraints {
+		c := &r.Constraints[i]
+		switch c.Type {
+		case ConstraintTogether, ConstraintApart:
+		case ConstraintSide:
+			if c.Side != SideLeft && c.Side != SideRight {
+				return fmt.Errorf("constraints[%d]: side must be left or right", i)
+			}
+		default:
+			return fmt.Errorf("constraints[%d]: type must be together, apart, or side", i)
+		}
+		sel := c.Guests
+		if len(sel.GuestIDs) == 0 && sel.Dietary == "" && sel.GuestOf == nil {
+			return fmt.Errorf("constraints[%d]: guests must select at least one guest", i)
+		}
+		if len(sel.GuestIDs) > maxBulkItems {
+			return fmt.Errorf("constraints[%d]: guestIds exceeds maximum of %d items", i, maxBulkItems)
+		}
+		if sel.Dietary != "" && !sel.Dietary.Valid() {
+			return fmt.Errorf("constraints[%d]: invalid dietary restriction", i)
+		}
+		if c.Weight < 0 {
+			return fmt.Errorf("constraints[%d]: weight must not be negative", i)
+		}
+		if c.Weight > MaxConstraintWeight {
+			return fmt.Errorf("constraints[%d]: weight must be at most %d", i, MaxConstraintWeight)
+		}
+		if c.Weight == 0 {
+			c.Weight = 1
+		}
+	}
+	if r.Iterations < 0 || r.Iterations > maxOptimizeSteps {
+		return fmt.Errorf("iterations must be between 0 and %d", maxOptimizeSteps)
+	}
+	if r.Iterations == 0 {
+		r.Iterations = defaultOptimizeSteps
+	}
+	return nil
+}
+
+// ConstraintReport tells how well the returned seating meets one constraint.
+type ConstraintReport struct {
+	Index      int    `json:"index"`
+	Type       string `json:"type"`
+	Hard       bool   `json:"hard"`
+	Satisfied  bool   `json:"satisfied"`
+	Violations int    `json:"violations"`
+	Guests     int    `json:"guests"`
+}
+
+type OptimizeResponse struct {
+	AutoAssignResponse
+	// Penalty is the weighted cost of the seating; lower is better
+	Penalty       float64            `json:"penalty"`
+	HardSatisfied bool               `json:"hardSatisfied"`
+	Report        []ConstraintReport `json:"report"`
+}
+
+const maxImportSize = 2 * 1024 * 1024 // 2 MB of CSV text
+
+// GuestColumns maps guest fields to CSV header names. Only Name is required;
+// optional columns that are left empty are looked up by their field name.
+type GuestColumns struct {
+	Name                string `json:"name"`
+	DietaryRestrictions string `json:"dietaryRestrictions,omitempty"`
+	// GuestOf holds the name of the guest's host
+	GuestOf string `json:"guestOf,omitempty"`
+	// Table holds a table name or ID
+	Table string `json:"table,omitempty"`
+}
+
+type GuestImportRequest struct {
+	CSV     string       `json:"csv"`
+	Columns GuestColumns `json:"columns"`
+	// Delimiter defaults to a comma
+	Delimiter string `json:"delimiter,omitempty"`
+	// IncludeDuplicates imports likely duplicates instead of skipping them
+	IncludeDuplicates bool `json:"includeDuplicates,omitempty"`
+	// Commit saves the guests as a new version instead of only previewing them
+	Commit  bool `json:"commit"`
+	Version *int `json:"version,omitempty"`
+}
+
+func (r *GuestImportRequest) Validate() error {
+	if r.CSV == "" {
+		return errors.New("csv is required")
+	}
+	if len(r.CSV) > maxImportSize {
+		return fmt.Errorf("csv exceeds maximum of %d bytes", maxImportSize)
+	}
+	switch r.Delimiter {
+	case "":
+		r.Delimiter = ","
+	case ",", ";", "\t", "|":
+	default:
+		return errors.New("delimiter must be one of , ; | or a tab")
+	}
+	if r.Columns.Name == "" {
+		r.Columns.Name = "name"
+	}
+	return nil
+}
+
+// Guest import row statuses
+const (
+	ImportRowNew       = "new"
+	ImportRowDuplicate = "duplicate"
+	ImportRowInvalid   = "invalid"
+)
+
+// GuestImportRow reports what happened to one CSV row.
+type GuestImportRow struct {
+	// Line is the 1-based line in the CSV, counting the header
+	Line   int    `json:"line"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// GuestID is the ID of the imported guest
+	GuestID *string `json:"guestId,omitempty"`
+	// DuplicateOf is the existing guest, or earlier row's guest, with the same name
+	DuplicateOf *string  `json:"duplicateOf,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+type GuestImportResponse struct {
+	Status     string            `json:"status"`
+	Version    int               `json:"version"`
+	Imported   int               `json:"imported"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Rows       []GuestImportRow  `json:"rows"`
+	Guests     []json.RawMessage `json:"guests"`
+	// Issues lists table assignments that had to be dropped, e.g. a full table
+	Issues []FieldError `json:"issues,omitempty"`
+	// Repaired lists existing guests whose seating was corrected along with
+	// the import, e.g. a seat on a deleted table
+	Repaired []json.RawMessage `json:"repaired,omitempty"`
+}
+
 // Organization models
 
 type Organization struct {
@@ -138,6 +664,28 @@ type OrganizationInvitation struct {
 	ExpiresAt      time.Time `json:"expiresAt"`
 }
 
+// FloorPlanTemplate is a layout of tables and labels, without guests, that
+// members of an organization can start floor plans from.
+type FloorPlanTemplate struct {
+	ID                uuid.UUID  `json:"id"`
+	OrganizationID    uuid.UUID  `json:"organizationId"`
+	Name              string     `json:"name"`
+	Description       string     `json:"description"`
+	SourceFloorPlanID *uuid.UUID `json:"sourceFloorPlanId,omitempty"`
+	CreatedBy         uuid.UUID  `json:"createdBy"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	TableCount        int        `json:"tableCount"`
+	LabelCount        int        `json:"labelCount"`
+}
+
+// FloorPlanTemplateFull is a template including its tables and labels.
+type FloorPlanTemplateFull struct {
+	FloorPlanTemplate
+	Tables []json.RawMessage `json:"tables"`
+	Labels []json.RawMessage `json:"labels"`
+}
+
 // Request types
 
 type CreateOrganizationRequest struct {
@@ -200,6 +748,27 @@ func (r *UpdateMemberRoleRequest) Validate() error {
 	return nil
 }
 
+// PublishTemplateRequest publishes the layout of a floor plan as a template.
+type PublishTemplateRequest struct {
+	FloorPlanID uuid.UUID `json:"floorPlanId"`
+	// Name defaults to the floor plan's name
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (r *PublishTemplateRequest) Validate() error {
+	if r.FloorPlanID == uuid.Nil {
+		return errors.New("floorPlanId is required")
+	}
+	if len(r.Name) > 200 {
+		return errors.New("name must be at most 200 characters")
+	}
+	if len(r.Description) > 2000 {
+		return errors.New("description must be at most 2000 characters")
+	}
+	return nil
+}
+
 type ShareFloorPlanRequest struct {
 	OrganizationID uuid.UUID `json:"organizationId"`
 }
@@ -208,12 +777,55 @@ type ShareToken struct {
 	ID          uuid.UUID  `json:"id"`
 	FloorPlanID uuid.UUID  `json:"floorPlanId"`
 	Token       string     `json:"token"`
+	Scope       ShareScope `json:"scope"`
 	CreatedBy   uuid.UUID  `json:"createdBy"`
 	CreatedAt   time.Time  `json:"createdAt"`
 	IsActive    bool       `json:"isActive"`
 	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
 }
 
+// ShareScope is how much of a floor plan a public share link shows.
+type ShareScope string
+
+const (
+	// ShareScopeLayout shows tables and labels without guests
+	ShareScopeLayout ShareScope = "layout"
+	// ShareScopeNames shows guests' names and seats without dietary restrictions
+	ShareScopeNames ShareScope = "names"
+	ShareScopeFull  ShareScope = "full"
+)
+
+func (s ShareScope) Valid() bool {
+	switch s {
+	case ShareScopeLayout, ShareScopeNames, ShareScopeFull:
+		return true
+	}
+	return false
+}
+
+// CreateShareTokenRequest picks the scope of a new share link, names unless
+// given.
+type CreateShareTokenRequest struct {
+	Scope ShareScope `json:"scope"`
+}
+
+func (r *CreateShareTokenRequest) Validate() error {
+	if r.Scope == "" {
+		r.Scope = ShareScopeNames
+	}
+	if !r.Scope.Valid() {
+		return errors.New("scope must be layout, names, or full")
+	}
+	return nil
+}
+
+// SeatLookup is where the guest found by a public name lookup sits. Table
+// and Seat are null until the guest is assigned.
+type SeatLookup struct {
+	Table *string `json:"table"`
+	Seat  *string `json:"seat"`
+}
+
 func (r *ShareFloorPlanRequest) Validate() error {
 	if r.OrganizationID == uuid.Nil {
 		return errors.New("organizationId is required")
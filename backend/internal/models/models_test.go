@@ -2,8 +2,11 @@ package models
 
 import (
 	"encoding/json"
+	"math"
 	"strings"
 	"testing"
+
+	"github.com/google/uuid"
 )
 
 func TestCreateFloorPlanRequest_Validate(t *testing.T) {
@@ -92,3 +95,339 @@ func TestBulkSaveRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchFloorPlanRequest_Validate(t *testing.T) {
+	id := uuid.New()
+	op := func(kind, entity string, data string) EntityOperation {
+		o := EntityOperation{Op: kind, Entity: entity, ID: id}
+		if data != "" {
+			o.Data = json.RawMessage(data)
+		}
+		return o
+	}
+
+	tests := []struct {
+		name    string
+		ops     []EntityOperation
+		wantErr bool
+	}{
+		{"no operations", nil, true},
+		{"create", []EntityOperation{op(OpCreate, EntityTable, `{"id":"`+id.String()+`","name":"T1"}`)}, false},
+		{"create without data id", []EntityOperation{op(OpCreate, EntityTable, `{"name":"T1"}`)}, true},
+		{"create with mismatched id", []EntityOperation{op(OpCreate, EntityGuest, `{"id":"`+uuid.New().String()+`"}`)}, true},
+		{"update partial", []EntityOperation{op(OpUpdate, EntityGuest, `{"name":"Ann"}`)}, false},
+		{"update non-object", []EntityOperation{op(OpUpdate, EntityGuest, `[1]`)}, true},
+		{"update clearing id", []EntityOperation{op(OpUpdate, EntityGuest, `{"id":null}`)}, true},
+		{"delete", []EntityOperation{op(OpDelete, EntityLabel, "")}, false},
+		{"unknown op", []EntityOperation{op("move", EntityLabel, "")}, true},
+		{"unknown entity", []EntityOperation{op(OpDelete, "chair", "")}, true},
+		{"missing id", []EntityOperation{{Op: OpDelete, Entity: EntityTable}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := PatchFloorPlanRequest{Version: 1, Operations: tt.ops}
+			err := req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptimizeRequest_Validate(t *testing.T) {
+	ids := GuestSelector{GuestIDs: []uuid.UUID{uuid.New()}}
+	tests := []struct {
+		name    string
+		req     OptimizeRequest
+		wantErr bool
+	}{
+		{"no constraints", OptimizeRequest{}, false},
+		{"together", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintTogether, Guests: ids}}}, false},
+		{"side", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintSide, Side: SideLeft, Guests: ids}}}, false},
+		{"side without side", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintSide, Guests: ids}}}, true},
+		{"unknown type", OptimizeRequest{Constraints: []SeatingConstraint{{Type: "near", Guests: ids}}}, true},
+		{"empty selector", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintApart}}}, true},
+		{"bad dietary", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintTogether, Guests: GuestSelector{Dietary: "KETO"}}}}, true},
+		{"negative weight", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintTogether, Guests: ids, Weight: -1}}}, true},
+		{"max weight", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintTogether, Guests: ids, Weight: MaxConstraintWeight}}}, false},
+		{"weight too large", OptimizeRequest{Constraints: []SeatingConstraint{{Type: ConstraintTogether, Guests: ids, Weight: MaxConstraintWeight + 1}}}, true},
+		{"too many iterations", OptimizeRequest{Iterations: maxOptimizeSteps + 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGuestImportRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     GuestImportRequest
+		wantErr bool
+	}{
+		{"minimal", GuestImportRequest{CSV: "name\nAlice"}, false},
+		{"semicolon", GuestImportRequest{CSV: "name\nAlice", Delimiter: ";"}, false},
+		{"empty csv", GuestImportRequest{}, true},
+		{"bad delimiter", GuestImportRequest{CSV: "name\nAlice", Delimiter: "::"}, true},
+		{"too large", GuestImportRequest{CSV: strings.Repeat("a", maxImportSize+1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := GuestImportRequest{CSV: "name\nAlice"}
+	req.Validate()
+	if req.Delimiter != "," || req.Columns.Name != "name" {
+		t.Errorf("expected defaults, got %+v", req)
+	}
+}
+
+func TestFloorPlanBundle_Validate(t *testing.T) {
+	bundle := func(revisions ...int) FloorPlanBundle {
+		b := FloorPlanBundle{Format: BundleFormat, FormatVersion: BundleFormatVersion}
+		for _, v := range revisions {
+			b.Revisions = append(b.Revisions, BundleRevision{Version: v})
+		}
+		return b
+	}
+	tests := []struct {
+		name    string
+		bundle  FloorPlanBundle
+		wantErr bool
+	}{
+		{"minimal", bundle(), false},
+		{"with history", bundle(1, 2, 5), false},
+		{"wrong format", FloorPlanBundle{Format: "csv", FormatVersion: 1}, true},
+		{"newer format version", FloorPlanBundle{Format: BundleFormat, FormatVersion: BundleFormatVersion + 1}, true},
+		{"unordered history", bundle(2, 1), true},
+		{"zero version", bundle(0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.bundle.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	b := bundle()
+	b.Validate()
+	if b.Name != "Untitled Floor Plan" {
+		t.Errorf("expected default name, got %q", b.Name)
+	}
+}
+
+func TestPublishTemplateRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     PublishTemplateRequest
+		wantErr bool
+	}{
+		{"minimal", PublishTemplateRequest{FloorPlanID: uuid.New()}, false},
+		{"missing floor plan", PublishTemplateRequest{Name: "Banquet"}, true},
+		{"long name", PublishTemplateRequest{FloorPlanID: uuid.New(), Name: strings.Repeat("a", 201)}, true},
+		{"long description", PublishTemplateRequest{FloorPlanID: uuid.New(), Description: strings.Repeat("a", 2001)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoomRequest_Validate(t *testing.T) {
+	square := []Position{{X: 0, Y: 0}, {X: 1000, Y: 0}, {X: 1000, Y: 800}, {X: 0, Y: 800}}
+	tests := []struct {
+		name    string
+		req     RoomRequest
+		wantErr bool
+	}{
+		{"minimal", RoomRequest{Name: "Hall"}, false},
+		{"outline", RoomRequest{Name: "Hall", RoomGeometry: RoomGeometry{Units: RoomUnitFeet, Outline: square}}, false},
+		{"wall", RoomRequest{Name: "Hall", RoomGeometry: RoomGeometry{Obstacles: []Obstacle{{Type: ObstacleWall, Points: square[:2]}}}}, false},
+		{"missing name", RoomRequest{}, true},
+		{"bad units", RoomRequest{Name: "Hall", RoomGeometry: RoomGeometry{Units: "yd"}}, true},
+		{"negative scale", RoomRequest{Name: "Hall", RoomGeometry: RoomGeometry{Scale: -1}}, true},
+		{"two point outline", RoomRequest{Name: "Hall", RoomGeometry: RoomGeometry{Outline: square[:2]}}, true},
+		{"two point stage", RoomRequest{Name: "Hall", RoomGeometry: RoomGeometry{Obstacles: []Obstacle{{Type: ObstacleStage, Points: square[:2]}}}}, true},
+		{"unknown obstacle", RoomRequest{Name: "Hall", RoomGeometry: RoomGeometry{Obstacles: []Obstacle{{Type: "BAR", Points: square}}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := RoomRequest{Name: "Hall"}
+	req.Validate()
+	if req.Units != RoomUnitMeters || req.Scale != DefaultRoomScale || req.Outline == nil || req.Obstacles == nil {
+		t.Errorf("expected defaults, got %+v", req.RoomGeometry)
+	}
+}
+
+func TestValidateLayoutRequest_Validate(t *testing.T) {
+	aisle := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name    string
+		req     ValidateLayoutRequest
+		wantErr bool
+	}{
+		{"defaults", ValidateLayoutRequest{}, false},
+		{"aisle", ValidateLayoutRequest{MinAisle: aisle(1.2)}, false},
+		{"no aisle", ValidateLayoutRequest{MinAisle: aisle(0)}, false},
+		{"negative", ValidateLayoutRequest{MinAisle: aisle(-1)}, true},
+		{"infinite", ValidateLayoutRequest{MinAisle: aisle(math.Inf(1))}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAutoLayoutRequest_Validate(t *testing.T) {
+	size := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name    string
+		req     AutoLayoutRequest
+		wantErr bool
+	}{
+		{"defaults", AutoLayoutRequest{}, false},
+		{"radial", AutoLayoutRequest{Style: AutoLayoutRadial, Spacing: size(1.5)}, false},
+		{"area", AutoLayoutRequest{Width: size(20), Height: size(12)}, false},
+		{"unknown style", AutoLayoutRequest{Style: "spiral"}, true},
+		{"negative spacing", AutoLayoutRequest{Spacing: size(-1)}, true},
+		{"width only", AutoLayoutRequest{Width: size(20)}, true},
+		{"zero height", AutoLayoutRequest{Width: size(20), Height: size(0)}, true},
+		{"huge spacing", AutoLayoutRequest{Spacing: size(1e300)}, true},
+		{"huge area", AutoLayoutRequest{Width: size(20), Height: size(maxLayoutExtent + 1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := AutoLayoutRequest{}
+	req.Validate()
+	if req.Style != AutoLayoutGrid {
+		t.Errorf("expected grid by default, got %q", req.Style)
+	}
+}
+
+func TestEventGuestRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     EventGuestRequest
+		wantErr bool
+	}{
+		{"valid", EventGuestRequest{Name: "Alice", DietaryRestrictions: []DietaryRestriction{DietaryVegan}}, false},
+		{"empty name", EventGuestRequest{Name: "  "}, true},
+		{"long name", EventGuestRequest{Name: strings.Repeat("a", maxEntityNameLength+1)}, true},
+		{"unknown diet", EventGuestRequest{Name: "Bob", DietaryRestrictions: []DietaryRestriction{"KETO"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := EventGuestRequest{Name: "Carol"}
+	req.Validate()
+	if req.DietaryRestrictions == nil {
+		t.Error("expected dietaryRestrictions to default to an empty list")
+	}
+}
+
+func TestContactRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ContactRequest
+		wantErr bool
+	}{
+		{"valid", ContactRequest{Name: "Alice", Email: "alice@example.com", Tags: []string{"board"}}, false},
+		{"no email", ContactRequest{Name: "Bob"}, false},
+		{"empty name", ContactRequest{Name: " "}, true},
+		{"bad email", ContactRequest{Name: "Carol", Email: "carol"}, true},
+		{"unknown diet", ContactRequest{Name: "Dan", DietaryRestrictions: []DietaryRestriction{"KETO"}}, true},
+		{"empty tag", ContactRequest{Name: "Eve", Tags: []string{" "}}, true},
+		{"long notes", ContactRequest{Name: "Finn", Notes: strings.Repeat("a", maxContactNotes+1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := ContactRequest{Name: "Gus", Tags: []string{" vip ", "vip", "board"}}
+	req.Validate()
+	if len(req.Tags) != 2 || req.Tags[0] != "vip" || req.Tags[1] != "board" {
+		t.Errorf("expected trimmed, deduplicated tags, got %q", req.Tags)
+	}
+}
+
+func TestRSVPRequest_Validate(t *testing.T) {
+	diets := func(d ...DietaryRestriction) *[]DietaryRestriction { return &d }
+	tests := []struct {
+		name    string
+		req     RSVPRequest
+		wantErr bool
+	}{
+		{"attending", RSVPRequest{Status: RSVPAttending, DietaryRestrictions: diets(DietaryVegan)}, false},
+		{"declined", RSVPRequest{Status: RSVPDeclined}, false},
+		{"no status", RSVPRequest{}, true},
+		{"unknown status", RSVPRequest{Status: "MAYBE"}, true},
+		{"unknown diet", RSVPRequest{Status: RSVPAttending, DietaryRestrictions: diets("KETO")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateShareTokenRequest_Validate(t *testing.T) {
+	req := CreateShareTokenRequest{}
+	if err := req.Validate(); err != nil || req.Scope != ShareScopeNames {
+		t.Errorf("expected names by default, got %q (%v)", req.Scope, err)
+	}
+	req = CreateShareTokenRequest{Scope: ShareScopeLayout}
+	if err := req.Validate(); err != nil || req.Scope != ShareScopeLayout {
+		t.Errorf("expected layout to be kept, got %q (%v)", req.Scope, err)
+	}
+	req = CreateShareTokenRequest{Scope: "everything"}
+	if err := req.Validate(); err == nil {
+		t.Error("expected an unknown scope to be rejected")
+	}
+}
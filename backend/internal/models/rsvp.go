@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxRSVPTokensPerRequest = 1000
+
+// RSVPStatus is a guest's answer to their RSVP link.
+type RSVPStatus string
+
+const (
+	RSVPAttending RSVPStatus = "ATTENDING"
+	RSVPDeclined  RSVPStatus = "DECLINED"
+)
+
+func (s RSVPStatus) Valid() bool {
+	return s == RSVPAttending || s == RSVPDeclined
+}
+
+// CreateRSVPTokensRequest creates personal RSVP links for guests of a floor
+// plan, replacing links the guests already have.
+type CreateRSVPTokensRequest struct {
+	GuestIDs []uuid.UUID `json:"guestIds"`
+}
+
+func (r *CreateRSVPTokensRequest) Validate() error {
+	if len(r.GuestIDs) == 0 {
+		return errors.New("guestIds is required")
+	}
+	if len(r.GuestIDs) > maxRSVPTokensPerRequest {
+		return fmt.Errorf("at most %d guests can be invited at once", maxRSVPTokensPerRequest)
+	}
+	seen := make(map[uuid.UUID]bool, len(r.GuestIDs))
+	for _, id := range r.GuestIDs {
+		if seen[id] {
+			return fmt.Errorf("guest %s is listed more than once", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// RSVPToken is a guest's personal RSVP link.
+type RSVPToken struct {
+	GuestID   uuid.UUID `json:"guestId"`
+	GuestName string    `json:"guestName"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RSVPInvitation is what a guest sees when opening their RSVP link.
+type RSVPInvitation struct {
+	FloorPlanName       string               `json:"floorPlanName"`
+	GuestName           string               `json:"guestName"`
+	DietaryRestrictions []DietaryRestriction `json:"dietaryRestrictions"`
+	Status              RSVPStatus           `json:"status,omitempty"`
+	RespondedAt         *time.Time           `json:"respondedAt,omitempty"`
+	ExpiresAt           *time.Time           `json:"expiresAt,omitempty"`
+}
+
+// RSVPRequest is a guest's answer. Dietary restrictions are left as they are
+// when omitted.
+type RSVPRequest struct {
+	Status              RSVPStatus            `json:"status"`
+	DietaryRestrictions *[]DietaryRestriction `json:"dietaryRestrictions,omitempty"`
+}
+
+func (r *RSVPRequest) Validate() error {
+	if !r.Status.Valid() {
+		return errors.New("status must be ATTENDING or DECLINED")
+	}
+	if r.DietaryRestrictions != nil {
+		for _, d := range *r.DietaryRestrictions {
+			if !d.Valid() {
+				return errors.New("dietaryRestrictions must be VEGETARIAN, VEGAN, PESCATARIAN, LACTOSE_INTOLERANT, or NONE")
+			}
+		}
+	}
+	return nil
+}
+
+// RSVPSummary counts the answers of a floor plan's guests. Pending guests
+// have an active RSVP link but haven't answered.
+type RSVPSummary struct {
+	Total      int         `json:"total"`
+	Attending  int         `json:"attending"`
+	Declined   int         `json:"declined"`
+	Pending    int         `json:"pending"`
+	NotInvited int         `json:"notInvited"`
+	Guests     []RSVPGuest `json:"guests"`
+}
+
+// RSVPGuest is a guest's RSVP state, with their active link if they have one.
+type RSVPGuest struct {
+	GuestID     string     `json:"guestId"`
+	Name        string     `json:"name"`
+	Status      RSVPStatus `json:"status,omitempty"`
+	Token       *string    `json:"token,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	RespondedAt *time.Time `json:"respondedAt,omitempty"`
+}
@@ -0,0 +1,337 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoomUnit is the unit a room's real dimensions are given in.
+type RoomUnit string
+
+const (
+	RoomUnitMeters RoomUnit = "m"
+	RoomUnitFeet   RoomUnit = "ft"
+)
+
+func (u RoomUnit) Valid() bool {
+	return u == RoomUnitMeters || u == RoomUnitFeet
+}
+
+// ObstacleType is the kind of fixed feature inside a room.
+type ObstacleType string
+
+const (
+	ObstacleWall       ObstacleType = "WALL"
+	ObstaclePillar     ObstacleType = "PILLAR"
+	ObstacleDoor       ObstacleType = "DOOR"
+	ObstacleStage      ObstacleType = "STAGE"
+	ObstacleDanceFloor ObstacleType = "DANCE_FLOOR"
+	ObstacleOther      ObstacleType = "OTHER"
+)
+
+func (t ObstacleType) Valid() bool {
+	switch t {
+	case ObstacleWall, ObstaclePillar, ObstacleDoor, ObstacleStage, ObstacleDanceFloor, ObstacleOther:
+		return true
+	}
+	return false
+}
+
+// Open reports whether obstacles of this type are lines (walls and doors)
+// rather than closed areas.
+func (t ObstacleType) Open() bool {
+	return t == ObstacleWall || t == ObstacleDoor
+}
+
+// Obstacle is a fixed feature of a room. Walls and doors are polylines, all
+// other types are polygons.
+type Obstacle struct {
+	Type   ObstacleType `json:"type"`
+	Label  string       `json:"label,omitempty"`
+	Points []Position   `json:"points"`
+}
+
+const (
+	DefaultRoomScale   = 100.0
+	maxRoomPoints      = 1000
+	maxRoomObstacles   = 500
+	maxObstacleLabel   = 200
+	maxVenueAddress    = 500
+	maxVenueNameLength = 200
+	// maxLayoutExtent bounds auto-layout spacing and area, so positions stay
+	// finite however far apart tables are placed
+	maxLayoutExtent = 100000
+)
+
+// RoomGeometry is the shape of a room in floor plan coordinates, the same
+// coordinates table and label positions use.
+type RoomGeometry struct {
+	Units RoomUnit `json:"units"`
+	// Scale is the number of plan coordinates per unit
+	Scale float64 `json:"scale"`
+	// Outline is the boundary polygon of the room; empty means unbounded
+	Outline   []Position `json:"outline"`
+	Obstacles []Obstacle `json:"obstacles"`
+}
+
+// Validate checks the geometry. Missing units and scale are defaulted.
+func (g *RoomGeometry) Validate() FieldErrors {
+	var errs FieldErrors
+	if g.Units == "" {
+		g.Units = RoomUnitMeters
+	}
+	if g.Scale == 0 {
+		g.Scale = DefaultRoomScale
+	}
+	if g.Outline == nil {
+		g.Outline = []Position{}
+	}
+	if g.Obstacles == nil {
+		g.Obstacles = []Obstacle{}
+	}
+
+	if !g.Units.Valid() {
+		errs.add("units", "must be one of m, ft")
+	}
+	validateNumber(&errs, "scale", g.Scale)
+	if g.Scale < 0 {
+		errs.add("scale", "must be positive")
+	}
+	if len(g.Outline) > 0 && len(g.Outline) < 3 {
+		errs.add("outline", "must have at least 3 points")
+	}
+	validatePoints(&errs, "outline", g.Outline)
+
+	if len(g.Obstacles) > maxRoomObstacles {
+		errs.add("obstacles", "must contain at most %d obstacles", maxRoomObstacles)
+		return errs
+	}
+	for i, o := range g.Obstacles {
+		obstaclePath := fmt.Sprintf("obstacles[%d]", i)
+		if !o.Type.Valid() {
+			errs.add(obstaclePath+".type", "must be one of WALL, PILLAR, DOOR, STAGE, DANCE_FLOOR, OTHER")
+		}
+		if len(o.Label) > maxObstacleLabel {
+			errs.add(obstaclePath+".label", "must be at most %d characters", maxObstacleLabel)
+		}
+		minPoints := 3
+		if o.Type.Open() {
+			minPoints = 2
+		}
+		if len(o.Points) < minPoints {
+			errs.add(obstaclePath+".points", "must have at least %d points", minPoints)
+		}
+		validatePoints(&errs, obstaclePath+".points", o.Points)
+	}
+	return errs
+}
+
+func validatePoints(errs *FieldErrors, path string, pts []Position) {
+	if len(pts) > maxRoomPoints {
+		errs.add(path, "must have at most %d points", maxRoomPoints)
+		return
+	}
+	for i, p := range pts {
+		validateNumber(errs, fmt.Sprintf("%s[%d].x", path, i), p.X)
+		validateNumber(errs, fmt.Sprintf("%s[%d].y", path, i), p.Y)
+	}
+}
+
+// Venue is a place events are held at, owned by a user and optionally shared
+// with an organization like a floor plan.
+type Venue struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"userId"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	Name           string     `json:"name"`
+	Address        string     `json:"address"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// VenueFull is a venue with its rooms.
+type VenueFull struct {
+	Venue
+	Rooms []Room `json:"rooms"`
+}
+
+// Room is a space in a venue that floor plans can be laid out in.
+type Room struct {
+	ID      uuid.UUID `json:"id"`
+	VenueID uuid.UUID `json:"venueId"`
+	Name    string    `json:"name"`
+	RoomGeometry
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type CreateVenueRequest struct {
+	Name           string     `json:"name"`
+	Address        string     `json:"address"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+}
+
+func (r *CreateVenueRequest) Validate() error {
+	return validateVenue(r.Name, r.Address)
+}
+
+type UpdateVenueRequest struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+func (r *UpdateVenueRequest) Validate() error {
+	return validateVenue(r.Name, r.Address)
+}
+
+func validateVenue(name, address string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("name is required")
+	}
+	if len(name) > maxVenueNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxVenueNameLength)
+	}
+	if len(address) > maxVenueAddress {
+		return fmt.Errorf("address must be at most %d characters", maxVenueAddress)
+	}
+	return nil
+}
+
+// RoomRequest creates or replaces a room.
+type RoomRequest struct {
+	Name string `json:"name"`
+	RoomGeometry
+}
+
+func (r *RoomRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > maxVenueNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxVenueNameLength)
+	}
+	if errs := r.RoomGeometry.Validate(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// SetFloorPlanRoomRequest places a floor plan in a room, or takes it out of
+// its room when RoomID is null.
+type SetFloorPlanRoomRequest struct {
+	RoomID *uuid.UUID `json:"roomId"`
+}
+
+func (r *SetFloorPlanRoomRequest) Validate() error {
+	return nil
+}
+
+// Layout issue types found when checking where tables are placed
+const (
+	LayoutOverlap   = "overlap"
+	LayoutOutOfRoom = "out_of_room"
+	LayoutObstacle  = "obstacle"
+	LayoutAisle     = "aisle"
+)
+
+// LayoutIssue is a problem with the placement of one or two tables.
+type LayoutIssue struct {
+	Type   string   `json:"type"`
+	Tables []string `json:"tables"`
+	// Obstacle is the index of the room obstacle involved
+	Obstacle *int `json:"obstacle,omitempty"`
+	// Gap is the space left for an aisle, in the units of the check
+	Gap     *float64 `json:"gap,omitempty"`
+	Message string   `json:"message"`
+}
+
+type ValidateLayoutRequest struct {
+	// MinAisle is the narrowest allowed gap between tables and in front of
+	// doors, in room units; in plan coordinates when the plan has no room
+	MinAisle *float64 `json:"minAisle,omitempty"`
+}
+
+func (r *ValidateLayoutRequest) Validate() error {
+	if r.MinAisle != nil && !nonNegative(*r.MinAisle) {
+		return errors.New("minAisle must be a non-negative number")
+	}
+	return nil
+}
+
+type ValidateLayoutResponse struct {
+	Valid bool `json:"valid"`
+	// Units of minAisle and gaps; empty for plan coordinates
+	Units    RoomUnit      `json:"units,omitempty"`
+	MinAisle float64       `json:"minAisle"`
+	Issues   []LayoutIssue `json:"issues"`
+}
+
+// AutoLayoutStyle is how automatic layout arranges tables.
+type AutoLayoutStyle string
+
+const (
+	AutoLayoutGrid    AutoLayoutStyle = "grid"
+	AutoLayoutBanquet AutoLayoutStyle = "banquet"
+	AutoLayoutRadial  AutoLayoutStyle = "radial"
+)
+
+func (s AutoLayoutStyle) Valid() bool {
+	return s == AutoLayoutGrid || s == AutoLayoutBanquet || s == AutoLayoutRadial
+}
+
+type AutoLayoutRequest struct {
+	// Style defaults to grid
+	Style AutoLayoutStyle `json:"style"`
+	// Spacing is the smallest gap between tables, in room units; in plan
+	// coordinates when the plan has no room. Defaults to the minimum aisle.
+	Spacing *float64 `json:"spacing,omitempty"`
+	// Width and Height give the area to fill when the plan's room has no
+	// outline, in the same units as Spacing
+	Width  *float64 `json:"width,omitempty"`
+	Height *float64 `json:"height,omitempty"`
+	// Commit saves the layout as a new version instead of only returning it
+	Commit bool `json:"commit"`
+	// Version, when set, must match the current version
+	Version *int `json:"version,omitempty"`
+}
+
+func (r *AutoLayoutRequest) Validate() error {
+	if r.Style == "" {
+		r.Style = AutoLayoutGrid
+	}
+	if !r.Style.Valid() {
+		return errors.New("style must be grid, banquet, or radial")
+	}
+	if r.Spacing != nil && !nonNegative(*r.Spacing) {
+		return errors.New("spacing must be a non-negative number")
+	}
+	if r.Spacing != nil && *r.Spacing > maxLayoutExtent {
+		return fmt.Errorf("spacing must not exceed %d", maxLayoutExtent)
+	}
+	if (r.Width == nil) != (r.Height == nil) {
+		return errors.New("width and height must be given together")
+	}
+	if r.Width != nil && (!nonNegative(*r.Width) || *r.Width == 0 || !nonNegative(*r.Height) || *r.Height == 0) {
+		return errors.New("width and height must be positive numbers")
+	}
+	if r.Width != nil && (*r.Width > maxLayoutExtent || *r.Height > maxLayoutExtent) {
+		return fmt.Errorf("width and height must not exceed %d", maxLayoutExtent)
+	}
+	return nil
+}
+
+type AutoLayoutResponse struct {
+	Status  string            `json:"status"`
+	Version int               `json:"version"`
+	Tables  []json.RawMessage `json:"tables"`
+}
+
+func nonNegative(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0) && v >= 0
+}
@@ -0,0 +1,115 @@
+// Package namematch finds the guest someone means when typing their name,
+// forgiving case, accents, word order, missing words and small typos.
+package namematch
+
+import (
+	"github.com/frallan97/table-planner-backend/internal/guestimport"
+)
+
+// How closely a name matches a query, best first.
+const (
+	matchFull   = iota // the same words
+	matchWords         // every query word is a word of the name
+	matchPrefix        // every query word starts a word of the name
+	matchFuzzy         // every query word is a typo away from a word of the name
+	noMatch
+)
+
+// Best returns the indexes of the names that match query most closely, in
+// order, or nil when none match.
+func Best(query string, names []string) []int {
+	words := guestimport.NameWords(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	best := noMatch
+	var out []int
+	for i, name := range names {
+		score := rank(words, guestimport.NameWords(name))
+		switch {
+		case score < best:
+			best, out = score, []int{i}
+		case score == best && score != noMatch:
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// rank scores how well the query words match a name's words. Each query word
+// must match a different word of the name.
+func rank(query, name []string) int {
+	if len(query) > len(name) {
+		return noMatch
+	}
+	score := matchWords
+	used := make([]bool, len(name))
+	for _, q := range query {
+		// Take the best unused word for q
+		bestWord, bestScore := -1, noMatch
+		for j, n := range name {
+			if used[j] {
+				continue
+			}
+			if s := wordScore(q, n); s < bestScore {
+				bestWord, bestScore = j, s
+			}
+		}
+		if bestWord < 0 {
+			return noMatch
+		}
+		used[bestWord] = true
+		score = max(score, bestScore)
+	}
+	if score == matchWords && len(query) == len(name) {
+		return matchFull
+	}
+	return score
+}
+
+func wordScore(q, n string) int {
+	switch {
+	case q == n:
+		return matchWords
+	case len(q) < len(n) && n[:len(q)] == q:
+		return matchPrefix
+	case distance(q, n) <= typos(q):
+		return matchFuzzy
+	}
+	return noMatch
+}
+
+// typos is how many edits a query word of this length may be off by.
+func typos(q string) int {
+	switch n := len([]rune(q)); {
+	case n < 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
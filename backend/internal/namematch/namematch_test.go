@@ -0,0 +1,53 @@
+package namematch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBest(t *testing.T) {
+	names := []string{"John Smith", "Johnny Walker", "Åsa Öberg", "Jane Smith", "Mary-Ann O'Neil"}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"john smith", []int{0}},
+		{"Smith, John", []int{0}},
+		{"asa oberg", []int{2}},
+		{"  ÅSA  ", []int{2}},
+		{"smith", []int{0, 3}},
+		{"john", []int{0}},
+		{"joh", []int{0, 1}},
+		{"jon smith", []int{0}},
+		{"mary ann oneil", []int{4}},
+		{"mary ann o neil", []int{4}},
+		{"walkr", []int{1}},
+		{"bob", nil},
+		{"...", nil},
+		{"john smith jr", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := Best(tt.query, names); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Best(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"kitten", "sitting", 3},
+		{"öberg", "oberg", 1},
+	}
+	for _, tt := range tests {
+		if got := distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package remap gives copies of floor plan entities fresh IDs, keeping the
+// references between them intact, so a copy never collides with the rows it
+// was made from.
+package remap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// IDs maps original entity IDs to their replacements. The same original ID
+// always maps to the same replacement, so tables, guests, labels and past
+// revisions of one plan can be remapped separately.
+type IDs map[string]string
+
+// New returns an empty mapping.
+func New() IDs {
+	return IDs{}
+}
+
+// ID returns the replacement for id, allocating one on first use.
+func (m IDs) ID(id string) string {
+	if id == "" {
+		return id
+	}
+	if newID, ok := m[id]; ok {
+		return newID
+	}
+	newID := uuid.NewString()
+	m[id] = newID
+	return newID
+}
+
+// Tables remaps table IDs and the guests seated at each table.
+func (m IDs) Tables(items []json.RawMessage) ([]json.RawMessage, error) {
+	return m.each(items, func(doc map[string]any) {
+		m.field(doc, "id")
+		if seats, ok := doc["seats"].([]any); ok {
+			for _, seat := range seats {
+				if seat, ok := seat.(map[string]any); ok {
+					m.field(seat, "guestId")
+				}
+			}
+		}
+		if guests, ok := doc["assignedGuests"].([]any); ok {
+			for i, id := range guests {
+				if id, ok := id.(string); ok {
+					guests[i] = m.ID(id)
+				}
+			}
+		}
+	})
+}
+
+// Guests remaps guest IDs, the table each guest is assigned to and the host
+// each companion is a guest of.
+func (m IDs) Guests(items []json.RawMessage) ([]json.RawMessage, error) {
+	return m.each(items, func(doc map[string]any) {
+		m.field(doc, "id")
+		m.field(doc, "assignedTableId")
+		m.field(doc, "guestOf")
+	})
+}
+
+// Labels remaps label IDs.
+func (m IDs) Labels(items []json.RawMessage) ([]json.RawMessage, error) {
+	return m.each(items, func(doc map[string]any) {
+		m.field(doc, "id")
+	})
+}
+
+// Entities remaps all tables, guests and labels of one plan or revision.
+func (m IDs) Entities(tables, guests, labels []json.RawMessage) (t, g, l []json.RawMessage, err error) {
+	if t, err = m.Tables(tables); err != nil {
+		return nil, nil, nil, fmt.Errorf("tables: %w", err)
+	}
+	if g, err = m.Guests(guests); err != nil {
+		return nil, nil, nil, fmt.Errorf("guests: %w", err)
+	}
+	if l, err = m.Labels(labels); err != nil {
+		return nil, nil, nil, fmt.Errorf("labels: %w", err)
+	}
+	return t, g, l, nil
+}
+
+// field remaps doc[key] if it holds an ID. Nulls and other types are left
+// for validation to report.
+func (m IDs) field(doc map[string]any, key string) {
+	if id, ok := doc[key].(string); ok {
+		doc[key] = m.ID(id)
+	}
+}
+
+// each rewrites every document with fn. Documents are decoded generically so
+// fields this package doesn't know about survive unchanged.
+func (m IDs) each(items []json.RawMessage, fn func(map[string]any)) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, len(items))
+	for i, item := range items {
+		var doc map[string]any
+		dec := json.NewDecoder(bytes.NewReader(item))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil || doc == nil {
+			return nil, fmt.Errorf("item %d: must be a JSON object", i)
+		}
+		fn(doc)
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = data
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package remap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEntities(t *testing.T) {
+	tables := []json.RawMessage{json.RawMessage(`{"id":"t1","name":"Head","capacity":2,"seats":[{"position":0,"guestId":"g1"},{"position":1,"guestId":null}],"assignedGuests":["g1"],"rotation":12.50}`)}
+	guests := []json.RawMessage{
+		json.RawMessage(`{"id":"g1","name":"Alice","assignedTableId":"t1","seatPosition":0,"custom":{"note":"keep"}}`),
+		json.RawMessage(`{"id":"g2","name":"Bob","assignedTableId":null}`),
+	}
+	labels := []json.RawMessage{json.RawMessage(`{"id":"l1","text":"Stage"}`)}
+
+	ids := New()
+	gotTables, gotGuests, gotLabels, err := ids.Entities(tables, guests, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var table struct {
+		ID    string `json:"id"`
+		Seats []struct {
+			GuestID *string `json:"guestId"`
+		} `json:"seats"`
+		AssignedGuests []string        `json:"assignedGuests"`
+		Rotation       json.RawMessage `json:"rotation"`
+	}
+	json.Unmarshal(gotTables[0], &table)
+	var alice, bob struct {
+		ID              string          `json:"id"`
+		AssignedTableID *string         `json:"assignedTableId"`
+		Custom          json.RawMessage `json:"custom"`
+	}
+	json.Unmarshal(gotGuests[0], &alice)
+	json.Unmarshal(gotGuests[1], &bob)
+	var label struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(gotLabels[0], &label)
+
+	if table.ID == "t1" || table.ID != ids["t1"] {
+		t.Errorf("table id not remapped: %s", table.ID)
+	}
+	if alice.ID == "g1" || *table.Seats[0].GuestID != alice.ID || table.AssignedGuests[0] != alice.ID {
+		t.Errorf("seat references not remapped consistently: %+v, guest %s", table, alice.ID)
+	}
+	if table.Seats[1].GuestID != nil {
+		t.Error("empty seat got a guest")
+	}
+	if alice.AssignedTableID == nil || *alice.AssignedTableID != table.ID {
+		t.Errorf("assignedTableId not remapped: %v", alice.AssignedTableID)
+	}
+	if bob.AssignedTableID != nil || bob.ID == alice.ID {
+		t.Errorf("unexpected bob %+v", bob)
+	}
+	if label.ID == "l1" || label.ID == "" {
+		t.Errorf("label id not remapped: %s", label.ID)
+	}
+	if string(alice.Custom) != `{"note":"keep"}` || string(table.Rotation) != "12.50" {
+		t.Errorf("unknown fields or numbers changed: %s %s", alice.Custom, table.Rotation)
+	}
+
+	// A later revision of the same plan maps to the same IDs
+	again, err := ids.Labels(labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again[0]) != string(gotLabels[0]) {
+		t.Errorf("remapping is not stable: %s vs %s", again[0], gotLabels[0])
+	}
+}
+
+func TestGuests_CompanionFollowsHost(t *testing.T) {
+	// The companion is listed before its host
+	guests := []json.RawMessage{
+		json.RawMessage(`{"id":"g2","name":"Bob","guestOf":"g1"}`),
+		json.RawMessage(`{"id":"g1","name":"Alice","guestOf":null}`),
+	}
+
+	got, err := New().Guests(guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bob, alice struct {
+		ID      string  `json:"id"`
+		GuestOf *string `json:"guestOf"`
+	}
+	json.Unmarshal(got[0], &bob)
+	json.Unmarshal(got[1], &alice)
+	if alice.ID == "g1" || bob.GuestOf == nil || *bob.GuestOf != alice.ID {
+		t.Errorf("companion doesn't follow its host: %s %s", got[0], got[1])
+	}
+	if alice.GuestOf != nil {
+		t.Errorf("host got a host: %s", got[1])
+	}
+}
+
+func TestEntities_InvalidItem(t *testing.T) {
+	_, err := New().Guests([]json.RawMessage{json.RawMessage(`[]`)})
+	if err == nil {
+		t.Error("expected an error for a non-object guest")
+	}
+}
@@ -0,0 +1,491 @@
+package seating
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Result is the outcome of an automatic assignment.
+type Result struct {
+	Guests          []models.Guest
+	Tables          []models.Table
+	Success         bool
+	Message         string
+	UnassignedCount int
+	// Displaced lists the guests whose pinned or kept placement couldn't be
+	// kept, because the seat was taken or the table full. They are assigned
+	// like any other guest.
+	Displaced []string
+}
+
+// AutoAssign seats guests at tables. It is a port of autoAssignGuests in the
+// frontend's algorithms.ts and must stay in step with it so both produce the
+// same seating. Guests and tables are copied, not modified. rng is only used
+// when cfg.Randomize is set.
+//
+// Unlike the frontend, placements of pinned guests and pinned seats are kept,
+// as is every placement when cfg.Incremental is set; only the remaining guests
+// are assigned, into the remaining seats.
+func AutoAssign(guests []models.Guest, tables []models.Table, cfg models.AutoAssignConfig, rng *rand.Rand) *Result {
+	if len(guests) == 0 {
+		return &Result{Guests: guests, Tables: tables, Message: "No guests to assign"}
+	}
+	if len(tables) == 0 {
+		return &Result{Guests: guests, Tables: tables, Message: "No tables configured", UnassignedCount: len(guests)}
+	}
+
+	cleared := make([]*models.Guest, len(guests))
+	guestByID := make(map[string]*models.Guest, len(guests))
+	for i, g := range guests {
+		g.AssignedTableID, g.SeatPosition = nil, nil
+		cleared[i] = &g
+		guestByID[g.ID] = cleared[i]
+	}
+	updated := make([]*models.Table, len(tables))
+	for i, t := range tables {
+		t.AssignedGuests = []string{}
+		seats := make([]models.Seat, len(t.Seats))
+		for j, s := range t.Seats {
+			s.GuestID = nil
+			seats[j] = s
+		}
+		t.Seats = seats
+		updated[i] = &t
+	}
+
+	assignedCount := 0
+	assignSeat := func(guest *models.Guest, table *models.Table, seatPos int) bool {
+		if seatPos < 0 || seatPos >= len(table.Seats) || table.Seats[seatPos].GuestID != nil {
+			return false
+		}
+		tableID, pos, guestID := table.ID, seatPos, guest.ID
+		guest.AssignedTableID = &tableID
+		guest.SeatPosition = &pos
+		table.Seats[seatPos].GuestID = &guestID
+		table.AssignedGuests = append(table.AssignedGuests, guest.ID)
+		assignedCount++
+		return true
+	}
+
+	firstEmpty := func(t *models.Table) (int, bool) {
+		for _, s := range t.Seats {
+			if s.GuestID == nil {
+				return s.Position, true
+			}
+		}
+		return 0, false
+	}
+
+	// Place kept guests first, those in a seat before those only at a table,
+	// who take the table's first free seat. Marking them paired keeps them
+	// out of the pairs and singles below.
+	paired := map[string]bool{}
+	var displaced []string
+	kept := keptPlacements(guests, tables, cfg.Incremental)
+	for _, i := range placeKept(len(cleared), kept, func(i int, p placement) bool {
+		seat, free := p.seat, true
+		if p.seat < 0 {
+			seat, free = firstEmpty(updated[p.table])
+		}
+		if !free || !assignSeat(cleared[i], updated[p.table], seat) {
+			return false
+		}
+		paired[cleared[i].ID] = true
+		return true
+	}) {
+		displaced = append(displaced, cleared[i].ID)
+	}
+
+	// Build pairs: host + companion
+	var pairs [][2]*models.Guest
+	var singles []*models.Guest
+	if cfg.CompanionPlacement != models.CompanionNone {
+		for _, g := range cleared {
+			if paired[g.ID] || g.GuestOf == nil {
+				continue
+			}
+			host, ok := guestByID[*g.GuestOf]
+			if ok && !paired[host.ID] {
+				pairs = append(pairs, [2]*models.Guest{host, g})
+				paired[host.ID] = true
+				paired[g.ID] = true
+			}
+		}
+		for _, g := range cleared {
+			if !paired[g.ID] {
+				singles = append(singles, g)
+			}
+		}
+	} else {
+		singles = append(singles, cleared...)
+	}
+
+	if cfg.Randomize {
+		rng.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+		rng.Shuffle(len(singles), func(i, j int) { singles[i], singles[j] = singles[j], singles[i] })
+	} else {
+		col := collate.New(language.Und)
+		sort.SliceStable(pairs, func(i, j int) bool { return col.CompareString(pairs[i][0].Name, pairs[j][0].Name) < 0 })
+		sort.SliceStable(singles, func(i, j int) bool { return col.CompareString(singles[i].Name, singles[j].Name) < 0 })
+	}
+
+	tableIdx := 0
+	if cfg.BalanceGuests {
+		for _, pair := range pairs {
+			assigned := false
+			for attempts := 0; attempts < len(updated); attempts++ {
+				table := updated[tableIdx]
+				tableIdx = (tableIdx + 1) % len(updated)
+				if seats, ok := findPairSeats(table, cfg.CompanionPlacement); ok {
+					assignSeat(pair[0], table, seats[0])
+					assignSeat(pair[1], table, seats[1])
+					assigned = true
+					break
+				}
+			}
+			if !assigned {
+				// Couldn't place as pair, fall back to singles
+				singles = append(singles, pair[0], pair[1])
+			}
+		}
+
+		for _, guest := range singles {
+			if guest.AssignedTableID != nil {
+				continue
+			}
+			placed := false
+			for attempts := 0; attempts < len(updated); attempts++ {
+				table := updated[tableIdx]
+				tableIdx = (tableIdx + 1) % len(updated)
+				if pos, ok := firstEmpty(table); ok {
+					assignSeat(guest, table, pos)
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				break
+			}
+		}
+	} else {
+		for _, pair := range pairs {
+			assigned := false
+			for tableIdx < len(updated) {
+				table := updated[tableIdx]
+				if seats, ok := findPairSeats(table, cfg.CompanionPlacement); ok {
+					assignSeat(pair[0], table, seats[0])
+					assignSeat(pair[1], table, seats[1])
+					assigned = true
+					if len(table.AssignedGuests) >= table.Capacity {
+						tableIdx++
+					}
+					break
+				}
+				tableIdx++
+			}
+			if !assigned {
+				singles = append(singles, pair[0], pair[1])
+			}
+		}
+
+		for _, guest := range singles {
+			if guest.AssignedTableID != nil {
+				continue
+			}
+			for tableIdx < len(updated) {
+				table := updated[tableIdx]
+				if pos, ok := firstEmpty(table); ok {
+					assignSeat(guest, table, pos)
+					if len(table.AssignedGuests) >= table.Capacity {
+						tableIdx++
+					}
+					break
+				}
+				tableIdx++
+			}
+		}
+	}
+
+	res := &Result{
+		Guests:          make([]models.Guest, len(cleared)),
+		Tables:          make([]models.Table, len(updated)),
+		Success:         true,
+		UnassignedCount: len(guests) - assignedCount,
+		Displaced:       displaced,
+	}
+	for i, g := range cleared {
+		res.Guests[i] = *g
+	}
+	for i, t := range updated {
+		res.Tables[i] = *t
+	}
+
+	switch {
+	case res.UnassignedCount == 0:
+		res.Message = fmt.Sprintf("Assigned all %d guests", assignedCount)
+	case assignedCount > 0:
+		res.Message = fmt.Sprintf("Assigned %d. %d couldn't fit", assignedCount, res.UnassignedCount)
+		res.Success = false
+	default:
+		res.Message = "No capacity available"
+		res.Success = false
+	}
+
+	if len(pairs) > 0 && cfg.CompanionPlacement != models.CompanionNone {
+		placedPairs := 0
+		for _, pair := range pairs {
+			if pair[0].AssignedTableID != nil && pair[1].AssignedTableID != nil {
+				placedPairs++
+			}
+		}
+		how := "together"
+		if cfg.CompanionPlacement == models.CompanionAcross {
+			how = "across"
+		}
+		res.Message += fmt.Sprintf(" (%d/%d pairs seated %s)", placedPairs, len(pairs), how)
+	}
+	if len(displaced) > 0 {
+		res.Message += fmt.Sprintf(". Pinned guests moved: %d", len(displaced))
+	}
+
+	return res
+}
+
+// placement is a table and seat index, or -1 for a guest at a table without a seat.
+type placement struct {
+	table int
+	seat  int
+}
+
+// keptPlacements returns, by guest index, the placements reassignment must
+// leave alone: guests in pinned seats, pinned guests and, when incremental,
+// every assigned guest.
+func keptPlacements(guests []models.Guest, tables []models.Table, incremental bool) map[int]placement {
+	guestByID := make(map[string]int, len(guests))
+	for i, g := range guests {
+		guestByID[normalizeID(g.ID)] = i
+	}
+	tableByID := make(map[string]int, len(tables))
+	for i, t := range tables {
+		tableByID[normalizeID(t.ID)] = i
+	}
+
+	kept := map[int]placement{}
+	for ti, t := range tables {
+		for si, seat := range t.Seats {
+			if !seat.Pinned || seat.GuestID == nil {
+				continue
+			}
+			if g, ok := guestByID[normalizeID(*seat.GuestID)]; ok {
+				kept[g] = placement{ti, si}
+			}
+		}
+	}
+
+	for gi, g := range guests {
+		if _, done := kept[gi]; done || !(g.Pinned || incremental) || g.AssignedTableID == nil {
+			continue
+		}
+		ti, ok := tableByID[normalizeID(*g.AssignedTableID)]
+		if !ok {
+			continue
+		}
+		p := placement{ti, -1}
+		if g.SeatPosition != nil {
+			for si, seat := range tables[ti].Seats {
+				if seat.Position == *g.SeatPosition {
+					p.seat = si
+				}
+			}
+		}
+		kept[gi] = p
+	}
+	return kept
+}
+
+// placeKept calls place for each kept guest, in guest order, those in a seat
+// before those only at a table, and returns the guests it couldn't place.
+func placeKept(n int, kept map[int]placement, place func(g int, p placement) bool) []int {
+	var displaced []int
+	for _, seated := range []bool{true, false} {
+		for g := 0; g < n; g++ {
+			p, ok := kept[g]
+			if !ok || (p.seat >= 0) != seated {
+				continue
+			}
+			if !place(g, p) {
+				displaced = append(displaced, g)
+			}
+		}
+	}
+	return displaced
+}
+
+// findPairSeats finds two empty seats for a host and companion: opposite each
+// other for "across", adjacent otherwise, falling back to any two empty seats.
+func findPairSeats(table *models.Table, placement models.CompanionPlacement) ([2]int, bool) {
+	var emptyOrder []int
+	empty := map[int]bool{}
+	for _, s := range table.Seats {
+		if s.GuestID == nil && !empty[s.Position] {
+			empty[s.Position] = true
+			emptyOrder = append(emptyOrder, s.Position)
+		}
+	}
+	if len(emptyOrder) < 2 {
+		return [2]int{}, false
+	}
+
+	switch {
+	case table.TableType == models.TableTypeLine && !table.SingleSided:
+		ends := 0
+		if table.EndSeatLeft {
+			ends++
+		}
+		if table.EndSeatRight {
+			ends++
+		}
+		sideSeats := len(table.Seats) - ends
+		perSide := (sideSeats + 1) / 2
+
+		if placement == models.CompanionAcross {
+			for i := 0; i < perSide; i++ {
+				across := perSide + i
+				if across < sideSeats && empty[i] && empty[across] {
+					return [2]int{i, across}, true
+				}
+			}
+		}
+		// next-to: find two adjacent seats on the same side
+		for i := 0; i < perSide-1; i++ {
+			if empty[i] && empty[i+1] {
+				return [2]int{i, i + 1}, true
+			}
+		}
+		for i := perSide; i < sideSeats-1; i++ {
+			if empty[i] && empty[i+1] {
+				return [2]int{i, i + 1}, true
+			}
+		}
+
+	case table.TableType == models.TableTypeRound:
+		n := len(table.Seats)
+		if placement == models.CompanionAcross {
+			half := n / 2
+			for i := 0; i < n; i++ {
+				opp := (i + half) % n
+				if empty[i] && empty[opp] {
+					return [2]int{i, opp}, true
+				}
+			}
+		}
+		for i := 0; i < n; i++ {
+			next := (i + 1) % n
+			if empty[i] && empty[next] {
+				return [2]int{i, next}, true
+			}
+		}
+
+	default:
+		// Single-sided LINE, U_SHAPE, or any other: just find adjacent
+		positions := append([]int(nil), emptyOrder...)
+		sort.Ints(positions)
+		for i := 0; i < len(positions)-1; i++ {
+			if positions[i+1]-positions[i] == 1 {
+				return [2]int{positions[i], positions[i+1]}, true
+			}
+		}
+	}
+
+	// Fallback: any two empty seats
+	return [2]int{emptyOrder[0], emptyOrder[1]}, true
+}
+
+// WriteAssignments copies the seating of a result into the stored entity
+// documents, keeping every other field as it was. Entities are matched by
+// position, so the items must be those the result was computed from.
+func WriteAssignments(tableItems, guestItems []json.RawMessage, res *Result) ([]json.RawMessage, []json.RawMessage, error) {
+	if len(tableItems) != len(res.Tables) || len(guestItems) != len(res.Guests) {
+		return nil, nil, fmt.Errorf("result does not match entities")
+	}
+
+	tables := make([]json.RawMessage, len(tableItems))
+	for i, item := range tableItems {
+		t := res.Tables[i]
+		raw, err := setFields(item, func(doc map[string]any) {
+			docSeats, _ := doc["seats"].([]any)
+			for j, seat := range docSeats {
+				if s, ok := seat.(map[string]any); ok && j < len(t.Seats) {
+					if t.Seats[j].GuestID != nil {
+						s["guestId"] = *t.Seats[j].GuestID
+					} else {
+						s["guestId"] = nil
+					}
+				}
+			}
+			doc["assignedGuests"] = t.AssignedGuests
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("tables[%d]: %w", i, err)
+		}
+		tables[i] = raw
+	}
+
+	guests := make([]json.RawMessage, len(guestItems))
+	for i, item := range guestItems {
+		g := res.Guests[i]
+		raw, err := setFields(item, func(doc map[string]any) {
+			doc["assignedTableId"] = g.AssignedTableID
+			doc["seatPosition"] = g.SeatPosition
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("guests[%d]: %w", i, err)
+		}
+		guests[i] = raw
+	}
+
+	return tables, guests, nil
+}
+
+// ClearAssignments empties every seat and unassigns every guest, keeping all
+// other fields. Pins go with the placements they held.
+func ClearAssignments(tableItems, guestItems []json.RawMessage) ([]json.RawMessage, []json.RawMessage, error) {
+	tables := make([]json.RawMessage, len(tableItems))
+	for i, item := range tableItems {
+		raw, err := setFields(item, func(doc map[string]any) {
+			docSeats, _ := doc["seats"].([]any)
+			for _, seat := range docSeats {
+				if s, ok := seat.(map[string]any); ok {
+					s["guestId"] = nil
+					delete(s, "pinned")
+				}
+			}
+			doc["assignedGuests"] = []string{}
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("tables[%d]: %w", i, err)
+		}
+		tables[i] = raw
+	}
+
+	guests := make([]json.RawMessage, len(guestItems))
+	for i, item := range guestItems {
+		raw, err := setFields(item, func(doc map[string]any) {
+			doc["assignedTableId"] = nil
+			doc["seatPosition"] = nil
+			delete(doc, "pinned")
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("guests[%d]: %w", i, err)
+		}
+		guests[i] = raw
+	}
+
+	return tables, guests, nil
+}
@@ -0,0 +1,265 @@
+package seating
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+var defaultConfig = models.AutoAssignConfig{
+	BalanceGuests:      true,
+	CompanionPlacement: models.CompanionNextTo,
+}
+
+func makeGuest(name string) models.Guest {
+	return models.Guest{ID: uuid.New().String(), Name: name}
+}
+
+func makeTable(tableType models.TableType, seats int) models.Table {
+	t := models.Table{
+		ID:             uuid.New().String(),
+		Name:           "T",
+		TableType:      tableType,
+		Capacity:       seats,
+		Seats:          make([]models.Seat, seats),
+		AssignedGuests: []string{},
+	}
+	for i := range t.Seats {
+		t.Seats[i].Position = i
+	}
+	return t
+}
+
+func TestAutoAssign_Empty(t *testing.T) {
+	res := AutoAssign(nil, nil, defaultConfig, nil)
+	if res.Success || !strings.Contains(res.Message, "No guests") || res.UnassignedCount != 0 {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	res = AutoAssign([]models.Guest{makeGuest("Alice"), makeGuest("Bob")}, nil, defaultConfig, nil)
+	if res.Success || !strings.Contains(res.Message, "No tables") || res.UnassignedCount != 2 {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestAutoAssign_InsufficientCapacity(t *testing.T) {
+	guests := []models.Guest{makeGuest("Alice"), makeGuest("Bob"), makeGuest("Charlie")}
+	res := AutoAssign(guests, []models.Table{makeTable(models.TableTypeRound, 2)}, defaultConfig, nil)
+	if res.Success || res.UnassignedCount != 1 {
+		t.Errorf("expected 1 unassigned, got %+v", res)
+	}
+	// Sorted by name, so Charlie is the one left over
+	if res.Guests[2].AssignedTableID != nil {
+		t.Errorf("expected Charlie unassigned, got %+v", res.Guests[2])
+	}
+	if guests[0].AssignedTableID != nil {
+		t.Error("expected input guests to be left unchanged")
+	}
+}
+
+func TestAutoAssign_Balanced(t *testing.T) {
+	var guests []models.Guest
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+		guests = append(guests, makeGuest(name))
+	}
+	tables := []models.Table{makeTable(models.TableTypeRound, 6), makeTable(models.TableTypeRound, 6)}
+
+	res := AutoAssign(guests, tables, defaultConfig, nil)
+	if !res.Success || res.Message != "Assigned all 8 guests" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(res.Tables[0].AssignedGuests) != 4 || len(res.Tables[1].AssignedGuests) != 4 {
+		t.Errorf("expected 4-4 split, got %d-%d", len(res.Tables[0].AssignedGuests), len(res.Tables[1].AssignedGuests))
+	}
+
+	cfg := defaultConfig
+	cfg.BalanceGuests = false
+	res = AutoAssign(guests, tables, cfg, nil)
+	if len(res.Tables[0].AssignedGuests) != 6 || len(res.Tables[1].AssignedGuests) != 2 {
+		t.Errorf("expected 6-2 fill, got %d-%d", len(res.Tables[0].AssignedGuests), len(res.Tables[1].AssignedGuests))
+	}
+}
+
+func TestAutoAssign_Companions(t *testing.T) {
+	host := makeGuest("Alice")
+	companion := makeGuest("Bob")
+	companion.GuestOf = &host.ID
+
+	tests := []struct {
+		name      string
+		table     models.Table
+		placement models.CompanionPlacement
+		wantSeats [2]int
+	}{
+		{"round next-to", makeTable(models.TableTypeRound, 6), models.CompanionNextTo, [2]int{0, 1}},
+		{"round across", makeTable(models.TableTypeRound, 6), models.CompanionAcross, [2]int{0, 3}},
+		{"double-sided line across", makeTable(models.TableTypeLine, 6), models.CompanionAcross, [2]int{0, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig
+			cfg.CompanionPlacement = tt.placement
+			res := AutoAssign([]models.Guest{companion, host}, []models.Table{tt.table}, cfg, nil)
+			if !res.Success || !strings.Contains(res.Message, "1/1 pairs") {
+				t.Fatalf("unexpected result %+v", res)
+			}
+			// Host is guests[1]: order of the input is kept
+			if *res.Guests[1].SeatPosition != tt.wantSeats[0] || *res.Guests[0].SeatPosition != tt.wantSeats[1] {
+				t.Errorf("seats = %d, %d, want %v", *res.Guests[1].SeatPosition, *res.Guests[0].SeatPosition, tt.wantSeats)
+			}
+		})
+	}
+}
+
+func TestAutoAssign_PinnedAndIncremental(t *testing.T) {
+	a, b, c, d := makeGuest("A"), makeGuest("B"), makeGuest("C"), makeGuest("D")
+	t1, t2 := makeTable(models.TableTypeRound, 2), makeTable(models.TableTypeRound, 2)
+
+	// D is pinned at T2 seat 1, C sits in a pinned seat at T2 seat 0, B sits
+	// unpinned at T1 seat 1
+	seat0, seat1 := 0, 1
+	d.AssignedTableID, d.SeatPosition, d.Pinned = &t2.ID, &seat1, true
+	t2.Seats[1].GuestID = &d.ID
+	c.AssignedTableID, c.SeatPosition = &t2.ID, &seat0
+	t2.Seats[0].GuestID, t2.Seats[0].Pinned = &c.ID, true
+	b.AssignedTableID, b.SeatPosition = &t1.ID, &seat1
+	t1.Seats[1].GuestID = &b.ID
+	guests := []models.Guest{a, b, c, d}
+	tables := []models.Table{t1, t2}
+
+	res := AutoAssign(guests, tables, defaultConfig, nil)
+	if !res.Success {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if *res.Guests[3].AssignedTableID != t2.ID || *res.Guests[3].SeatPosition != 1 {
+		t.Errorf("expected pinned guest kept, got %+v", res.Guests[3])
+	}
+	if *res.Guests[2].AssignedTableID != t2.ID || *res.Guests[2].SeatPosition != 0 {
+		t.Errorf("expected guest in pinned seat kept, got %+v", res.Guests[2])
+	}
+	// Unpinned B is reassigned from scratch, sorted after A
+	if *res.Guests[1].SeatPosition != 1 || *res.Guests[0].SeatPosition != 0 {
+		t.Errorf("expected A, B at T1 seats 0, 1, got %+v, %+v", res.Guests[0], res.Guests[1])
+	}
+
+	// Incremental keeps B at seat 1 and seats only A
+	cfg := defaultConfig
+	cfg.Incremental = true
+	b.SeatPosition = &seat0
+	t1.Seats[0].GuestID, t1.Seats[1].GuestID = &b.ID, nil
+	res = AutoAssign([]models.Guest{a, b, c, d}, []models.Table{t1, t2}, cfg, nil)
+	if !res.Success || res.Message != "Assigned all 4 guests" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if *res.Guests[1].SeatPosition != 0 || *res.Guests[0].SeatPosition != 1 {
+		t.Errorf("expected B kept at seat 0 and A at seat 1, got %+v, %+v", res.Guests[1], res.Guests[0])
+	}
+}
+
+func TestAutoAssign_KeptWithoutSeat(t *testing.T) {
+	a, b, c := makeGuest("A"), makeGuest("B"), makeGuest("C")
+	t1, t2 := makeTable(models.TableTypeRound, 1), makeTable(models.TableTypeRound, 2)
+
+	// A is pinned in T1's only seat and B is pinned to the same seat; C is
+	// pinned to T2 without a seat
+	seat0 := 0
+	a.AssignedTableID, a.SeatPosition, a.Pinned = &t1.ID, &seat0, true
+	t1.Seats[0].GuestID = &a.ID
+	b.AssignedTableID, b.SeatPosition, b.Pinned = &t1.ID, &seat0, true
+	c.AssignedTableID, c.Pinned = &t2.ID, true
+
+	res := AutoAssign([]models.Guest{a, b, c}, []models.Table{t1, t2}, defaultConfig, nil)
+	if !res.Success {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if *res.Guests[0].AssignedTableID != t1.ID || *res.Guests[0].SeatPosition != 0 {
+		t.Errorf("expected A kept, got %+v", res.Guests[0])
+	}
+	if len(res.Tables[0].AssignedGuests) != 1 {
+		t.Errorf("expected T1 within capacity, got %v", res.Tables[0].AssignedGuests)
+	}
+	if g := res.Guests[2]; *g.AssignedTableID != t2.ID || g.SeatPosition == nil || *g.SeatPosition != 0 {
+		t.Errorf("expected C given T2's first free seat, got %+v", g)
+	}
+	if g := res.Guests[1]; *g.AssignedTableID != t2.ID || g.SeatPosition == nil || *g.SeatPosition != 1 {
+		t.Errorf("expected B moved to T2, got %+v", g)
+	}
+	if len(res.Displaced) != 1 || res.Displaced[0] != b.ID || !strings.Contains(res.Message, "Pinned guests moved: 1") {
+		t.Errorf("expected B reported as moved, got %v: %q", res.Displaced, res.Message)
+	}
+}
+
+func TestAutoAssign_RandomizeIsSeeded(t *testing.T) {
+	var guests []models.Guest
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		guests = append(guests, makeGuest(name))
+	}
+	tables := []models.Table{makeTable(models.TableTypeRound, 5)}
+	cfg := defaultConfig
+	cfg.Randomize = true
+
+	first := AutoAssign(guests, tables, cfg, rand.New(rand.NewSource(7)))
+	second := AutoAssign(guests, tables, cfg, rand.New(rand.NewSource(7)))
+	for i := range first.Guests {
+		if *first.Guests[i].SeatPosition != *second.Guests[i].SeatPosition {
+			t.Fatal("expected the same seed to give the same seating")
+		}
+	}
+}
+
+func TestWriteAssignments(t *testing.T) {
+	tableID, guestID := uuid.New(), uuid.New()
+	tableItems := []json.RawMessage{json.RawMessage(`{"id":"` + tableID.String() +
+		`","name":"T","tableType":"ROUND","capacity":1,"seats":[{"position":0,"guestId":null,"label":"Seat 1"}],"assignedGuests":[],"color":"red"}`)}
+	guestItems := []json.RawMessage{json.RawMessage(`{"id":"` + guestID.String() + `","name":"Ann","note":"vip"}`)}
+
+	tables := []models.Table{}
+	guests := []models.Guest{}
+	for _, item := range tableItems {
+		var tbl models.Table
+		json.Unmarshal(item, &tbl)
+		tables = append(tables, tbl)
+	}
+	for _, item := range guestItems {
+		var g models.Guest
+		json.Unmarshal(item, &g)
+		guests = append(guests, g)
+	}
+
+	res := AutoAssign(guests, tables, defaultConfig, nil)
+	outTables, outGuests, err := WriteAssignments(tableItems, guestItems, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(outTables[0]), `"guestId":"`+guestID.String()+`"`) || !strings.Contains(string(outTables[0]), `"color":"red"`) {
+		t.Errorf("unexpected table %s", outTables[0])
+	}
+	if !strings.Contains(string(outGuests[0]), `"assignedTableId":"`+tableID.String()+`"`) || !strings.Contains(string(outGuests[0]), `"note":"vip"`) {
+		t.Errorf("unexpected guest %s", outGuests[0])
+	}
+}
+
+func TestClearAssignments(t *testing.T) {
+	tableID, guestID := uuid.New(), uuid.New()
+	tableItems := []json.RawMessage{json.RawMessage(`{"id":"` + tableID.String() +
+		`","capacity":1,"seats":[{"position":0,"guestId":"` + guestID.String() + `","pinned":true}],"assignedGuests":["` + guestID.String() + `"],"color":"red"}`)}
+	guestItems := []json.RawMessage{json.RawMessage(`{"id":"` + guestID.String() +
+		`","name":"Ann","assignedTableId":"` + tableID.String() + `","seatPosition":0,"pinned":true,"note":"vip"}`)}
+
+	outTables, outGuests, err := ClearAssignments(tableItems, guestItems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(outTables[0]), guestID.String()) || strings.Contains(string(outTables[0]), "pinned") ||
+		!strings.Contains(string(outTables[0]), `"color":"red"`) {
+		t.Errorf("unexpected table %s", outTables[0])
+	}
+	if strings.Contains(string(outGuests[0]), tableID.String()) || strings.Contains(string(outGuests[0]), "pinned") ||
+		!strings.Contains(string(outGuests[0]), `"note":"vip"`) {
+		t.Errorf("unexpected guest %s", outGuests[0])
+	}
+}
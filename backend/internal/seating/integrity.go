@@ -0,0 +1,290 @@
+// Package seating implements seat assignment logic shared by the save,
+// auto-assign and import endpoints.
+package seating
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// ReconcileResult is the consistent form of a set of tables and guests along
+// with every change that was needed to get there. Entities that needed no
+// change keep their original encoding.
+type ReconcileResult struct {
+	Tables []json.RawMessage
+	Guests []json.RawMessage
+	Issues []models.FieldError
+}
+
+type seatKey struct {
+	table int
+	pos   int
+}
+
+// Reconcile makes table seats and guest assignments agree with each other.
+//
+// A guest's assignedTableId and seatPosition are authoritative: references to
+// deleted tables or missing seats are cleared, a seat claimed by two guests
+// goes to the guest the seat already lists (else the first claimant), and
+// unseated guests beyond a table's capacity are unassigned. Seat guestIds and
+// assignedGuests are then rebuilt from the guests, which also removes dangling
+// references and guests seated more than once.
+//
+// Entities must already have passed validation.
+func Reconcile(tableItems, guestItems []json.RawMessage) (*ReconcileResult, error) {
+	tables := make([]models.Table, len(tableItems))
+	for i, item := range tableItems {
+		if err := json.Unmarshal(item, &tables[i]); err != nil {
+			return nil, fmt.Errorf("tables[%d]: %w", i, err)
+		}
+	}
+	guests := make([]models.Guest, len(guestItems))
+	for i, item := range guestItems {
+		if err := json.Unmarshal(item, &guests[i]); err != nil {
+			return nil, fmt.Errorf("guests[%d]: %w", i, err)
+		}
+	}
+
+	result := &ReconcileResult{Issues: []models.FieldError{}}
+	issue := func(path, format string, args ...any) {
+		result.Issues = append(result.Issues, models.FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	tableByID := make(map[string]int, len(tables))
+	for i, t := range tables {
+		tableByID[normalizeID(t.ID)] = i
+	}
+	guestIDs := make([]string, len(guests))
+	guestByID := make(map[string]int, len(guests))
+	for i, g := range guests {
+		guestIDs[i] = normalizeID(g.ID)
+		guestByID[guestIDs[i]] = i
+	}
+
+	// Guest side: drop references to tables and seats that don't exist
+	tableOf := make([]int, len(guests))
+	seatOf := make([]int, len(guests))
+	guestChanged := make([]bool, len(guests))
+	for i, g := range guests {
+		tableOf[i], seatOf[i] = -1, -1
+		if g.AssignedTableID == nil {
+			continue
+		}
+		ti, ok := tableByID[normalizeID(*g.AssignedTableID)]
+		if !ok {
+			issue(fmt.Sprintf("guests[%d].assignedTableId", i), "table %s does not exist; guest unassigned", *g.AssignedTableID)
+			guestChanged[i] = true
+			continue
+		}
+		tableOf[i] = ti
+		if g.SeatPosition == nil {
+			continue
+		}
+		if !hasSeat(tables[ti], *g.SeatPosition) {
+			issue(fmt.Sprintf("guests[%d].seatPosition", i), "table %q has no seat %d; seat cleared", tables[ti].Name, *g.SeatPosition)
+			guestChanged[i] = true
+			continue
+		}
+		seatOf[i] = *g.SeatPosition
+	}
+
+	// Resolve seats claimed by more than one guest
+	owner := map[seatKey]int{}
+	for i := range guests {
+		if seatOf[i] < 0 {
+			continue
+		}
+		key := seatKey{tableOf[i], seatOf[i]}
+		prev, taken := owner[key]
+		if !taken {
+			owner[key] = i
+			continue
+		}
+		loser := i
+		if listed := seatGuest(tables[key.table], key.pos); listed == guestIDs[i] && listed != guestIDs[prev] {
+			owner[key], loser = i, prev
+		}
+		issue(fmt.Sprintf("guests[%d].seatPosition", loser), "seat %d at table %q is taken by guest %s; seat cleared",
+			key.pos, tables[key.table].Name, guests[owner[key]].ID)
+		seatOf[loser] = -1
+		guestChanged[loser] = true
+	}
+
+	// Unassign unseated guests that don't fit
+	assigned := make([]int, len(tables))
+	for i := range guests {
+		if tableOf[i] >= 0 && seatOf[i] >= 0 {
+			assigned[tableOf[i]]++
+		}
+	}
+	for i := range guests {
+		ti := tableOf[i]
+		if ti < 0 || seatOf[i] >= 0 {
+			continue
+		}
+		if assigned[ti] >= tables[ti].Capacity {
+			issue(fmt.Sprintf("guests[%d].assignedTableId", i), "table %q is full; guest unassigned", tables[ti].Name)
+			tableOf[i] = -1
+			guestChanged[i] = true
+			continue
+		}
+		assigned[ti]++
+	}
+
+	// Table side: rebuild seats and assignedGuests from the guests
+	result.Tables = make([]json.RawMessage, len(tables))
+	for ti, t := range tables {
+		changed := false
+		seats := make([]any, len(t.Seats))
+		for si, seat := range t.Seats {
+			want := -1
+			if gi, ok := owner[seatKey{ti, seat.Position}]; ok {
+				want = gi
+			}
+			current := ""
+			if seat.GuestID != nil {
+				current = normalizeID(*seat.GuestID)
+			}
+			_, known := guestByID[current]
+			path := fmt.Sprintf("tables[%d].seats[%d].guestId", ti, si)
+			switch {
+			case want < 0 && current == "":
+				seats[si] = nil
+				continue
+			case want >= 0 && current == guestIDs[want]:
+				seats[si] = guests[want].ID
+				continue
+			case want >= 0:
+				issue(path, "set to guest %s assigned to this seat", guests[want].ID)
+			case !known:
+				issue(path, "guest %s does not exist; seat cleared", *seat.GuestID)
+			default:
+				issue(path, "guest %s is not assigned to this seat; seat cleared", *seat.GuestID)
+			}
+			changed = true
+			if want >= 0 {
+				seats[si] = guests[want].ID
+			} else {
+				seats[si] = nil
+			}
+		}
+
+		members := []string{}
+		seen := map[string]bool{}
+		for _, id := range t.AssignedGuests {
+			gi, ok := guestByID[normalizeID(id)]
+			if ok && tableOf[gi] == ti && !seen[guestIDs[gi]] {
+				members = append(members, guests[gi].ID)
+				seen[guestIDs[gi]] = true
+			}
+		}
+		for gi := range guests {
+			if tableOf[gi] == ti && !seen[guestIDs[gi]] {
+				members = append(members, guests[gi].ID)
+				seen[guestIDs[gi]] = true
+			}
+		}
+		if !sameIDs(t.AssignedGuests, members) {
+			issue(fmt.Sprintf("tables[%d].assignedGuests", ti), "rebuilt from guest assignments (%d guests)", len(members))
+			changed = true
+		}
+
+		if !changed {
+			result.Tables[ti] = tableItems[ti]
+			continue
+		}
+		raw, err := setFields(tableItems[ti], func(doc map[string]any) {
+			docSeats, _ := doc["seats"].([]any)
+			for si, seat := range docSeats {
+				if s, ok := seat.(map[string]any); ok && si < len(seats) {
+					s["guestId"] = seats[si]
+				}
+			}
+			doc["assignedGuests"] = members
+		})
+		if err != nil {
+			return nil, fmt.Errorf("tables[%d]: %w", ti, err)
+		}
+		result.Tables[ti] = raw
+	}
+
+	result.Guests = make([]json.RawMessage, len(guests))
+	for gi := range guests {
+		if !guestChanged[gi] {
+			result.Guests[gi] = guestItems[gi]
+			continue
+		}
+		raw, err := setFields(guestItems[gi], func(doc map[string]any) {
+			doc["assignedTableId"] = nil
+			doc["seatPosition"] = nil
+			if tableOf[gi] >= 0 {
+				doc["assignedTableId"] = tables[tableOf[gi]].ID
+			}
+			if seatOf[gi] >= 0 && tableOf[gi] >= 0 {
+				doc["seatPosition"] = seatOf[gi]
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("guests[%d]: %w", gi, err)
+		}
+		result.Guests[gi] = raw
+	}
+
+	return result, nil
+}
+
+// normalizeID makes UUIDs comparable regardless of case.
+func normalizeID(id string) string {
+	if parsed, err := uuid.Parse(id); err == nil {
+		return parsed.String()
+	}
+	return strings.ToLower(id)
+}
+
+func hasSeat(t models.Table, pos int) bool {
+	for _, s := range t.Seats {
+		if s.Position == pos {
+			return true
+		}
+	}
+	return false
+}
+
+func seatGuest(t models.Table, pos int) string {
+	for _, s := range t.Seats {
+		if s.Position == pos && s.GuestID != nil {
+			return normalizeID(*s.GuestID)
+		}
+	}
+	return ""
+}
+
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if normalizeID(a[i]) != normalizeID(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// setFields edits an entity document in place, keeping fields the backend
+// doesn't model and numbers exactly as they were.
+func setFields(data json.RawMessage, edit func(doc map[string]any)) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	edit(doc)
+	return json.Marshal(doc)
+}
@@ -0,0 +1,158 @@
+package seating
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// roundTable builds a round table with the given seat occupants ("" for empty).
+func roundTable(id uuid.UUID, occupants ...string) json.RawMessage {
+	seats := make([]string, len(occupants))
+	assigned := []string{}
+	for i, g := range occupants {
+		guestID := "null"
+		if g != "" {
+			guestID = `"` + g + `"`
+			assigned = append(assigned, `"`+g+`"`)
+		}
+		seats[i] = fmt.Sprintf(`{"position":%d,"guestId":%s,"label":"Seat %d"}`, i, guestID, i+1)
+	}
+	return json.RawMessage(fmt.Sprintf(`{"id":"%s","name":"T","tableType":"ROUND","capacity":%d,"seats":[%s],"assignedGuests":[%s],"color":"red"}`,
+		id, len(occupants), strings.Join(seats, ","), strings.Join(assigned, ",")))
+}
+
+func guest(id uuid.UUID, tableID *uuid.UUID, seat *int) json.RawMessage {
+	table, pos := "null", "null"
+	if tableID != nil {
+		table = `"` + tableID.String() + `"`
+	}
+	if seat != nil {
+		pos = fmt.Sprint(*seat)
+	}
+	return json.RawMessage(fmt.Sprintf(`{"id":"%s","name":"G","assignedTableId":%s,"seatPosition":%s}`, id, table, pos))
+}
+
+func seatIDs(t *testing.T, raw json.RawMessage) []string {
+	t.Helper()
+	var table models.Table
+	if err := json.Unmarshal(raw, &table); err != nil {
+		t.Fatal(err)
+	}
+	out := make([]string, len(table.Seats))
+	for i, s := range table.Seats {
+		if s.GuestID != nil {
+			out[i] = *s.GuestID
+		}
+	}
+	return out
+}
+
+func decodeGuest(t *testing.T, raw json.RawMessage) models.Guest {
+	t.Helper()
+	var g models.Guest
+	if err := json.Unmarshal(raw, &g); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestReconcile_Consistent(t *testing.T) {
+	tableID, a := uuid.New(), uuid.New()
+	zero := 0
+	tables := []json.RawMessage{roundTable(tableID, a.String(), "")}
+	guests := []json.RawMessage{guest(a, &tableID, &zero)}
+
+	res, err := Reconcile(tables, guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Issues) != 0 {
+		t.Errorf("expected no issues, got %v", res.Issues)
+	}
+	if string(res.Tables[0]) != string(tables[0]) {
+		t.Errorf("expected table to be left untouched")
+	}
+}
+
+func TestReconcile_Repairs(t *testing.T) {
+	tableID, deletedTable := uuid.New(), uuid.New()
+	a, b, c, ghost := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	zero, one := 0, 1
+
+	tables := []json.RawMessage{
+		// Seat 0 lists b, seat 1 lists an unknown guest and seat 2 double-books b
+		roundTable(tableID, b.String(), ghost.String(), b.String()),
+	}
+	guests := []json.RawMessage{
+		guest(a, &tableID, &zero),     // claims seat 0 first
+		guest(b, &tableID, &zero),     // seat 0 lists b, so b wins it
+		guest(c, &deletedTable, &one), // table is gone
+	}
+
+	res, err := Reconcile(tables, guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seats := seatIDs(t, res.Tables[0])
+	if seats[0] != b.String() || seats[1] != "" || seats[2] != "" {
+		t.Errorf("seats = %v, want [%s, empty, empty]", seats, b)
+	}
+
+	ga := decodeGuest(t, res.Guests[0])
+	if ga.AssignedTableID == nil || *ga.AssignedTableID != tableID.String() || ga.SeatPosition != nil {
+		t.Errorf("expected guest a to stay at the table without a seat, got %+v", ga)
+	}
+	gc := decodeGuest(t, res.Guests[2])
+	if gc.AssignedTableID != nil || gc.SeatPosition != nil {
+		t.Errorf("expected guest c to be unassigned, got %+v", gc)
+	}
+
+	var table models.Table
+	json.Unmarshal(res.Tables[0], &table)
+	if len(table.AssignedGuests) != 2 || table.AssignedGuests[0] != b.String() || table.AssignedGuests[1] != a.String() {
+		t.Errorf("assignedGuests = %v, want [%s %s]", table.AssignedGuests, b, a)
+	}
+	if !strings.Contains(string(res.Tables[0]), `"color":"red"`) {
+		t.Errorf("expected unknown fields to be kept, got %s", res.Tables[0])
+	}
+
+	paths := map[string]bool{}
+	for _, issue := range res.Issues {
+		paths[issue.Path] = true
+	}
+	for _, want := range []string{
+		"guests[0].seatPosition",
+		"guests[2].assignedTableId",
+		"tables[0].seats[1].guestId",
+		"tables[0].seats[2].guestId",
+		"tables[0].assignedGuests",
+	} {
+		if !paths[want] {
+			t.Errorf("missing issue for %s in %v", want, res.Issues)
+		}
+	}
+}
+
+func TestReconcile_TableFull(t *testing.T) {
+	tableID := uuid.New()
+	a, b := uuid.New(), uuid.New()
+	tables := []json.RawMessage{roundTable(tableID, "")}
+	guests := []json.RawMessage{guest(a, &tableID, nil), guest(b, &tableID, nil)}
+
+	res, err := Reconcile(tables, guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := decodeGuest(t, res.Guests[1]); g.AssignedTableID != nil {
+		t.Errorf("expected second guest to be unassigned, got %+v", g)
+	}
+	if g := decodeGuest(t, res.Guests[0]); g.AssignedTableID == nil {
+		t.Errorf("expected first guest to keep the table")
+	}
+}
@@ -0,0 +1,575 @@
+package seating
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Penalties that rank seatings: any hard violation costs more than the
+// heaviest soft one or leaving a guest unseated, which costs more than a soft
+// violation of default weight.
+const (
+	hardPenalty     = 10 * models.MaxConstraintWeight
+	unseatedPenalty = 10
+)
+
+// Annealing temperature range, in units of penalty
+const (
+	startTemperature = 5.0
+	endTemperature   = 0.01
+)
+
+// Options configures Optimize.
+type Options struct {
+	Constraints []models.SeatingConstraint
+	// Locked guests keep the seat they are in, as do pinned guests and guests in pinned seats
+	Locked     []uuid.UUID
+	Iterations int
+}
+
+// OptimizeResult is the best seating found along with how it meets each constraint.
+type OptimizeResult struct {
+	Result
+	Penalty       float64
+	HardSatisfied bool
+	Report        []models.ConstraintReport
+}
+
+// slot is a seat, or a place on the bench for unseated guests (table -1).
+type slot struct {
+	table int
+	seat  int // index into Table.Seats
+}
+
+// optimizer holds the search state. Every guest always occupies a slot; the
+// first seats slots are real seats and the rest form the bench.
+type optimizer struct {
+	guests  []models.Guest
+	tables  []models.Table
+	cons    []models.SeatingConstraint
+	members [][]int
+	side    []int // -1 left, 1 right, 0 both
+	slots   []slot
+	seats   int
+	locked  []bool
+	// displaced are the IDs of kept guests that couldn't keep their placement
+	displaced []string
+
+	guestSlot []int // slot index per guest
+	occupant  []int // guest index per slot, or -1
+
+	// Kept up to date as guests move, so scoring a move only touches the
+	// constraints of the guests it moves
+	guestCons [][]int // constraint indexes per guest
+	count     [][]int // members of each constraint per table
+	used      []int   // tables with members, per constraint
+	viol      []int   // violations per constraint
+	unseated  int
+	total     float64 // current cost, up to rounding
+}
+
+// checkEvery is how many steps Optimize takes between checks for cancellation.
+const checkEvery = 1024
+
+// Optimize searches for the seating that best satisfies the constraints using
+// simulated annealing, starting from the current seating. Guests and tables
+// are copied, not modified. It stops with ctx's error when ctx is done.
+func Optimize(ctx context.Context, guests []models.Guest, tables []models.Table, opts Options, rng *rand.Rand) (*OptimizeResult, error) {
+	o := newOptimizer(guests, tables, opts)
+
+	cost := o.cost()
+	best, bestCost := append([]int(nil), o.guestSlot...), cost
+
+	var movable []int
+	for g := range o.guests {
+		if !o.locked[g] {
+			movable = append(movable, g)
+		}
+	}
+
+	if len(movable) > 0 && o.seats > 0 && cost > 0 {
+		for i := 0; i < opts.Iterations; i++ {
+			if i%checkEvery == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			temp := startTemperature * math.Pow(endTemperature/startTemperature, float64(i)/float64(opts.Iterations))
+
+			var move func()
+			switch n := rng.Intn(20); {
+			case n == 0 && len(o.tables) > 1:
+				// Swap whole tables so groups can change sides in one step
+				t1, t2 := rng.Intn(len(o.tables)), rng.Intn(len(o.tables))
+				if t1 == t2 {
+					continue
+				}
+				move = func() { o.swapTables(t1, t2) }
+			default:
+				// Move a guest to a seat, or to the bench now and then
+				g := movable[rng.Intn(len(movable))]
+				target := rng.Intn(o.seats)
+				if n == 1 {
+					target = o.seats + rng.Intn(len(o.slots)-o.seats)
+				}
+				from := o.guestSlot[g]
+				if h := o.occupant[target]; target == from || (h >= 0 && o.locked[h]) {
+					continue
+				}
+				move = func() { o.swapSlots(from, target) }
+			}
+
+			before := o.total
+			move()
+			delta := o.total - before
+			if delta <= 0 || rng.Float64() < math.Exp(-delta/temp) {
+				cost += delta
+				if cost < bestCost {
+					// Drop the rounding the running total picked up
+					cost = o.cost()
+					bestCost = cost
+					copy(best, o.guestSlot)
+					if bestCost == 0 {
+						break
+					}
+				}
+			} else {
+				// Both moves are their own inverse
+				move()
+			}
+		}
+	}
+
+	o.setState(best)
+	return o.result(bestCost), nil
+}
+
+func newOptimizer(guests []models.Guest, tables []models.Table, opts Options) *optimizer {
+	o := &optimizer{
+		guests: guests,
+		tables: tables,
+		cons:   opts.Constraints,
+	}
+
+	guestByID := make(map[string]int, len(guests))
+	for i, g := range guests {
+		guestByID[normalizeID(g.ID)] = i
+	}
+	tableByID := make(map[string]int, len(tables))
+	for i, t := range tables {
+		tableByID[normalizeID(t.ID)] = i
+	}
+
+	// Locked guests keep their current placement alongside the pinned ones
+	o.locked = make([]bool, len(guests))
+	kept := keptPlacements(guests, tables, false)
+	current := keptPlacements(guests, tables, true)
+	for _, id := range opts.Locked {
+		if g, ok := guestByID[id.String()]; ok {
+			o.locked[g] = true
+			if p, ok := current[g]; ok {
+				kept[g] = p
+			}
+		}
+	}
+	for g := range kept {
+		o.locked[g] = true
+	}
+
+	o.members = make([][]int, len(o.cons))
+	o.guestCons = make([][]int, len(guests))
+	for i, c := range o.cons {
+		o.members[i] = selectGuests(guests, guestByID, c.Guests)
+		for _, g := range o.members[i] {
+			o.guestCons[g] = append(o.guestCons[g], i)
+		}
+	}
+
+	// Split the plan into halves by table position
+	o.side = make([]int, len(tables))
+	if len(tables) > 0 {
+		minX, maxX := tables[0].Position.X, tables[0].Position.X
+		for _, t := range tables {
+			minX, maxX = math.Min(minX, t.Position.X), math.Max(maxX, t.Position.X)
+		}
+		mid := (minX + maxX) / 2
+		for i, t := range tables {
+			switch {
+			case t.Position.X < mid:
+				o.side[i] = -1
+			case t.Position.X > mid:
+				o.side[i] = 1
+			}
+		}
+	}
+
+	slotAt := map[slot]int{}
+	for ti, t := range tables {
+		for si := range t.Seats {
+			slotAt[slot{ti, si}] = len(o.slots)
+			o.slots = append(o.slots, slot{ti, si})
+		}
+	}
+	o.seats = len(o.slots)
+	for range guests {
+		o.slots = append(o.slots, slot{table: -1})
+	}
+	o.occupant = make([]int, len(o.slots))
+	for i := range o.occupant {
+		o.occupant[i] = -1
+	}
+	o.guestSlot = make([]int, len(guests))
+	for i := range o.guestSlot {
+		o.guestSlot[i] = -1
+	}
+
+	// Kept guests sit down first. Those whose seat is taken, or whose table is
+	// full, are no longer locked and get seated like everyone else.
+	for _, g := range placeKept(len(guests), kept, func(g int, p placement) bool {
+		for si := range tables[p.table].Seats {
+			if p.seat >= 0 && si != p.seat {
+				continue
+			}
+			if s := slotAt[slot{p.table, si}]; o.occupant[s] < 0 {
+				o.seat(g, s)
+				return true
+			}
+		}
+		return false
+	}) {
+		o.locked[g] = false
+		o.displaced = append(o.displaced, guests[g].ID)
+	}
+
+	// Start from the current seating; guests at a table without a seat take
+	// the table's first free seat
+	var tableOnly []int
+	for gi, g := range guests {
+		if g.AssignedTableID == nil || o.guestSlot[gi] >= 0 {
+			continue
+		}
+		ti, ok := tableByID[normalizeID(*g.AssignedTableID)]
+		if !ok {
+			continue
+		}
+		if g.SeatPosition == nil {
+			tableOnly = append(tableOnly, gi)
+			continue
+		}
+		for si, seat := range tables[ti].Seats {
+			if seat.Position == *g.SeatPosition {
+				if s := slotAt[slot{ti, si}]; o.occupant[s] < 0 {
+					o.seat(gi, s)
+				}
+				break
+			}
+		}
+	}
+	for _, gi := range tableOnly {
+		ti := tableByID[normalizeID(*guests[gi].AssignedTableID)]
+		for si := range tables[ti].Seats {
+			if s := slotAt[slot{ti, si}]; o.occupant[s] < 0 {
+				o.seat(gi, s)
+				break
+			}
+		}
+	}
+
+	// Seat everyone else wherever there is room, so the search starts full
+	next := 0
+	for gi := range guests {
+		if o.guestSlot[gi] >= 0 || o.locked[gi] {
+			continue
+		}
+		for next < o.seats && o.occupant[next] >= 0 {
+			next++
+		}
+		if next == o.seats {
+			break
+		}
+		o.seat(gi, next)
+	}
+
+	// Everyone left waits on the bench
+	bench := o.seats
+	for gi := range guests {
+		if o.guestSlot[gi] < 0 {
+			o.seat(gi, bench)
+			bench++
+		}
+	}
+
+	o.recount()
+	return o
+}
+
+// selectGuests returns the indexes of guests matched by a selector.
+func selectGuests(guests []models.Guest, guestByID map[string]int, sel models.GuestSelector) []int {
+	picked := make([]bool, len(guests))
+	for _, id := range sel.GuestIDs {
+		if g, ok := guestByID[id.String()]; ok {
+			picked[g] = true
+		}
+	}
+	for i, g := range guests {
+		if sel.Dietary != "" {
+			for _, d := range g.DietaryRestrictions {
+				if d == sel.Dietary {
+					picked[i] = true
+				}
+			}
+		}
+		if sel.GuestOf != nil {
+			host := sel.GuestOf.String()
+			if normalizeID(g.ID) == host || (g.GuestOf != nil && normalizeID(*g.GuestOf) == host) {
+				picked[i] = true
+			}
+		}
+	}
+
+	var out []int
+	for i, ok := range picked {
+		if ok {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// swapSlots exchanges the occupants of two slots, either of which may be empty.
+func (o *optimizer) swapSlots(a, b int) {
+	ga, gb := o.occupant[a], o.occupant[b]
+	o.occupant[a], o.occupant[b] = gb, ga
+	ta, tb := o.slots[a].table, o.slots[b].table
+	if ga >= 0 {
+		o.guestSlot[ga] = b
+		o.retable(ga, ta, tb)
+	}
+	if gb >= 0 {
+		o.guestSlot[gb] = a
+		o.retable(gb, tb, ta)
+	}
+}
+
+// retable updates the running cost for guest g moving from table from to
+// table to, where -1 is the bench.
+func (o *optimizer) retable(g, from, to int) {
+	if from == to {
+		return
+	}
+	switch {
+	case from < 0:
+		o.unseated--
+		o.total -= unseatedPenalty
+	case to < 0:
+		o.unseated++
+		o.total += unseatedPenalty
+	}
+	for _, i := range o.guestCons[g] {
+		before := o.viol[i]
+		if from >= 0 {
+			o.leave(i, from)
+		}
+		if to >= 0 {
+			o.join(i, to)
+		}
+		o.total += o.weight(i) * float64(o.viol[i]-before)
+	}
+}
+
+// join records a member of constraint i sitting down at table t.
+func (o *optimizer) join(i, t int) {
+	switch o.cons[i].Type {
+	case models.ConstraintTogether:
+		if o.count[i][t] == 0 {
+			o.used[i]++
+			o.viol[i] = max(o.used[i]-1, 0)
+		}
+	case models.ConstraintApart:
+		o.viol[i] += o.count[i][t]
+	case models.ConstraintSide:
+		if o.wrongSide(i, t) {
+			o.viol[i]++
+		}
+	}
+	o.count[i][t]++
+}
+
+// leave records a member of constraint i getting up from table t.
+func (o *optimizer) leave(i, t int) {
+	o.count[i][t]--
+	switch o.cons[i].Type {
+	case models.ConstraintTogether:
+		if o.count[i][t] == 0 {
+			o.used[i]--
+			o.viol[i] = max(o.used[i]-1, 0)
+		}
+	case models.ConstraintApart:
+		o.viol[i] -= o.count[i][t]
+	case models.ConstraintSide:
+		if o.wrongSide(i, t) {
+			o.viol[i]--
+		}
+	}
+}
+
+// wrongSide reports whether table t is on the other side than side
+// constraint i asks for. Tables in the middle are on both sides.
+func (o *optimizer) wrongSide(i, t int) bool {
+	want := -1
+	if o.cons[i].Side == models.SideRight {
+		want = 1
+	}
+	return o.side[t] != 0 && o.side[t] != want
+}
+
+// swapTables exchanges the guests at the same seat index of two tables,
+// leaving locked guests where they are.
+func (o *optimizer) swapTables(t1, t2 int) {
+	first1, first2 := o.firstSlot(t1), o.firstSlot(t2)
+	n := min(len(o.tables[t1].Seats), len(o.tables[t2].Seats))
+	for i := 0; i < n; i++ {
+		a, b := first1+i, first2+i
+		if ga, gb := o.occupant[a], o.occupant[b]; (ga >= 0 && o.locked[ga]) || (gb >= 0 && o.locked[gb]) {
+			continue
+		}
+		o.swapSlots(a, b)
+	}
+}
+
+func (o *optimizer) firstSlot(table int) int {
+	n := 0
+	for t := 0; t < table; t++ {
+		n += len(o.tables[t].Seats)
+	}
+	return n
+}
+
+func (o *optimizer) seat(g, s int) {
+	o.guestSlot[g] = s
+	o.occupant[s] = g
+}
+
+func (o *optimizer) setState(guestSlot []int) {
+	for i := range o.occupant {
+		o.occupant[i] = -1
+	}
+	copy(o.guestSlot, guestSlot)
+	for g, s := range o.guestSlot {
+		o.occupant[s] = g
+	}
+	o.recount()
+}
+
+// recount rebuilds the running cost from the current seating.
+func (o *optimizer) recount() {
+	o.count = make([][]int, len(o.cons))
+	for i := range o.count {
+		o.count[i] = make([]int, len(o.tables))
+	}
+	o.used = make([]int, len(o.cons))
+	o.viol = make([]int, len(o.cons))
+	o.unseated = 0
+	for g := range o.guests {
+		t := o.tableOf(g)
+		if t < 0 {
+			o.unseated++
+			continue
+		}
+		for _, i := range o.guestCons[g] {
+			o.join(i, t)
+		}
+	}
+	o.total = o.cost()
+}
+
+func (o *optimizer) tableOf(g int) int {
+	return o.slots[o.guestSlot[g]].table
+}
+
+func (o *optimizer) weight(i int) float64 {
+	if o.cons[i].Hard {
+		return hardPenalty
+	}
+	return o.cons[i].Weight
+}
+
+// cost sums the penalties of the current seating. Unseated guests count only
+// toward the unseated penalty, not toward constraint violations.
+func (o *optimizer) cost() float64 {
+	total := unseatedPenalty * float64(o.unseated)
+	for i := range o.cons {
+		total += o.weight(i) * float64(o.viol[i])
+	}
+	return total
+}
+
+func (o *optimizer) result(penalty float64) *OptimizeResult {
+	res := &OptimizeResult{
+		Result: Result{
+			Guests:    make([]models.Guest, len(o.guests)),
+			Tables:    make([]models.Table, len(o.tables)),
+			Displaced: o.displaced,
+		},
+		Penalty:       penalty,
+		HardSatisfied: true,
+		Report:        make([]models.ConstraintReport, len(o.cons)),
+	}
+
+	for i, t := range o.tables {
+		t.AssignedGuests = []string{}
+		seats := make([]models.Seat, len(t.Seats))
+		copy(seats, t.Seats)
+		t.Seats = seats
+		res.Tables[i] = t
+	}
+	for s, sl := range o.slots[:o.seats] {
+		seat := &res.Tables[sl.table].Seats[sl.seat]
+		seat.GuestID = nil
+		if g := o.occupant[s]; g >= 0 {
+			id := o.guests[g].ID
+			seat.GuestID = &id
+			res.Tables[sl.table].AssignedGuests = append(res.Tables[sl.table].AssignedGuests, id)
+		}
+	}
+	for i, g := range o.guests {
+		g.AssignedTableID, g.SeatPosition = nil, nil
+		if s := o.guestSlot[i]; s < o.seats {
+			t := res.Tables[o.slots[s].table]
+			tableID, pos := t.ID, t.Seats[o.slots[s].seat].Position
+			g.AssignedTableID, g.SeatPosition = &tableID, &pos
+		} else {
+			res.UnassignedCount++
+		}
+		res.Guests[i] = g
+	}
+
+	satisfied := 0
+	for i, c := range o.cons {
+		v := o.viol[i]
+		res.Report[i] = models.ConstraintReport{
+			Index:      i,
+			Type:       c.Type,
+			Hard:       c.Hard,
+			Satisfied:  v == 0,
+			Violations: v,
+			Guests:     len(o.members[i]),
+		}
+		if v == 0 {
+			satisfied++
+		} else if c.Hard {
+			res.HardSatisfied = false
+		}
+	}
+
+	seated := len(o.guests) - res.UnassignedCount
+	res.Success = res.HardSatisfied && res.UnassignedCount == 0
+	res.Message = fmt.Sprintf("Seated %d of %d guests; %d/%d constraints satisfied", seated, len(o.guests), satisfied, len(o.cons))
+	if len(o.displaced) > 0 {
+		res.Message += fmt.Sprintf(". Pinned guests moved: %d", len(o.displaced))
+	}
+	return res
+}
@@ -0,0 +1,221 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/frallan97/table-planner-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func guestIDs(guests ...models.Guest) []uuid.UUID {
+	ids := make([]uuid.UUID, len(guests))
+	for i, g := range guests {
+		ids[i] = uuid.MustParse(g.ID)
+	}
+	return ids
+}
+
+func tableOf(res *OptimizeResult, g int) string {
+	if res.Guests[g].AssignedTableID == nil {
+		return ""
+	}
+	return *res.Guests[g].AssignedTableID
+}
+
+func TestOptimize_TogetherAndApart(t *testing.T) {
+	var guests []models.Guest
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		guests = append(guests, makeGuest(name))
+	}
+	left := makeTable(models.TableTypeRound, 3)
+	right := makeTable(models.TableTypeRound, 3)
+	right.Position.X = 500
+
+	// A, C and E start at different tables from the initial fill
+	opts := Options{
+		Constraints: []models.SeatingConstraint{
+			{Type: models.ConstraintTogether, Guests: models.GuestSelector{GuestIDs: guestIDs(guests[0], guests[3], guests[4])}, Hard: true},
+			{Type: models.ConstraintApart, Guests: models.GuestSelector{GuestIDs: guestIDs(guests[0], guests[1])}, Hard: true},
+			{Type: models.ConstraintSide, Side: models.SideRight, Guests: models.GuestSelector{GuestIDs: guestIDs(guests[1])}, Weight: 1},
+		},
+		Iterations: 5000,
+	}
+
+	res, err := Optimize(context.Background(), guests, []models.Table{left, right}, opts, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Success || !res.HardSatisfied || res.Penalty != 0 {
+		t.Fatalf("expected all constraints met, got %+v", res.Report)
+	}
+	if tableOf(res, 0) != tableOf(res, 3) || tableOf(res, 0) != tableOf(res, 4) {
+		t.Error("expected A, D and E at the same table")
+	}
+	if tableOf(res, 0) == tableOf(res, 1) {
+		t.Error("expected A and B at different tables")
+	}
+	if tableOf(res, 1) != right.ID {
+		t.Error("expected B on the right")
+	}
+	for _, r := range res.Report {
+		if !r.Satisfied {
+			t.Errorf("constraint %d not satisfied: %+v", r.Index, r)
+		}
+	}
+}
+
+func TestOptimize_LockedGuestsStay(t *testing.T) {
+	a, b := makeGuest("A"), makeGuest("B")
+	t1, t2 := makeTable(models.TableTypeRound, 2), makeTable(models.TableTypeRound, 2)
+	seat := 1
+	a.AssignedTableID, a.SeatPosition = &t2.ID, &seat
+
+	// Together would be cheapest by moving A, but A is locked
+	opts := Options{
+		Constraints: []models.SeatingConstraint{
+			{Type: models.ConstraintTogether, Guests: models.GuestSelector{GuestIDs: guestIDs(a, b)}, Weight: 1},
+		},
+		Locked:     guestIDs(a),
+		Iterations: 2000,
+	}
+	res, err := Optimize(context.Background(), []models.Guest{a, b}, []models.Table{t1, t2}, opts, rand.New(rand.NewSource(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tableOf(res, 0) != t2.ID || *res.Guests[0].SeatPosition != 1 {
+		t.Errorf("expected locked guest to keep seat 1 at T2, got %+v", res.Guests[0])
+	}
+	if tableOf(res, 1) != t2.ID {
+		t.Errorf("expected B to join A, got %s", tableOf(res, 1))
+	}
+}
+
+func TestOptimize_PinnedGuestsStay(t *testing.T) {
+	a, b := makeGuest("A"), makeGuest("B")
+	t1, t2 := makeTable(models.TableTypeRound, 2), makeTable(models.TableTypeRound, 2)
+	seat := 0
+	a.AssignedTableID, a.SeatPosition, a.Pinned = &t1.ID, &seat, true
+	t1.Seats[0].GuestID = &a.ID
+
+	// Moving A to the right table would satisfy the constraint, but A is pinned
+	opts := Options{
+		Constraints: []models.SeatingConstraint{
+			{Type: models.ConstraintSide, Side: models.SideRight, Guests: models.GuestSelector{GuestIDs: guestIDs(a)}, Weight: 1},
+		},
+		Iterations: 2000,
+	}
+	t2.Position.X = 500
+	res, err := Optimize(context.Background(), []models.Guest{a, b}, []models.Table{t1, t2}, opts, rand.New(rand.NewSource(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tableOf(res, 0) != t1.ID || *res.Guests[0].SeatPosition != 0 {
+		t.Errorf("expected pinned guest to keep seat 0 at T1, got %+v", res.Guests[0])
+	}
+}
+
+func TestOptimize_DisplacedPinnedGuestSeated(t *testing.T) {
+	a, b := makeGuest("A"), makeGuest("B")
+	t1, t2 := makeTable(models.TableTypeRound, 1), makeTable(models.TableTypeRound, 1)
+	seat := 0
+	a.AssignedTableID, a.SeatPosition, a.Pinned = &t1.ID, &seat, true
+	b.AssignedTableID, b.SeatPosition, b.Pinned = &t1.ID, &seat, true
+	t1.Seats[0].GuestID, t1.Seats[0].Pinned = &a.ID, true
+
+	res, err := Optimize(context.Background(), []models.Guest{a, b}, []models.Table{t1, t2}, Options{Iterations: 100}, rand.New(rand.NewSource(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tableOf(res, 0) != t1.ID {
+		t.Errorf("expected A to keep the pinned seat, got %s", tableOf(res, 0))
+	}
+	if tableOf(res, 1) != t2.ID || res.UnassignedCount != 0 {
+		t.Errorf("expected B seated at T2, got %q", tableOf(res, 1))
+	}
+	if len(res.Displaced) != 1 || res.Displaced[0] != b.ID {
+		t.Errorf("expected B displaced, got %v", res.Displaced)
+	}
+}
+
+func TestOptimize_UnsatisfiableReported(t *testing.T) {
+	vegan := []models.DietaryRestriction{models.DietaryVegan}
+	var guests []models.Guest
+	for _, name := range []string{"A", "B", "C"} {
+		g := makeGuest(name)
+		g.DietaryRestrictions = vegan
+		guests = append(guests, g)
+	}
+	tables := []models.Table{makeTable(models.TableTypeRound, 2), makeTable(models.TableTypeRound, 2)}
+
+	opts := Options{
+		Constraints: []models.SeatingConstraint{
+			{Type: models.ConstraintTogether, Guests: models.GuestSelector{Dietary: models.DietaryVegan}, Hard: true},
+		},
+		Iterations: 2000,
+	}
+	res, err := Optimize(context.Background(), guests, tables, opts, rand.New(rand.NewSource(5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Seating all three vegans together is impossible, so one is left unseated
+	if !res.HardSatisfied || res.UnassignedCount != 1 || res.Success {
+		t.Errorf("expected hard constraint kept by leaving one guest unseated, got %+v", res)
+	}
+	if res.Report[0].Guests != 3 {
+		t.Errorf("expected selector to match 3 guests, got %d", res.Report[0].Guests)
+	}
+}
+
+func TestOptimize_Canceled(t *testing.T) {
+	a, b := makeGuest("A"), makeGuest("B")
+	opts := Options{
+		Constraints: []models.SeatingConstraint{
+			{Type: models.ConstraintApart, Guests: models.GuestSelector{GuestIDs: guestIDs(a, b)}, Hard: true},
+		},
+		Iterations: 2000,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Optimize(ctx, []models.Guest{a, b}, []models.Table{makeTable(models.TableTypeRound, 2)}, opts, rand.New(rand.NewSource(1)))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the search to stop, got %v", err)
+	}
+}
+
+func TestOptimizer_RunningCostMatchesRecount(t *testing.T) {
+	var guests []models.Guest
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		guests = append(guests, makeGuest(name))
+	}
+	tables := []models.Table{makeTable(models.TableTypeRound, 2), makeTable(models.TableTypeRound, 3), makeTable(models.TableTypeRound, 1)}
+	tables[2].Position.X = 500
+	o := newOptimizer(guests, tables, Options{Constraints: []models.SeatingConstraint{
+		{Type: models.ConstraintTogether, Guests: models.GuestSelector{GuestIDs: guestIDs(guests[0], guests[1], guests[2])}, Weight: 1.5},
+		{Type: models.ConstraintApart, Guests: models.GuestSelector{GuestIDs: guestIDs(guests[2], guests[3], guests[4])}, Hard: true},
+		{Type: models.ConstraintSide, Side: models.SideRight, Guests: models.GuestSelector{GuestIDs: guestIDs(guests[5], guests[6])}, Weight: 2},
+	}})
+
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 500; i++ {
+		if i%10 == 0 {
+			o.swapTables(rng.Intn(len(tables)), rng.Intn(len(tables)))
+		} else {
+			o.swapSlots(rng.Intn(len(o.slots)), rng.Intn(len(o.slots)))
+		}
+		running := o.total
+		o.recount()
+		if math.Abs(running-o.total) > 1e-9 {
+			t.Fatalf("step %d: running cost %v, recounted %v", i, running, o.total)
+		}
+	}
+}
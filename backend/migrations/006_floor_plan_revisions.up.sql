@@ -0,0 +1,20 @@
+-- Full snapshot of a floor plan's entities at every committed version
+CREATE TABLE floor_plan_revisions (
+    floor_plan_id UUID NOT NULL REFERENCES floor_plans(id) ON DELETE CASCADE,
+    version       INTEGER NOT NULL,
+    created_by    UUID NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    restored_from INTEGER,
+    tables        JSONB NOT NULL DEFAULT '[]',
+    guests        JSONB NOT NULL DEFAULT '[]',
+    labels        JSONB NOT NULL DEFAULT '[]',
+    PRIMARY KEY (floor_plan_id, version)
+);
+
+-- Seed history with the current state of existing plans
+INSERT INTO floor_plan_revisions (floor_plan_id, version, created_by, created_at, tables, guests, labels)
+SELECT fp.id, fp.version, fp.user_id, fp.updated_at,
+       COALESCE((SELECT jsonb_agg(t.data) FROM floor_plan_tables t WHERE t.floor_plan_id = fp.id), '[]'),
+       COALESCE((SELECT jsonb_agg(g.data) FROM floor_plan_guests g WHERE g.floor_plan_id = fp.id), '[]'),
+       COALESCE((SELECT jsonb_agg(l.data) FROM floor_plan_labels l WHERE l.floor_plan_id = fp.id), '[]')
+FROM floor_plans fp;
@@ -0,0 +1,3 @@
+ALTER TABLE floor_plan_labels DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS updated_by, DROP COLUMN IF EXISTS version;
+ALTER TABLE floor_plan_guests DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS updated_by, DROP COLUMN IF EXISTS version;
+ALTER TABLE floor_plan_tables DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS updated_by, DROP COLUMN IF EXISTS version;
@@ -0,0 +1,15 @@
+-- Per-entity optimistic concurrency for tables, guests and labels
+ALTER TABLE floor_plan_tables
+    ADD COLUMN version    INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN updated_by UUID,
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE floor_plan_guests
+    ADD COLUMN version    INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN updated_by UUID,
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE floor_plan_labels
+    ADD COLUMN version    INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN updated_by UUID,
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
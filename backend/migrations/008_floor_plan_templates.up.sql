@@ -0,0 +1,15 @@
+-- Reusable table and label layouts published to an organization
+CREATE TABLE floor_plan_templates (
+    id                   UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id      UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name                 TEXT NOT NULL CHECK (char_length(name) >= 1 AND char_length(name) <= 200),
+    description          TEXT NOT NULL DEFAULT '',
+    source_floor_plan_id UUID REFERENCES floor_plans(id) ON DELETE SET NULL,
+    tables               JSONB NOT NULL DEFAULT '[]',
+    labels               JSONB NOT NULL DEFAULT '[]',
+    created_by           UUID NOT NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_floor_plan_templates_org ON floor_plan_templates(organization_id);
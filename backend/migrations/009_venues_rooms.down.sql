@@ -0,0 +1,3 @@
+ALTER TABLE floor_plans DROP COLUMN IF EXISTS room_id;
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS venues;
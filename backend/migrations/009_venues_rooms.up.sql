@@ -0,0 +1,30 @@
+-- Venues and the rooms floor plans are laid out in
+CREATE TABLE venues (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         UUID NOT NULL,
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL CHECK (char_length(name) >= 1 AND char_length(name) <= 200),
+    address         TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_venues_user ON venues(user_id);
+CREATE INDEX idx_venues_org ON venues(organization_id);
+
+-- Room geometry is in floor plan coordinates; scale converts it to units
+CREATE TABLE rooms (
+    id         UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    venue_id   UUID NOT NULL REFERENCES venues(id) ON DELETE CASCADE,
+    name       TEXT NOT NULL CHECK (char_length(name) >= 1 AND char_length(name) <= 200),
+    units      TEXT NOT NULL DEFAULT 'm' CHECK (units IN ('m', 'ft')),
+    scale      DOUBLE PRECISION NOT NULL DEFAULT 100 CHECK (scale > 0),
+    outline    JSONB NOT NULL DEFAULT '[]',
+    obstacles  JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_rooms_venue ON rooms(venue_id);
+
+ALTER TABLE floor_plans ADD COLUMN room_id UUID REFERENCES rooms(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE floor_plans DROP COLUMN IF EXISTS event_id;
+DROP TABLE IF EXISTS event_guests;
+DROP TABLE IF EXISTS events;
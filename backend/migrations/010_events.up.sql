@@ -0,0 +1,28 @@
+-- Events group the floor plans of an occasion around one guest list
+CREATE TABLE events (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         UUID NOT NULL,
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL CHECK (char_length(name) >= 1 AND char_length(name) <= 200),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_events_user ON events(user_id);
+CREATE INDEX idx_events_org ON events(organization_id);
+
+-- The canonical guests; floor plans in the event hold copies linked by eventGuestId
+CREATE TABLE event_guests (
+    id                   UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id             UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name                 TEXT NOT NULL CHECK (char_length(name) >= 1 AND char_length(name) <= 200),
+    dietary_restrictions JSONB NOT NULL DEFAULT '[]',
+    guest_of             UUID REFERENCES event_guests(id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_event_guests_event ON event_guests(event_id);
+
+ALTER TABLE floor_plans ADD COLUMN event_id UUID REFERENCES events(id) ON DELETE SET NULL;
+CREATE INDEX idx_floor_plans_event ON floor_plans(event_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_floor_plan_guests_contact;
+DROP TABLE IF EXISTS organization_contacts;
@@ -0,0 +1,21 @@
+-- The guest directory of an organization; floor plan guests pulled from it
+-- reference their contact by contactId
+CREATE TABLE organization_contacts (
+    id                   UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id      UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name                 TEXT NOT NULL CHECK (char_length(name) >= 1 AND char_length(name) <= 200),
+    email                TEXT NOT NULL DEFAULT '',
+    dietary_restrictions JSONB NOT NULL DEFAULT '[]',
+    tags                 TEXT[] NOT NULL DEFAULT '{}',
+    notes                TEXT NOT NULL DEFAULT '',
+    created_by           UUID NOT NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organization_contacts_org ON organization_contacts(organization_id, name);
+CREATE INDEX idx_organization_contacts_tags ON organization_contacts USING GIN (tags);
+
+-- Finds the floor plans a contact was pulled into
+CREATE INDEX idx_floor_plan_guests_contact ON floor_plan_guests ((data->>'contactId'))
+    WHERE data ? 'contactId';
@@ -0,0 +1,14 @@
+-- Personal RSVP links, each scoped to one guest of a floor plan
+CREATE TABLE guest_rsvp_tokens (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    floor_plan_id UUID NOT NULL REFERENCES floor_plans(id) ON DELETE CASCADE,
+    guest_id      UUID NOT NULL,
+    token         TEXT NOT NULL UNIQUE,
+    created_by    UUID NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ,
+    is_active     BOOLEAN NOT NULL DEFAULT true,
+    responded_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_rsvp_tokens_guest ON guest_rsvp_tokens(floor_plan_id, guest_id);
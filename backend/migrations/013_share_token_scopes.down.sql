@@ -0,0 +1 @@
+ALTER TABLE floor_plan_share_tokens DROP COLUMN IF EXISTS scope;
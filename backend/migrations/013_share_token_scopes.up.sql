@@ -0,0 +1,8 @@
+-- How much of the plan a share link shows: layout (no guests), names (no
+-- dietary restrictions) or full.
+--
+-- Existing links become names links, so they stop showing guests' dietary
+-- restrictions once this runs. Creators who want them shown again must
+-- create a new link with the full scope.
+ALTER TABLE floor_plan_share_tokens ADD COLUMN scope TEXT NOT NULL DEFAULT 'names'
+    CHECK (scope IN ('layout', 'names', 'full'));
@@ -307,8 +307,8 @@ function QRSharePrint({
         if (existing.token) {
           setShareUrl(`${window.location.origin}/share/${existing.token}`);
         } else {
-          // Create new token
-          const created = await api.createShareToken(floorPlanId!);
+          // Create new token; guests scanning it need names to find their seat
+          const created = await api.createShareToken(floorPlanId!, "names");
           setShareUrl(`${window.location.origin}/share/${created.token}`);
         }
       } catch (err) {
@@ -1,8 +1,14 @@
 import { useState, useEffect } from "react";
-import { api, type OrganizationWithRole } from "@/lib/api";
+import { api, type OrganizationWithRole, type ShareScope } from "@/lib/api";
 import { Share2, Building2, UserCircle, ChevronDown, Loader2, Link2, Copy, X, QrCode } from "lucide-react";
 import { QRCodeSVG } from "qrcode.react";
 
+const SHARE_SCOPES: { value: ShareScope; label: string; description: string }[] = [
+  { value: "layout", label: "Layout", description: "Tables only, no guests" },
+  { value: "names", label: "Names", description: "Guests' names and seats" },
+  { value: "full", label: "Full", description: "Everything, including dietary restrictions" },
+];
+
 interface ShareControlProps {
   floorPlanId: string;
   currentOrgId?: string;
@@ -25,6 +31,7 @@ export function ShareControl({
   const [loading, setLoading] = useState(false);
   const [shareToken, setShareToken] = useState<string | null>(null);
   const [tokenExpiresAt, setTokenExpiresAt] = useState<string | null>(null);
+  const [tokenScope, setTokenScope] = useState<ShareScope>("names");
   const [tokenLoading, setTokenLoading] = useState(false);
   const [copied, setCopied] = useState(false);
 
@@ -40,6 +47,7 @@ export function ShareControl({
       const res = await api.getShareToken(floorPlanId);
       setShareToken(res.token);
       setTokenExpiresAt(res.expiresAt ?? null);
+      if (res.scope) setTokenScope(res.scope);
     } catch {
       // ignore
     }
@@ -48,9 +56,10 @@ export function ShareControl({
   const handleCreateToken = async () => {
     setTokenLoading(true);
     try {
-      const res = await api.createShareToken(floorPlanId);
+      const res = await api.createShareToken(floorPlanId, tokenScope);
       setShareToken(res.token);
       setTokenExpiresAt(res.expiresAt ?? null);
+      setTokenScope(res.scope);
     } catch (err) {
       console.error("Failed to create share token:", err);
     } finally {
@@ -215,6 +224,9 @@ export function ShareControl({
             </div>
             {shareToken && shareUrl ? (
               <div className="px-3 pb-2 space-y-2">
+                <p className="text-[10px] text-muted-foreground">
+                  Shows: {SHARE_SCOPES.find((s) => s.value === tokenScope)?.description}
+                </p>
                 <div className="flex items-center gap-1">
                   <div className="flex-1 text-xs bg-muted/50 rounded px-2 py-1.5 truncate font-mono">
                     {shareUrl}
@@ -249,6 +261,22 @@ export function ShareControl({
               </div>
             ) : (
               <div className="px-3 pb-2">
+                <div className="flex gap-1 mb-2" role="radiogroup" aria-label="What the link shows">
+                  {SHARE_SCOPES.map((scope) => (
+                    <button
+                      key={scope.value}
+                      role="radio"
+                      aria-checked={tokenScope === scope.value}
+                      onClick={() => setTokenScope(scope.value)}
+                      title={scope.description}
+                      className={`flex-1 px-2 py-1 text-xs border rounded transition-colors ${
+                        tokenScope === scope.value ? "bg-primary text-primary-foreground border-primary" : "hover:bg-muted"
+                      }`}
+                    >
+                      {scope.label}
+                    </button>
+                  ))}
+                </div>
                 <button
                   onClick={handleCreateToken}
                   disabled={tokenLoading}
@@ -3,7 +3,7 @@ import { useGuests } from "./useGuests";
 import { useTables } from "./useTables";
 import { useLabels } from "./useLabels";
 import { useAuth } from "./useAuth";
-import { api } from "@/lib/api";
+import { api, subscribeFloorPlanEvents } from "@/lib/api";
 import type { Table, Guest, FloorLabel } from "@/lib/types";
 
 type GuestsState = ReturnType<typeof useGuests>;
@@ -41,7 +41,7 @@ const PlannerContext = createContext<PlannerContextValue | null>(null);
 const SAVE_DEBOUNCE_MS = 2000;
 
 export function PlannerProvider({ children }: { children: React.ReactNode }) {
-  const { isAuthenticated } = useAuth();
+  const { isAuthenticated, user } = useAuth();
   const guestsHook = useGuests();
   const tablesHook = useTables();
   const labelsHook = useLabels();
@@ -112,9 +112,16 @@ export function PlannerProvider({ children }: { children: React.ReactNode }) {
     isLoadingRef.current = true;
     try {
       const data = await api.getFloorPlan(id);
-      tablesHook.setTables((data.tables || []) as Table[]);
-      guestsHook.setGuests((data.guests || []) as Guest[]);
-      labelsHook.setLabels((data.labels || []) as FloorLabel[]);
+      const loaded: Snapshot = {
+        tables: (data.tables || []) as Table[],
+        guests: (data.guests || []) as Guest[],
+        labels: (data.labels || []) as FloorLabel[],
+      };
+      tablesHook.setTables(loaded.tables);
+      guestsHook.setGuests(loaded.guests);
+      labelsHook.setLabels(loaded.labels);
+      // What was loaded is what the server has
+      lastSavedDataRef.current = JSON.stringify(loaded);
       setVersion(data.version);
       setHasConflict(false);
       undoStackRef.current = [];
@@ -150,6 +157,8 @@ export function PlannerProvider({ children }: { children: React.ReactNode }) {
   // Debounced save to API
   const saveTimerRef = useRef<ReturnType<typeof setTimeout> | null>(null);
   const lastSavedDataRef = useRef<string>("");
+  const latestRef = useRef<Snapshot>({ tables: curTables, guests: curGuests, labels: curLabels });
+  latestRef.current = { tables: curTables, guests: curGuests, labels: curLabels };
 
   useEffect(() => {
     if (!isAuthenticated || !currentFloorPlanId || isLoadingRef.current || hasConflict || version === null) return;
@@ -166,11 +175,15 @@ export function PlannerProvider({ children }: { children: React.ReactNode }) {
     saveTimerRef.current = setTimeout(async () => {
       setIsSaving(true);
       try {
+        // Edits like deleting a table leave guests assigned to it; the server
+        // fixes such seating and returns what it stored, as it does after
+        // merging in others' changes
         const result = await api.bulkSave(currentFloorPlanId, {
           version,
           tables: curTables,
           guests: curGuests,
           labels: curLabels,
+          repair: true,
         });
         if (result.status === "conflict") {
           setHasConflict(true);
@@ -178,7 +191,25 @@ export function PlannerProvider({ children }: { children: React.ReactNode }) {
           if (result.version !== undefined) {
             setVersion(result.version);
           }
-          lastSavedDataRef.current = dataStr;
+          if (result.status === "merged" && JSON.stringify(latestRef.current) !== dataStr) {
+            // The merge brought in others' changes, which the edits made
+            // while saving don't have; saving those would undo the merge
+            setHasConflict(true);
+          } else if (result.tables && result.guests && result.labels) {
+            const stored: Snapshot = {
+              tables: result.tables as Table[],
+              guests: result.guests as Guest[],
+              labels: result.labels as FloorLabel[],
+            };
+            tablesHook.setTables(stored.tables);
+            guestsHook.setGuests(stored.guests);
+            labelsHook.setLabels(stored.labels);
+            lastSavedDataRef.current = JSON.stringify(stored);
+          } else if (result.status === "merged") {
+            loadFloorPlan(currentFloorPlanId);
+          } else {
+            lastSavedDataRef.current = dataStr;
+          }
           setLastSaved(new Date());
         }
       } catch (err) {
@@ -193,7 +224,25 @@ export function PlannerProvider({ children }: { children: React.ReactNode }) {
         clearTimeout(saveTimerRef.current);
       }
     };
-  }, [curTables, curGuests, curLabels, currentFloorPlanId, isAuthenticated, hasConflict, version]);
+  }, [curTables, curGuests, curLabels, currentFloorPlanId, isAuthenticated, hasConflict, version, loadFloorPlan]);
+
+  // Follow saves made by others: reload when there is nothing unsaved here,
+  // otherwise flag the conflict so the planner can choose when to reload
+  const versionRef = useRef(version);
+  versionRef.current = version;
+
+  useEffect(() => {
+    if (!isAuthenticated || !currentFloorPlanId) return;
+    return subscribeFloorPlanEvents(currentFloorPlanId, (event) => {
+      if (event.type !== "saved" || event.userId === user?.id) return;
+      if (event.version === undefined || versionRef.current === null || event.version <= versionRef.current) return;
+      if (JSON.stringify(latestRef.current) !== lastSavedDataRef.current) {
+        setHasConflict(true);
+      } else {
+        loadFloorPlan(currentFloorPlanId);
+      }
+    });
+  }, [currentFloorPlanId, isAuthenticated, user?.id, loadFloorPlan]);
 
   const resolveConflict = useCallback(() => {
     if (currentFloorPlanId) {
@@ -28,11 +28,22 @@ export function useFloorPlanPresence(floorPlanId: string | null) {
 
     intervalRef.current = setInterval(sendHeartbeat, HEARTBEAT_INTERVAL);
 
+    // Leave when the page goes away, and come back if it is restored
+    const onPageHide = () => api.leavePresence(floorPlanId);
+    const onPageShow = (e: PageTransitionEvent) => {
+      if (e.persisted) sendHeartbeat();
+    };
+    window.addEventListener("pagehide", onPageHide);
+    window.addEventListener("pageshow", onPageShow);
+
     return () => {
       if (intervalRef.current) {
         clearInterval(intervalRef.current);
         intervalRef.current = null;
       }
+      window.removeEventListener("pagehide", onPageHide);
+      window.removeEventListener("pageshow", onPageShow);
+      api.leavePresence(floorPlanId);
     };
   }, [floorPlanId, sendHeartbeat]);
 
@@ -183,9 +183,112 @@ export interface FloorPlanPresence {
   lastSeenAt: string;
 }
 
+export interface EntityChanges {
+  upserted: string[];
+  deleted: string[];
+}
+
+// A change to a floor plan from its event stream. Saves list the entities
+// they touched unless truncated, when the whole plan should be refetched.
+export interface FloorPlanEvent {
+  type: "saved" | "presence.joined" | "presence.left";
+  floorPlanId: string;
+  userId: string;
+  userEmail?: string;
+  version?: number;
+  changes?: { tables: EntityChanges; guests: EntityChanges; labels: EntityChanges };
+  truncated?: boolean;
+}
+
+const EVENT_RECONNECT_MS = 5000;
+
+/**
+ * Subscribe to a floor plan's Server-Sent Events. EventSource can't send the
+ * Authorization header, so the stream is read with fetch instead. Reconnects
+ * after the stream ends or fails; call the returned function to stop.
+ */
+export function subscribeFloorPlanEvents(fpId: string, onEvent: (event: FloorPlanEvent) => void): () => void {
+  const controller = new AbortController();
+  let retryTimer: ReturnType<typeof setTimeout> | null = null;
+
+  const connect = async () => {
+    try {
+      let response = await openEventStream(fpId, controller.signal);
+      if (response.status === 401 && (await tryRefreshToken())) {
+        response = await openEventStream(fpId, controller.signal);
+      }
+      if (response.status === 401) {
+        onUnauthorized?.();
+        return;
+      }
+      if (response.status === 403) {
+        // Access to the plan was revoked
+        return;
+      }
+      if (!response.ok || !response.body) {
+        throw new Error(`API error ${response.status}`);
+      }
+      await readEventStream(response.body, onEvent);
+    } catch (err) {
+      if (controller.signal.aborted) return;
+      console.error("Floor plan event stream failed:", err);
+    }
+    if (!controller.signal.aborted) {
+      retryTimer = setTimeout(connect, EVENT_RECONNECT_MS);
+    }
+  };
+  connect();
+
+  return () => {
+    controller.abort();
+    if (retryTimer) clearTimeout(retryTimer);
+  };
+}
+
+function openEventStream(fpId: string, signal: AbortSignal): Promise<Response> {
+  const headers: Record<string, string> = { Accept: "text/event-stream" };
+  if (accessToken) {
+    headers["Authorization"] = `Bearer ${accessToken}`;
+  }
+  return fetch(`${API_BASE}/api/floor-plans/${fpId}/events`, { headers, signal });
+}
+
+// readEventStream parses "data:" lines of each event until the stream ends.
+// Comments such as keep-alives are skipped.
+async function readEventStream(body: ReadableStream<Uint8Array>, onEvent: (event: FloorPlanEvent) => void) {
+  const reader = body.pipeThrough(new TextDecoderStream()).getReader();
+  let buffer = "";
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) return;
+    buffer += value;
+    let end: number;
+    while ((end = buffer.indexOf("\n\n")) >= 0) {
+      const frame = buffer.slice(0, end);
+      buffer = buffer.slice(end + 2);
+      const data = frame
+        .split("\n")
+        .filter((line) => line.startsWith("data:"))
+        .map((line) => line.slice(5).trimStart())
+        .join("\n");
+      if (!data) continue;
+      try {
+        onEvent(JSON.parse(data) as FloorPlanEvent);
+      } catch {
+        // Ignore events we can't parse
+      }
+    }
+  }
+}
+
+// How much of a floor plan a share link shows: tables only, guests' names
+// and seats, or everything including dietary restrictions.
+export type ShareScope = "layout" | "names" | "full";
+
 export interface ShareToken {
   token: string | null;
   expiresAt?: string | null;
+  scope?: ShareScope;
 }
 
 export interface PublicFloorPlan {
@@ -195,14 +298,18 @@ export interface PublicFloorPlan {
   guests: unknown[];
   labels: unknown[];
   organizationName?: string;
+  scope: ShareScope;
 }
 
 export interface BulkSaveResult {
   status: string;
   version?: number;
+  // Set when the server's copy differs from what was sent, e.g. after it
+  // repaired seat assignments
   tables?: unknown[];
   guests?: unknown[];
   labels?: unknown[];
+  repairs?: { field: string; message: string }[];
   error?: string;
 }
 
@@ -246,7 +353,10 @@ export const api = {
     });
   },
 
-  async bulkSave(id: string, data: { version: number; tables: unknown[]; guests: unknown[]; labels: unknown[] }): Promise<BulkSaveResult> {
+  async bulkSave(
+    id: string,
+    data: { version: number; tables: unknown[]; guests: unknown[]; labels: unknown[]; repair?: boolean }
+  ): Promise<BulkSaveResult> {
     const headers: Record<string, string> = {
       "Content-Type": "application/json",
     };
@@ -293,9 +403,13 @@ export const api = {
   },
 
   // Share tokens
-  createShareToken(fpId: string): Promise<{ token: string; expiresAt?: string }> {
+  createShareToken(
+    fpId: string,
+    scope?: ShareScope
+  ): Promise<{ token: string; expiresAt?: string; scope: ShareScope }> {
     return request(`/api/floor-plans/${fpId}/share-token`, {
       method: "POST",
+      body: scope ? JSON.stringify({ scope }) : undefined,
     });
   },
 
@@ -343,6 +457,15 @@ export const api = {
     return request(`/api/floor-plans/${fpId}/presence`);
   },
 
+  // Sent with keepalive so it still goes out while the page unloads
+  leavePresence(fpId: string): void {
+    const headers: Record<string, string> = {};
+    if (accessToken) {
+      headers["Authorization"] = `Bearer ${accessToken}`;
+    }
+    fetch(`${API_BASE}/api/floor-plans/${fpId}/presence`, { method: "DELETE", headers, keepalive: true }).catch(() => {});
+  },
+
   // Organizations
   listOrganizations(): Promise<OrganizationWithRole[]> {
     return request("/api/organizations");
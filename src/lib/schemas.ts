@@ -16,6 +16,7 @@ export const seatSchema = z.object({
   position: z.number(),
   guestId: z.string().nullable(),
   label: z.string(),
+  pinned: z.boolean().optional(),
 });
 
 export const tableSchema = z.object({
@@ -43,6 +44,7 @@ export const guestSchema = z.object({
   seatPosition: z.number().nullable(),
   guestOf: z.string().nullable(),
   createdAt: z.string().or(z.date()),
+  pinned: z.boolean().optional(),
 });
 
 export const floorLabelSchema = z.object({
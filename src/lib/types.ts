@@ -14,6 +14,7 @@ export interface Guest {
   seatPosition: number | null;
   guestOf: string | null;
   createdAt: Date;
+  pinned?: boolean;
 }
 
 export enum TableType {
@@ -26,6 +27,7 @@ export interface Seat {
   position: number;
   guestId: string | null;
   label: string;
+  pinned?: boolean;
 }
 
 export interface Table {